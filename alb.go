@@ -0,0 +1,157 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// StartALB starts handling Application Load Balancer requests by passing
+// each request to the HTTP handler. The target group can be configured
+// with or without multi-value headers: the response uses the same
+// format as the request.
+func StartALB(h http.Handler, opts ...Option) {
+	o := newOptions(opts)
+	startLambda(o, withSpan(o, albHandler(o.wrap(h), o)))
+}
+
+// ALBRequest returns a pointer to the Application Load Balancer request, or
+// nil if the current context is not associated with an ALB request.
+func ALBRequest(ctx context.Context) *events.ALBTargetGroupRequest {
+	request, _ := ctx.Value(ctxKeyALB).(*events.ALBTargetGroupRequest)
+	return request
+}
+
+func albHandler(h http.Handler, o *options) func(ctx context.Context, request events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return func(ctx context.Context, request events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+		inv := newInvocation(o)
+		defer inv.release()
+		multiValue := request.MultiValueHeaders != nil
+		r, err := newALBRequest(ctx, inv, &request)
+		if err != nil {
+			response, err := inv.fail(err)
+			return albResponse(&response, multiValue), err
+		}
+		w := inv.serve(h, r)
+		if err := inv.complete(r); err != nil {
+			response, err := inv.fail(err)
+			return albResponse(&response, multiValue), err
+		}
+		return albResponse(&w.response2, multiValue), w.err
+	}
+}
+
+// newALBRequest converts the ALB request into the equivalent API Gateway
+// proxy request, so that the HTTP request is created in the same way
+// for both event sources.
+func newALBRequest(ctx context.Context, inv *invocation, request *events.ALBTargetGroupRequest) (*http.Request, error) {
+	headers := request.Headers
+	if request.MultiValueHeaders != nil {
+		headers = make(map[string]string, len(request.MultiValueHeaders))
+		for k, vv := range request.MultiValueHeaders {
+			sep := ","
+			if strings.EqualFold(k, "Cookie") {
+				sep = "; "
+			}
+			headers[k] = strings.Join(vv, sep)
+		}
+	}
+
+	// Unlike API Gateway, ALB does not decode the query string parameters,
+	// so they are added to the path as they are.
+	path := request.Path
+	if query := albQuery(request); query != "" {
+		path += "?" + query
+	}
+
+	// ALB appends the client IP address to X-Forwarded-For
+	var sourceIP string
+	for k, v := range headers {
+		if strings.EqualFold(k, "X-Forwarded-For") {
+			sourceIP = strings.TrimSpace(v[strings.LastIndexByte(v, ',')+1:])
+			break
+		}
+	}
+
+	inv.request = events.APIGatewayProxyRequest{
+		HTTPMethod:      request.HTTPMethod,
+		Path:            path,
+		Headers:         headers,
+		Body:            request.Body,
+		IsBase64Encoded: request.IsBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			HTTPMethod: request.HTTPMethod,
+			Identity:   events.APIGatewayRequestIdentity{SourceIP: sourceIP},
+		},
+	}
+	return inv.newRequest(ctx, ctxKeyALB, request)
+}
+
+// albQuery returns the raw query string of the request, with the
+// parameters sorted by name so the result is deterministic.
+func albQuery(request *events.ALBTargetGroupRequest) string {
+	params := request.MultiValueQueryStringParameters
+	if params == nil && len(request.QueryStringParameters) > 0 {
+		params = make(map[string][]string, len(request.QueryStringParameters))
+		for k, v := range request.QueryStringParameters {
+			params[k] = []string{v}
+		}
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		for _, v := range params[k] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(k)
+			b.WriteByte('=')
+			b.WriteString(v)
+		}
+	}
+	return b.String()
+}
+
+// albResponse converts the response to the ALB format. If multiValue is
+// true, all headers are returned as multi-value headers, otherwise only
+// the last value of each header is returned.
+func albResponse(response *apiGatewayProxyResponse, multiValue bool) events.ALBTargetGroupResponse {
+	alb := events.ALBTargetGroupResponse{
+		StatusCode:        response.StatusCode,
+		StatusDescription: strconv.Itoa(response.StatusCode) + " " + http.StatusText(response.StatusCode),
+		Body:              response.Body,
+		IsBase64Encoded:   response.IsBase64Encoded,
+	}
+	if multiValue {
+		alb.MultiValueHeaders = make(map[string][]string, len(response.Headers)+len(response.MultiValueHeaders))
+		for k, v := range response.Headers {
+			alb.MultiValueHeaders[k] = []string{v}
+		}
+		for k, vv := range response.MultiValueHeaders {
+			alb.MultiValueHeaders[k] = vv
+		}
+		return alb
+	}
+	alb.Headers = response.Headers
+	if len(response.MultiValueHeaders) > 0 {
+		alb.Headers = make(map[string]string, len(response.Headers)+len(response.MultiValueHeaders))
+		for k, v := range response.Headers {
+			alb.Headers[k] = v
+		}
+		for k, vv := range response.MultiValueHeaders {
+			alb.Headers[k] = vv[len(vv)-1]
+		}
+	}
+	return alb
+}
@@ -0,0 +1,86 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestALBHandler(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := ALBRequest(r.Context())
+		if request == nil {
+			t.Fatal("got nil ALB request")
+		}
+		if Request(r.Context()) != nil {
+			t.Error("got API Gateway request, want nil")
+		}
+		info := Info(r.Context())
+		w.Header().Add("Set-Cookie", "x=1")
+		w.Header().Add("Set-Cookie", "y=2")
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(r.URL.RawQuery + " " + r.URL.Query().Get("q") + " " + ClientIP(r) + " " +
+			info.Route + " " + info.RequestID))
+	})
+	const arn = "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/lambda/abc"
+	tests := []struct {
+		request  events.ALBTargetGroupRequest
+		response events.ALBTargetGroupResponse
+	}{
+		{
+			request: events.ALBTargetGroupRequest{
+				HTTPMethod:            "GET",
+				Path:                  "/",
+				QueryStringParameters: map[string]string{"q": "a%20b", "a": "1"},
+				Headers: map[string]string{
+					"x-forwarded-for": "198.51.100.1, 203.0.113.1",
+					"x-amzn-trace-id": "Root=1-abc",
+				},
+				RequestContext: events.ALBTargetGroupRequestContext{ELB: events.ELBContext{TargetGroupArn: arn}},
+			},
+			response: events.ALBTargetGroupResponse{
+				StatusCode:        http.StatusAccepted,
+				StatusDescription: "202 Accepted",
+				Headers: map[string]string{
+					"Content-Type": "text/plain",
+					"Set-Cookie":   "y=2",
+				},
+				Body: "a=1&q=a+b a b 203.0.113.1 " + arn + " Root=1-abc",
+			},
+		},
+		{
+			request: events.ALBTargetGroupRequest{
+				HTTPMethod:                      "GET",
+				Path:                            "/",
+				MultiValueQueryStringParameters: map[string][]string{"q": {"x", "y"}},
+				MultiValueHeaders: map[string][]string{
+					"x-forwarded-for": {"198.51.100.1", "203.0.113.2"},
+				},
+				RequestContext: events.ALBTargetGroupRequestContext{ELB: events.ELBContext{TargetGroupArn: arn}},
+			},
+			response: events.ALBTargetGroupResponse{
+				StatusCode:        http.StatusAccepted,
+				StatusDescription: "202 Accepted",
+				MultiValueHeaders: map[string][]string{
+					"Content-Type": {"text/plain"},
+					"Set-Cookie":   {"x=1", "y=2"},
+				},
+				Body: "q=x&q=y x 203.0.113.2 " + arn + " ",
+			},
+		},
+	}
+	for i, tt := range tests {
+		handler := albHandler(h, newOptions(nil))
+		response, err := handler(context.Background(), tt.request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if !reflect.DeepEqual(response, tt.response) {
+			t.Errorf("%d: got=%+v\nwant=%+v", i, response, tt.response)
+		}
+	}
+}
@@ -11,12 +11,14 @@ import (
 	"encoding/base64"
 	"io"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"os"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/jjeffery/kv"
 )
 
@@ -24,6 +26,22 @@ type ctxKey int
 
 const (
 	ctxKeyEventContext ctxKey = 1
+	ctxKeySNSRecord    ctxKey = 2
+	ctxKeyScheduled    ctxKey = 3
+	ctxKeyWebSocket    ctxKey = 4
+	ctxKeyInvocation   ctxKey = 5
+	ctxKeyOptions      ctxKey = 6
+	ctxKeyV2           ctxKey = 7
+	ctxKeyALB          ctxKey = 8
+	ctxKeyLogger       ctxKey = 9
+	ctxKeyTrace        ctxKey = 10
+	ctxKeyBaggage      ctxKey = 11
+	ctxKeyConfig       ctxKey = 12
+	ctxKeyAuthorizer   ctxKey = 13
+	ctxKeyAppSync      ctxKey = 14
+	ctxKeyMountPath    ctxKey = 15
+	ctxKeyQueryArrays  ctxKey = 16
+	ctxKeyStartTime    ctxKey = 17
 )
 
 // Callback functions that can be overridden.
@@ -38,7 +56,8 @@ var (
 
 	// ShouldEncodeBody is called to determine if the body should be base64-encoded.
 	// The default implementation returns true if the response has a Content-Encoding header,
-	// or if body contains bytes outside the range [0x09, 0x7f].
+	// or if body contains bytes outside the range [0x09, 0x7f]. It is not called for binary
+	// gRPC-Web responses, which are always base64-encoded.
 	ShouldEncodeBody func(response *events.APIGatewayProxyResponse, body []byte) bool
 )
 
@@ -60,8 +79,22 @@ func IsLambda() bool {
 
 // Start starts handling AWS Lambda API Gateway proxy requests by passing
 // each request to the HTTP hander function.
-func Start(h http.Handler) {
-	lambda.Start(apiGatewayHandler(h))
+//
+// The Start functions use the aws-lambda-go/lambda package, which includes
+// support for the deprecated go1.x runtime. For smaller binaries, build with
+// the apigatewayproxy.runtimeapi tag to use a built-in client for the Lambda
+// runtime API instead, which requires a runtime such as provided.al2.
+//
+// A gRPC server wrapped for gRPC-Web can run behind the handler. Binary
+// gRPC-Web responses are always base64 encoded, including when the status
+// is configured using WithPlainTextStatus, and HTTP trailers set by the
+// handler are appended to the body as a gRPC-Web trailers frame, because
+// API Gateway cannot return trailers. The gRPC-Web headers are passed
+// through unchanged. Configure the gRPC-Web media types as binary media
+// types of the API, so that API Gateway base64 encodes the request bodies.
+func Start(h http.Handler, opts ...Option) {
+	o := newOptions(opts)
+	startLambda(o, withSpan(o, apiGatewayHandler(o.wrap(h), o)))
 }
 
 // Request returns a pointer to the API Gateway proxy request, or nil if the
@@ -71,75 +104,305 @@ func Request(ctx context.Context) *events.APIGatewayProxyRequest {
 	return request
 }
 
-func apiGatewayHandler(h http.Handler) func(request events.APIGatewayProxyRequest) (apiGatewayProxyResponse, error) {
-	return func(request events.APIGatewayProxyRequest) (apiGatewayProxyResponse, error) {
-		RequestReceived(&request)
-		r, err := newRequest(&request)
+func apiGatewayHandler(h http.Handler, o *options) func(ctx context.Context, request events.APIGatewayProxyRequest) (apiGatewayProxyResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (apiGatewayProxyResponse, error) {
+		inv := newInvocation(o)
+		defer inv.release()
+		inv.request = request
+		RequestReceived(&inv.request)
+		if err := normalizeRequest(&inv.request, o); err != nil {
+			return inv.fail(err)
+		}
+		r, err := inv.newRequest(ctx, ctxKeyEventContext, &inv.request)
 		if err != nil {
-			return apiGatewayProxyResponse{}, err
+			return inv.fail(err)
 		}
-		w := responseWriter{
-			header: make(http.Header),
+		w := inv.serve(h, r)
+		if err := inv.complete(r); err != nil {
+			return inv.fail(err)
 		}
-		h.ServeHTTP(&w, r)
-		w.finished()
-		SendingResponse(&request, &w.response)
+		SendingResponse(&inv.request, &w.response)
 		return w.response2, w.err
 	}
 }
 
-type emptyReader struct{}
+// invocation contains the state for handling one request event. The
+// request event, the request context and the response writer all
+// live for the duration of the invocation, so they are allocated together.
+type invocation struct {
+	request events.APIGatewayProxyRequest
+	ctx     eventContext
+	writer  responseWriter
+	body    *bytes.Buffer // decoded request body
+	reqBody requestBody   // body of the HTTP request
+	pool    BufferPool
+	opts    *options
 
-func (er emptyReader) Read(b []byte) (int, error) {
-	return 0, io.EOF
+	handlerTime time.Duration // time spent in the handler, if usage is reported
+	startTime   time.Time     // time of an SNS or scheduled event
 }
 
-func newRequest(request *events.APIGatewayProxyRequest) (*http.Request, error) {
-	u, err := url.Parse(request.Path)
-	if err != nil {
-		return nil, kv.Wrap(err, "cannot parse request path").With("path", request.Path)
-	}
-	q := u.Query()
-	for k, v := range request.QueryStringParameters {
-		q.Set(k, v)
-	}
-	u.RawQuery = q.Encode()
-
-	var body io.Reader
-	{
-		if request.Body == "" {
-			// empty body
-			body = emptyReader{}
-		} else if request.IsBase64Encoded {
-			b, err := base64.StdEncoding.DecodeString(request.Body)
-			if err != nil {
-				return nil, kv.Wrap(err, "cannot decode base64 body")
+func newInvocation(o *options) *invocation {
+	inv := &invocation{
+		pool: o.bufferPool,
+		opts: o,
+	}
+	inv.writer.opts = o
+	return inv
+}
+
+// serve passes the request to the handler, and returns the finished
+// response writer.
+func (inv *invocation) serve(h http.Handler, r *http.Request) *responseWriter {
+	o := inv.opts
+	w := &inv.writer
+	defer inv.closeBody(r)
+	if o.compressionMinSize > 0 {
+		w.preferredEncoding = negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	}
+	if o.originalHeaderCase {
+		w.headerNames = originalHeaderNames(&inv.request)
+	}
+	if o.contentLength {
+		w.head = r.Method == http.MethodHead
+	}
+	if o.headerMode != 0 && !validRequestHeader(r.Header, o) {
+		WriteError(w, r, http.StatusBadRequest, "invalid request header")
+	} else if err := inv.verifyChecksums(r); err != nil {
+		writeChecksumError(w, r, err)
+	} else {
+		var start time.Time
+		if o.usageReporter != nil {
+			start = time.Now()
+		}
+		ok := inv.serveHTTP(h, r)
+		if o.usageReporter != nil {
+			inv.handlerTime = time.Since(start)
+		}
+		if !ok {
+			// the handler panicked, and the error response has been written
+			return w
+		}
+	}
+	w.finished()
+	if w.headerViolations != nil {
+		inv.reportHeaderViolations(r)
+	}
+	return w
+}
+
+// serveHTTP calls the handler, and reports whether it returned normally.
+// If an error response or a panic hook is configured, a panic in the
+// handler is recovered and the error response replaces whatever the
+// handler wrote. Otherwise the panic propagates to the Lambda runtime,
+// which reports it.
+func (inv *invocation) serveHTTP(h http.Handler, r *http.Request) (ok bool) {
+	if o := inv.opts; o.errorTemplate != nil || o.onPanic != nil || o.onError != nil {
+		defer func() {
+			if p := recover(); p != nil {
+				if o.onPanic != nil {
+					o.onPanic(r.Context(), newPanicRecord(p, r))
+				}
+				inv.reportError(panicError(p), func() RequestSnapshot { return newRequestSnapshot(r) })
+				inv.writer.replace(o.errorResponse(http.StatusInternalServerError, &inv.request))
+				ok = false
+			}
+		}()
+	}
+	h.ServeHTTP(&inv.writer, r)
+	return true
+}
+
+// complete is called after the response has been finished. It offloads an
+// oversize response body if configured, and checks that the response is
+// not too large to return to Lambda.
+func (inv *invocation) complete(r *http.Request) error {
+	w := &inv.writer
+	// the response to a WebSocket event is sent to the client as a message,
+	// so it cannot be replaced by a redirect or an envelope
+	if inv.ctx.key != ctxKeyWebSocket {
+		if err := w.offload(r.Context(), inv.opts, &inv.request); err != nil {
+			return err
+		}
+	}
+	if o := inv.opts; o.errorTemplate != nil || o.onError != nil {
+		if size := payloadSize(&w.response2); size > MaxResponseSize {
+			err := kv.Wrap(ErrBodyTooLarge, "cannot return response").With("size", size, "max", MaxResponseSize)
+			inv.reportError(err, func() RequestSnapshot { return newRequestSnapshot(r) })
+			if o.errorTemplate != nil {
+				// Lambda would fail the invocation, so return the error response
+				w.replace(o.errorResponse(http.StatusInternalServerError, &inv.request))
 			}
-			body = bytes.NewBuffer(b)
-		} else {
-			body = strings.NewReader(request.Body)
 		}
 	}
+	return nil
+}
 
-	requestURI := u.String()
-	r, err := http.NewRequest(request.HTTPMethod, requestURI, body)
+// fail returns the response for an error that occurred converting the
+// request or the response. If an error response is configured, it is
+// returned to API Gateway instead of the error.
+func (inv *invocation) fail(err error) (apiGatewayProxyResponse, error) {
+	inv.reportError(err, inv.eventSnapshot)
+	if inv.opts.errorTemplate == nil {
+		return apiGatewayProxyResponse{}, err
+	}
+	inv.writer.replace(inv.opts.errorResponse(http.StatusInternalServerError, &inv.request))
+	return inv.writer.response2, nil
+}
+
+// newRequest creates the HTTP request for the invocation's request. The
+// value is added to the request context using key, so the HTTP handler
+// can access the original event if it wants.
+func (inv *invocation) newRequest(ctx context.Context, key ctxKey, value interface{}) (*http.Request, error) {
+	inv.ctx = eventContext{
+		Context: ctx,
+		key:     key,
+		value:   value,
+		inv:     inv,
+	}
+	body, err := inv.requestBody()
 	if err != nil {
-		return nil, kv.Wrap(err, "cannot create HTTP request")
+		return nil, err
 	}
-	// http.NewRequest does not set the RequestURI field
-	r.RequestURI = requestURI
+	r, err := newHTTPRequest(&inv.ctx, &inv.request, body, inv.opts)
+	if err != nil {
+		return nil, err
+	}
+	if inv.reqBody.r != nil {
+		// http.NewRequest only knows the length of standard readers
+		r.ContentLength = inv.reqBody.size
+	}
+	return r, nil
+}
+
+// requestBody returns a reader for the body of the request, decoding
+// it into a pooled buffer if it is base64 encoded.
+func (inv *invocation) requestBody() (io.Reader, error) {
+	request := &inv.request
+	if request.Body == "" {
+		// empty body
+		return http.NoBody, nil
+	}
+	if !request.IsBase64Encoded {
+		inv.reqBody.r = strings.NewReader(request.Body)
+		inv.reqBody.size = int64(len(request.Body))
+		return &inv.reqBody, nil
+	}
+	enc := inv.opts.base64Encoding
+	if inv.streamMultipartBody() {
+		inv.reqBody.r = base64BodyReader{base64.NewDecoder(enc, strings.NewReader(request.Body))}
+		inv.reqBody.size = decodedLen(enc, request.Body)
+		return &inv.reqBody, nil
+	}
+	inv.body = getBuffer(inv.pool)
+	inv.body.Grow(enc.DecodedLen(len(request.Body)) + bytes.MinRead)
+	if _, err := inv.body.ReadFrom(base64.NewDecoder(enc, strings.NewReader(request.Body))); err != nil {
+		return nil, kv.Wrap(newConversionError(ErrInvalidBase64Body, err), "cannot decode request body")
+	}
+	inv.reqBody.r = bytes.NewReader(inv.body.Bytes())
+	inv.reqBody.size = int64(inv.body.Len())
+	return &inv.reqBody, nil
+}
 
-	for k, v := range request.Headers {
-		r.Header.Set(k, v)
-		if strings.EqualFold(k, "Host") {
-			r.Host = v
+// release returns the invocation's buffers to the pool. It is called
+// after the response has been converted, which copies the response body.
+func (inv *invocation) release() {
+	inv.reportUsage()
+	putBuffer(inv.pool, inv.body)
+	inv.body = nil
+	inv.writer.release()
+}
+
+// eventContext is equivalent to the context returned by context.WithValue,
+// but can be embedded in another struct to avoid a separate allocation.
+type eventContext struct {
+	context.Context
+	key   ctxKey
+	value interface{}
+	inv   *invocation
+}
+
+func (c *eventContext) Value(key interface{}) interface{} {
+	if k, ok := key.(ctxKey); ok {
+		switch k {
+		case c.key:
+			return c.value
+		case ctxKeyInvocation:
+			return c.inv
+		}
+	}
+	return c.Context.Value(key)
+}
+
+// newHTTPRequest creates the HTTP request from the method, path, query
+// and headers of the request event.
+func newHTTPRequest(ctx context.Context, request *events.APIGatewayProxyRequest, body io.Reader, o *options) (*http.Request, error) {
+	// http.NewRequest parses the path, and the resulting URL is reused
+	// when adding the query string parameters
+	path := requestPath(request, o)
+	r, err := http.NewRequestWithContext(ctx, request.HTTPMethod, path, body)
+	if err != nil {
+		return nil, kv.Wrap(requestError(err), "cannot create HTTP request").With("path", path)
+	}
+	u := r.URL
+	if u.Host != "" {
+		// http.NewRequest parses a path starting with "//" as a host name,
+		// but a conventional HTTP server treats it as a path
+		if u, err = url.ParseRequestURI(path); err != nil {
+			return nil, kv.Wrap(requestError(err), "cannot create HTTP request").With("path", path)
 		}
+		r.URL = u
+	}
+	u.RawQuery = requestQuery(u.RawQuery, request, o)
+
+	// http.NewRequest does not set the RequestURI field
+	if o.originalRequestURI {
+		// the path in the event, before any base path is removed or the
+		// resource path is expanded
+		r.RequestURI, _, _ = strings.Cut(request.Path, "?")
+		if r.RequestURI == "" {
+			r.RequestURI = path
+		}
+		if u.RawQuery != "" {
+			r.RequestURI += "?" + u.RawQuery
+		}
+	} else if u.Scheme == "" && u.Host == "" && u.RawQuery == "" && u.Fragment == "" && u.EscapedPath() == path {
+		// u.String() would return the path unchanged, so avoid an allocation
+		r.RequestURI = path
+	} else {
+		r.RequestURI = u.String()
 	}
 
-	// add the request event to the request context so the HTTP handler
-	// can access it if it wants
-	ctx := context.WithValue(r.Context(), ctxKeyEventContext, request)
-	r = r.WithContext(ctx)
+	if len(request.PathParameters) > 0 {
+		setPathValues(r, request.PathParameters)
+	}
+
+	// allocate all header value slices at once, including those for the
+	// X-Forwarded headers
+	var values []string
+	if len(request.Headers) > 0 {
+		values = make([]string, len(request.Headers), len(request.Headers)+forwardedHeaderCount)
+		i := 0
+		for k, v := range request.Headers {
+			if strings.EqualFold(k, "Host") {
+				// like net/http, the host is not included in the header map
+				r.Host = v
+				if !o.hostHeader {
+					continue
+				}
+			}
+			values[i] = v
+			r.Header[textproto.CanonicalMIMEHeaderKey(k)] = values[i : i+1 : i+1]
+			i++
+		}
+	}
+	addForwardedHeaders(r.Header, request, values[len(values):cap(values)])
+	if o.forwardedHeader {
+		addForwardedHeader(r, request)
+	}
+	if o.urlScheme {
+		setURLScheme(r, request)
+	}
 
 	return r, nil
 }
@@ -155,16 +418,30 @@ type apiGatewayProxyResponse struct {
 }
 
 type responseWriter struct {
-	preferredEncoding string
+	preferredEncoding string // negotiated content coding for compression
 	response          events.APIGatewayProxyResponse
 	response2         apiGatewayProxyResponse
-	body              bytes.Buffer
+	body              *bytes.Buffer
+	opts              *options
+	binary            bool // body contains bytes that are not text
+	bodySize          int  // size of the body before compression
+	bufferCap         int  // peak capacity of the body buffers held at once
 	header            http.Header
 	headersWritten    bool
+	head              bool              // response to a HEAD request
+	invalidHeader     bool              // handler set an invalid header in strict mode
+	headerViolations  []HeaderViolation // invalid response headers set by the handler
+	headerNames       map[string]string // canonical to original request header names
+	stream            *responseStream   // non-nil when streaming the response
 	err               error
 }
 
 func (w *responseWriter) Header() http.Header {
+	if w.header == nil {
+		// the header is allocated when first used, so that a response
+		// without headers only allocates the response header map
+		w.header = make(http.Header)
+	}
 	return w.header
 }
 
@@ -172,6 +449,22 @@ func (w *responseWriter) Write(b []byte) (int, error) {
 	if !w.headersWritten {
 		w.WriteHeader(http.StatusOK)
 	}
+	if s := w.stream; s != nil && s.flushOnWrite {
+		if !s.started {
+			w.startStream()
+		}
+		if s.started {
+			return s.write(b)
+		}
+	}
+	if w.body == nil {
+		w.body = getBuffer(w.opts.bufferPool)
+	}
+	if !w.binary {
+		// scan each write as it arrives, so the default ShouldEncodeBody
+		// does not need to scan the whole body again
+		w.binary = !isText(b)
+	}
 	return w.body.Write(b)
 }
 
@@ -179,31 +472,67 @@ func (w *responseWriter) WriteHeader(status int) {
 	if w.headersWritten {
 		return
 	}
-	w.response.StatusCode = status
-	w.response.Headers = make(map[string]string)
-	for k, vv := range w.header {
-		for _, v := range vv {
-			w.response.Headers[k] = v
-		}
+	if w.opts != nil && w.opts.headerMode != 0 {
+		var valid bool
+		valid, w.headerViolations = sanitizeHeader(w.header, true, w.opts)
+		w.invalidHeader = !valid
 	}
 	w.response2.StatusCode = status
-	w.response2.Headers = make(map[string]string)
+	w.response2.Headers = make(map[string]string, len(w.header))
 	for k, vv := range w.header {
 		if len(vv) == 1 {
 			w.response2.Headers[k] = vv[0]
-		} else {
+		} else if len(vv) > 1 {
 			if w.response2.MultiValueHeaders == nil {
 				w.response2.MultiValueHeaders = make(map[string][]string)
 			}
 			w.response2.MultiValueHeaders[k] = vv
 		}
 	}
+	w.response.StatusCode = status
+	if w.response2.MultiValueHeaders == nil {
+		// the single value headers are identical, so share the map
+		w.response.Headers = w.response2.Headers
+	} else {
+		w.response.Headers = make(map[string]string, len(w.header))
+		for k, vv := range w.header {
+			if len(vv) > 0 {
+				w.response.Headers[k] = vv[len(vv)-1]
+			}
+		}
+	}
 	w.headersWritten = true
+	if w.stream != nil {
+		w.stream.flushOnWrite = w.opts.streamWrites || isEventStream(w.response.Headers["Content-Type"])
+	}
 }
 
 func (w *responseWriter) finished() {
 	// write the header if it has not already been written
 	w.WriteHeader(http.StatusOK)
+	if w.stream != nil && w.stream.started {
+		// the header has been sent, so the rest of the body is sent as is
+		w.flushStream()
+		return
+	}
+	if w.invalidHeader {
+		w.replace(http.StatusInternalServerError, map[string]string{
+			"Content-Type": "text/plain; charset=utf-8",
+		}, "invalid response header\n")
+		return
+	}
+	grpcWeb := isGRPCWeb(w.response.Headers["Content-Type"])
+	if grpcWeb {
+		w.writeGRPCWebTrailers()
+	}
+	if w.body != nil {
+		w.bodySize = w.body.Len()
+	}
+	w.stripHopByHopHeaders()
+	if !w.opts.noSniff {
+		w.sniffContentType()
+	}
+	w.compress()
 
 	// Regardless of the content type or the content encoding, if the body is
 	// valid UTF8, return it as a string. The call to utf8.Valid will return
@@ -212,9 +541,31 @@ func (w *responseWriter) finished() {
 	// Note that if the body starts with UTF8 byte order marks (0xef, 0xbb, 0xbf), it will
 	// be base64 encoded. This is the correct behaviour, because BOMs in the middle of
 	// a UTF8 string are not valid, and the body will be part of a larger, JSON string.
-	b := w.body.Bytes()
-	if ShouldEncodeBody(&w.response, b) {
-		w.response.Body = base64.StdEncoding.EncodeToString(b)
+	var b []byte
+	if w.body != nil {
+		b = w.body.Bytes()
+	}
+	if w.opts.contentLength {
+		w.setContentLength(len(b))
+	}
+	var encode bool
+	if grpcWeb {
+		encode = true
+	} else if w.partialContent() {
+		// a partial binary body must not be returned as text
+		encode = isContentEncoded(&w.response) || w.binary
+		if !encode && !isDefaultShouldEncodeBody() {
+			encode = ShouldEncodeBody(&w.response, b)
+		}
+	} else if w.opts.plainText(w.response.StatusCode) {
+		encode = false
+	} else if isDefaultShouldEncodeBody() {
+		encode = isContentEncoded(&w.response) || w.binary
+	} else {
+		encode = ShouldEncodeBody(&w.response, b)
+	}
+	if encode {
+		w.response.Body = w.opts.base64Encoding.EncodeToString(b)
 		w.response.IsBase64Encoded = true
 	} else {
 		w.response.Body = string(b)
@@ -223,21 +574,77 @@ func (w *responseWriter) finished() {
 
 	w.response2.Body = w.response.Body
 	w.response2.IsBase64Encoded = w.response.IsBase64Encoded
+	if w.headerNames != nil {
+		w.renameHeaders()
+	}
+}
+
+// sniffContentType sets the Content-Type header from the first 512 bytes of
+// the body if the handler did not set it, as net/http does. Like net/http,
+// a Content-Type header with no values prevents sniffing, and responses
+// with a Content-Encoding are not sniffed.
+func (w *responseWriter) sniffContentType() {
+	if w.bodySize == 0 || !bodyAllowedForStatus(w.response.StatusCode) {
+		return
+	}
+	if _, ok := w.header["Content-Type"]; ok {
+		return
+	}
+	if isContentEncoded(&w.response) || w.partialContent() {
+		return
+	}
+	w.setResponseHeader("Content-Type", http.DetectContentType(w.body.Bytes()))
+}
+
+// bodyAllowedForStatus reports whether a response with the status code
+// can have a body.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// release returns the body buffer to the pool. The body must not be
+// used after calling release.
+func (w *responseWriter) release() {
+	putBuffer(w.opts.bufferPool, w.body)
+	w.body = nil
 }
 
 // shouldEncodeBody is the default implementation for ShouldEncodeBody
 func shouldEncodeBody(response *events.APIGatewayProxyResponse, body []byte) bool {
-	if contentEncoding := response.Headers["Content-Encoding"]; contentEncoding != "" && contentEncoding != "identity" {
-		return true
-	}
+	return isContentEncoded(response) || !isText(body)
+}
+
+// isDefaultShouldEncodeBody reports whether ShouldEncodeBody has not been
+// overridden, in which case the response writer can make the same decision
+// without scanning the body a second time.
+func isDefaultShouldEncodeBody() bool {
+	return reflect.ValueOf(ShouldEncodeBody).Pointer() == reflect.ValueOf(shouldEncodeBody).Pointer()
+}
+
+// isContentEncoded reports whether the response has a Content-Encoding
+// other than identity.
+func isContentEncoded(response *events.APIGatewayProxyResponse) bool {
+	contentEncoding := response.Headers["Content-Encoding"]
+	return contentEncoding != "" && contentEncoding != "identity"
+}
+
+// isText reports whether body contains only bytes in the range [0x20, 0x7f],
+// or tab, carriage return and line feed.
+func isText(body []byte) bool {
 	for _, b := range body {
 		switch b {
 		case '\t', '\r', '\n':
 			continue
 		}
 		if b < 0x20 || b > 0x7f {
-			return true
+			return false
 		}
 	}
-	return false
+	return true
 }
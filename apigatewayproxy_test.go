@@ -1,9 +1,12 @@
 package apigatewayproxy
 
 import (
+	"context"
+	"encoding/base64"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
 	"testing"
@@ -60,7 +63,7 @@ func TestHandler(t *testing.T) {
 			},
 			response: apiGatewayProxyResponse{
 				StatusCode: 200,
-				Headers:    map[string]string{},
+				Headers:    map[string]string{"Content-Type": "text/plain; charset=utf-8"},
 				Body:       "hello",
 			},
 		},
@@ -80,7 +83,7 @@ func TestHandler(t *testing.T) {
 			},
 			response: apiGatewayProxyResponse{
 				StatusCode: 200,
-				Headers:    map[string]string{},
+				Headers:    map[string]string{"Content-Type": "text/plain; charset=utf-8"},
 				Body:       "*/*\ngzip",
 			},
 		},
@@ -185,6 +188,20 @@ func TestHandler(t *testing.T) {
 				Body: "RequestURI=/this/is/the/path?q=q1\nGET",
 			},
 		},
+		{
+			// RequestURI is escaped
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.RequestURI))
+			}),
+			request: events.APIGatewayProxyRequest{
+				Path: "/this is/the/path",
+			},
+			response: apiGatewayProxyResponse{
+				StatusCode: 200,
+				Headers:    map[string]string{"Content-Type": "text/plain; charset=utf-8"},
+				Body:       "/this%20is/the/path",
+			},
+		},
 		{
 			// body setup from POST
 			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -200,7 +217,7 @@ func TestHandler(t *testing.T) {
 			},
 			response: apiGatewayProxyResponse{
 				StatusCode: 200,
-				Headers:    map[string]string{},
+				Headers:    map[string]string{"Content-Type": "text/plain; charset=utf-8"},
 				Body:       "This is the body\n",
 			},
 		},
@@ -219,7 +236,7 @@ func TestHandler(t *testing.T) {
 			},
 			response: apiGatewayProxyResponse{
 				StatusCode: 200,
-				Headers:    map[string]string{},
+				Headers:    map[string]string{"Content-Type": "text/plain; charset=utf-8"},
 				Body:       "This is the body\n",
 			},
 		},
@@ -244,9 +261,9 @@ func TestHandler(t *testing.T) {
 	}
 
 	for i, tt := range tests {
-		handler := apiGatewayHandler(tt.handler)
+		handler := apiGatewayHandler(tt.handler, newOptions(nil))
 
-		response, err := handler(tt.request)
+		response, err := handler(context.Background(), tt.request)
 		if err != nil {
 			if !tt.expectError {
 				t.Errorf("%d: got %v, want no error", i, err)
@@ -262,3 +279,254 @@ func TestHandler(t *testing.T) {
 		}
 	}
 }
+
+// simpleGet returns the handler and request for a simple GET request.
+func simpleGet() (func(context.Context, events.APIGatewayProxyRequest) (apiGatewayProxyResponse, error), events.APIGatewayProxyRequest) {
+	hello := []byte("hello")
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(hello)
+	})
+	handler := apiGatewayHandler(h, newOptions(nil))
+	request := events.APIGatewayProxyRequest{
+		Path:       "/test",
+		HTTPMethod: "GET",
+		Headers: map[string]string{
+			"Accept": "*/*",
+		},
+	}
+	return handler, request
+}
+
+func BenchmarkHandlerSimpleGet(b *testing.B) {
+	handler, request := simpleGet()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := handler(context.Background(), request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestHandlerSimpleGetAllocs(t *testing.T) {
+	handler, request := simpleGet()
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := handler(context.Background(), request); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs >= 10 {
+		t.Errorf("got %v allocs, want fewer than 10", allocs)
+	}
+}
+
+func TestShouldEncodeBody(t *testing.T) {
+	tests := []struct {
+		writes [][]byte
+		header map[string]string
+		want   bool
+	}{
+		{writes: [][]byte{[]byte("hello"), []byte(" world\r\n")}, want: false},
+		{writes: [][]byte{[]byte("hello"), {0x00}, []byte("world")}, want: true},
+		{writes: [][]byte{[]byte("hello")}, header: map[string]string{"Content-Encoding": "gzip"}, want: true},
+		{writes: [][]byte{[]byte("hello")}, header: map[string]string{"Content-Encoding": "identity"}, want: false},
+		{writes: nil, want: false},
+	}
+
+	for i, tt := range tests {
+		handler := apiGatewayHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range tt.header {
+				w.Header().Set(k, v)
+			}
+			for _, b := range tt.writes {
+				w.Write(b)
+			}
+		}), newOptions(nil))
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{Path: "/"})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.IsBase64Encoded, tt.want; got != want {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+	}
+}
+
+func TestShouldEncodeBodyOverride(t *testing.T) {
+	defer func(f func(*events.APIGatewayProxyResponse, []byte) bool) {
+		ShouldEncodeBody = f
+	}(ShouldEncodeBody)
+	var called bool
+	ShouldEncodeBody = func(response *events.APIGatewayProxyResponse, body []byte) bool {
+		called = true
+		return true
+	}
+
+	handler := apiGatewayHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}), newOptions(nil))
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{Path: "/"})
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if !called {
+		t.Error("ShouldEncodeBody not called")
+	}
+	if got, want := response.Body, "aGVsbG8="; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestOriginalRequestURI(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.RequestURI + "\n" + r.URL.Path))
+	})
+	tests := []struct {
+		opts    []Option
+		request events.APIGatewayProxyRequest
+		want    string
+	}{
+		{
+			request: events.APIGatewayProxyRequest{Path: "/a b/c;d"},
+			want:    "/a%20b/c;d\n/a b/c;d",
+		},
+		{
+			opts:    []Option{WithOriginalRequestURI()},
+			request: events.APIGatewayProxyRequest{Path: "/a b/c;d"},
+			want:    "/a b/c;d\n/a b/c;d",
+		},
+		{
+			opts: []Option{WithOriginalRequestURI()},
+			request: events.APIGatewayProxyRequest{
+				Path:                  "/a%2Fb",
+				QueryStringParameters: map[string]string{"q": "x y"},
+			},
+			want: "/a%2Fb?q=x+y\n/a/b",
+		},
+		{
+			opts: []Option{WithOriginalRequestURI(), WithBasePathMapping("/orders"), WithTrailingSlash(TrailingSlashStrip)},
+			request: events.APIGatewayProxyRequest{
+				Path:                  "/orders/a%20b/",
+				QueryStringParameters: map[string]string{"q": "1"},
+			},
+			want: "/orders/a%20b/?q=1\n/a b",
+		},
+	}
+	for i, tt := range tests {
+		handler := apiGatewayHandler(h, newOptions(tt.opts))
+		response, err := handler(context.Background(), tt.request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestHostHeader(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host + "\n" + r.Header.Get("Host")))
+	})
+	request := events.APIGatewayProxyRequest{
+		Path:    "/",
+		Headers: map[string]string{"host": "example.com", "Accept": "*/*"},
+	}
+	tests := []struct {
+		opts []Option
+		want string
+	}{
+		{
+			want: "example.com\n",
+		},
+		{
+			opts: []Option{WithHostHeader()},
+			want: "example.com\nexample.com",
+		},
+	}
+	for i, tt := range tests {
+		handler := apiGatewayHandler(h, newOptions(tt.opts))
+		response, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestBase64Encoding(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(append(body, 0xff))
+	})
+	handler := apiGatewayHandler(h, newOptions([]Option{WithBase64Encoding(base64.RawURLEncoding)}))
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		Path:            "/",
+		Body:            base64.RawURLEncoding.EncodeToString([]byte{0xfb, 0xff}),
+		IsBase64Encoded: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := response.Body, base64.RawURLEncoding.EncodeToString([]byte{0xfb, 0xff, 0xff}); got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	if !response.IsBase64Encoded {
+		t.Error("got not base64 encoded, want encoded")
+	}
+}
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		status int
+		body   string
+		want   []string
+	}{
+		{body: "<html><body>hi</body></html>", want: []string{"text/html; charset=utf-8"}},
+		{body: "\x89PNG\r\n\x1a\n", want: []string{"image/png"}},
+		{header: http.Header{"Content-Type": {"application/json"}}, body: "{}", want: []string{"application/json"}},
+		{header: http.Header{"Content-Type": nil}, body: "hello"},
+		{header: http.Header{"Content-Encoding": {"gzip"}}, body: "hello"},
+		{status: http.StatusNoContent, body: "hello"},
+		{body: ""},
+	}
+	for i, tt := range tests {
+		h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range tt.header {
+				w.Header()[k] = v
+			}
+			if tt.status != 0 {
+				w.WriteHeader(tt.status)
+			}
+			w.Write([]byte(tt.body))
+		})
+		server := httptest.NewServer(h)
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		resp.Body.Close()
+		server.Close()
+		if got := resp.Header["Content-Type"]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: net/http: got %q, want %q", i, got, tt.want)
+		}
+
+		response, err := apiGatewayHandler(h, newOptions(nil))(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Path:       "/",
+		})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		var got []string
+		if v, ok := response.Headers["Content-Type"]; ok {
+			got = []string{v}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: adapter: got %q, want %q", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// AppSyncResolverEvent is the payload sent by AWS AppSync to a direct
+// Lambda resolver, without a request mapping template.
+type AppSyncResolverEvent struct {
+	Arguments json.RawMessage        `json:"arguments"`
+	Identity  json.RawMessage        `json:"identity,omitempty"`
+	Source    json.RawMessage        `json:"source,omitempty"`
+	Prev      json.RawMessage        `json:"prev,omitempty"`
+	Stash     map[string]interface{} `json:"stash,omitempty"`
+	Request   struct {
+		Headers    map[string]string `json:"headers"`
+		DomainName string            `json:"domainName,omitempty"`
+	} `json:"request"`
+	Info struct {
+		FieldName           string                 `json:"fieldName"`
+		ParentTypeName      string                 `json:"parentTypeName"`
+		Variables           map[string]interface{} `json:"variables,omitempty"`
+		SelectionSetList    []string               `json:"selectionSetList,omitempty"`
+		SelectionSetGraphQL string                 `json:"selectionSetGraphQL,omitempty"`
+	} `json:"info"`
+}
+
+// appSyncIdentity contains the fields of the AppSync identity that are
+// common to Cognito user pool, OIDC and IAM authorization.
+type appSyncIdentity struct {
+	Sub      string   `json:"sub"`
+	UserArn  string   `json:"userArn"`
+	SourceIP []string `json:"sourceIp"`
+}
+
+// identity returns the identity of the caller, which is empty for API key
+// authorization.
+func (e *AppSyncResolverEvent) identity() appSyncIdentity {
+	var identity appSyncIdentity
+	if len(e.Identity) > 0 {
+		json.Unmarshal(e.Identity, &identity)
+	}
+	return identity
+}
+
+// StartAppSync starts handling AWS AppSync direct Lambda resolver events by
+// passing each event to the HTTP handler, so that GraphQL fields can be
+// resolved by an existing REST handler during a migration. This adapter is
+// experimental: its behavior may change, and batch invocations are not
+// supported.
+//
+// The field arguments are the JSON request body, and the HTTP method and
+// path are determined by the parent type and field name: see
+// WithAppSyncRoute. The request headers are the headers that AppSync
+// received from the client.
+//
+// A 2xx response body is the result of the field. If the body is not
+// valid JSON, the result is the body as a string. Any other status code
+// returns an error to AppSync, whose message is the response body.
+func StartAppSync(h http.Handler, opts ...Option) {
+	o := newOptions(opts)
+	startLambda(o, withSpan(o, appSyncHandler(o.wrap(h), o)))
+}
+
+// AppSyncEvent returns a pointer to the AppSync resolver event, or nil if
+// the current context is not associated with an AppSync resolver event.
+func AppSyncEvent(ctx context.Context) *AppSyncResolverEvent {
+	event, _ := ctx.Value(ctxKeyAppSync).(*AppSyncResolverEvent)
+	return event
+}
+
+// WithAppSyncRoute maps the GraphQL field to the HTTP method and path of
+// the request passed to the HTTP handler. Any occurrence of "{name}" in path
+// is replaced with the value of the field argument with that name, so that
+// the field "Query.user(id: ID!)" can be mapped to "GET /users/{id}". Fields
+// that are not mapped are sent as POST requests to "/appsync/{type}/{field}".
+func WithAppSyncRoute(typeName, fieldName, method, path string) Option {
+	return func(o *options) {
+		if o.appSyncRoutes == nil {
+			o.appSyncRoutes = make(map[string]appSyncRoute)
+		}
+		o.appSyncRoutes[typeName+"."+fieldName] = appSyncRoute{
+			method: method,
+			path:   path,
+		}
+	}
+}
+
+// AppSyncError is the error returned to AppSync when the handler responds
+// with a status code outside the range 200-299. AppSync returns the message
+// to the client in the GraphQL errors.
+type AppSyncError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *AppSyncError) Error() string {
+	return e.Message
+}
+
+func appSyncHandler(h http.Handler, o *options) func(ctx context.Context, event AppSyncResolverEvent) (json.RawMessage, error) {
+	return func(ctx context.Context, event AppSyncResolverEvent) (json.RawMessage, error) {
+		inv := newInvocation(o)
+		defer inv.release()
+		route, err := o.appSyncRoute(&event)
+		if err != nil {
+			return nil, err
+		}
+		headers := make(map[string]string, len(event.Request.Headers)+1)
+		for k, v := range event.Request.Headers {
+			if strings.EqualFold(k, "Accept-Encoding") || strings.EqualFold(k, "Content-Length") {
+				// the response is decoded rather than returned, so it
+				// must not be compressed, and the body is the arguments
+				continue
+			}
+			headers[k] = v
+		}
+		headers["Content-Type"] = "application/json"
+		inv.request = events.APIGatewayProxyRequest{
+			HTTPMethod: route.method,
+			Path:       route.path,
+			Headers:    headers,
+			Body:       string(event.Arguments),
+		}
+		r, err := inv.newRequest(ctx, ctxKeyAppSync, &event)
+		if err != nil {
+			inv.reportError(err, inv.eventSnapshot)
+			return nil, err
+		}
+		w := inv.serve(h, r)
+		return appSyncResult(w)
+	}
+}
+
+// appSyncRoute is the HTTP method and path for a GraphQL field.
+type appSyncRoute struct {
+	method string
+	path   string
+}
+
+// appSyncRoute returns the HTTP method and path for the GraphQL field.
+func (o *options) appSyncRoute(event *AppSyncResolverEvent) (appSyncRoute, error) {
+	info := &event.Info
+	route, ok := o.appSyncRoutes[info.ParentTypeName+"."+info.FieldName]
+	if !ok {
+		return appSyncRoute{
+			method: http.MethodPost,
+			path:   "/appsync/" + url.PathEscape(info.ParentTypeName) + "/" + url.PathEscape(info.FieldName),
+		}, nil
+	}
+	if !strings.Contains(route.path, "{") {
+		return route, nil
+	}
+	var args map[string]interface{}
+	if len(event.Arguments) > 0 {
+		if err := json.Unmarshal(event.Arguments, &args); err != nil {
+			return appSyncRoute{}, kv.Wrap(err, "cannot decode arguments").With("field", info.FieldName)
+		}
+	}
+	var replace []string
+	for name, value := range args {
+		switch value.(type) {
+		case string, float64, bool:
+			replace = append(replace, "{"+name+"}", url.PathEscape(fmt.Sprint(value)))
+		}
+	}
+	route.path = strings.NewReplacer(replace...).Replace(route.path)
+	return route, nil
+}
+
+// appSyncResult returns the result of the field for the handler response.
+func appSyncResult(w *responseWriter) (json.RawMessage, error) {
+	body := w.response.Body
+	if w.response.IsBase64Encoded {
+		b, err := w.opts.base64Encoding.DecodeString(body)
+		if err != nil {
+			return nil, kv.Wrap(err, "cannot decode response body")
+		}
+		body = string(b)
+	}
+	if status := w.response.StatusCode; status < 200 || status > 299 {
+		message := strings.TrimSpace(body)
+		if message == "" {
+			message = http.StatusText(status)
+		}
+		return nil, &AppSyncError{StatusCode: status, Message: message}
+	}
+	if body == "" {
+		return json.RawMessage("null"), nil
+	}
+	if json.Valid([]byte(body)) {
+		return json.RawMessage(body), nil
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot encode result")
+	}
+	return b, nil
+}
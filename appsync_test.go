@@ -0,0 +1,69 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestAppSyncHandler(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if AppSyncEvent(r.Context()) == nil {
+			t.Error("got nil, want AppSync event")
+		}
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/users/u 1":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"u 1","method":"` + r.Method + `","auth":"` + r.Header.Get("Authorization") + `"}`))
+		case "/appsync/Mutation/createUser":
+			w.Write(body)
+		case "/appsync/Query/greeting":
+			w.Write([]byte("hello"))
+		case "/appsync/Query/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.Error(w, "user not found", http.StatusNotFound)
+		}
+	})
+	handler := appSyncHandler(h, newOptions([]Option{
+		WithAppSyncRoute("Query", "user", "GET", "/users/{id}"),
+	}))
+	tests := []struct {
+		typeName  string
+		fieldName string
+		arguments string
+		want      string
+		wantErr   string
+	}{
+		{typeName: "Query", fieldName: "user", arguments: `{"id":"u 1"}`, want: `{"id":"u 1","method":"GET","auth":"token"}`},
+		{typeName: "Mutation", fieldName: "createUser", arguments: `{"name":"x"}`, want: `{"name":"x"}`},
+		{typeName: "Query", fieldName: "greeting", arguments: `{}`, want: `"hello"`},
+		{typeName: "Query", fieldName: "empty", want: `null`},
+		{typeName: "Query", fieldName: "missing", wantErr: "user not found"},
+	}
+	for i, tt := range tests {
+		var event AppSyncResolverEvent
+		event.Info.ParentTypeName = tt.typeName
+		event.Info.FieldName = tt.fieldName
+		event.Arguments = json.RawMessage(tt.arguments)
+		event.Request.Headers = map[string]string{"authorization": "token", "accept-encoding": "gzip"}
+		got, err := handler(context.Background(), event)
+		if tt.wantErr != "" {
+			var appSyncErr *AppSyncError
+			if !errors.As(err, &appSyncErr) || appSyncErr.Message != tt.wantErr || appSyncErr.StatusCode != http.StatusNotFound {
+				t.Errorf("%d: got error %v, want %q", i, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%d: got %s, want %s", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// StartAuthorizer starts handling API Gateway Lambda authorizer events of
+// the REQUEST type by passing each event to the HTTP handler as the request
+// that is being authorized, so that authorizers can be written and tested
+// with the same HTTP tooling as the API. Use AuthorizerRequest to obtain the
+// authorizer event, which includes the method ARN.
+//
+// The response status code determines the result:
+//
+//   - 2xx allows the request. The response body, if any, is a JSON
+//     AuthorizerResult with the principal ID and context.
+//   - 401 (Unauthorized) returns ErrUnauthorized to Lambda, and API Gateway
+//     sends a 401 response to the client.
+//   - Other 4xx status codes deny the request, and API Gateway sends a 403
+//     (Forbidden) response to the client. The response body is used if it
+//     is a JSON AuthorizerResult, and is ignored otherwise.
+//   - Any other status code is an error, and API Gateway sends a 500
+//     (Internal Server Error) response to the client.
+func StartAuthorizer(h http.Handler, opts ...Option) {
+	o := newOptions(opts)
+	startLambda(o, withSpan(o, authorizerHandler(o.wrap(h), o)))
+}
+
+// AuthorizerRequest returns a pointer to the API Gateway authorizer event,
+// or nil if the current context is not associated with an authorizer event.
+func AuthorizerRequest(ctx context.Context) *events.APIGatewayCustomAuthorizerRequestTypeRequest {
+	request, _ := ctx.Value(ctxKeyAuthorizer).(*events.APIGatewayCustomAuthorizerRequestTypeRequest)
+	return request
+}
+
+// AuthorizerResult is the JSON response body of an authorizer handler.
+// All fields are optional.
+type AuthorizerResult struct {
+	// Effect is "Allow" or "Deny". If empty, the effect is determined by
+	// the response status code.
+	Effect string `json:"effect,omitempty"`
+
+	// PrincipalID identifies the caller, and is available to the API as
+	// $context.authorizer.principalId.
+	PrincipalID string `json:"principalId,omitempty"`
+
+	// Resource is the list of method ARNs that the policy applies to. If
+	// empty, the policy applies to the method ARN of the event. When the
+	// authorizer result is cached, a policy for the method ARN only denies
+	// requests to other methods that use the cached result, so consider
+	// using a wildcard.
+	Resource []string `json:"resource,omitempty"`
+
+	// Context is passed to the API as $context.authorizer.{key}. The values
+	// must be strings, numbers or booleans.
+	Context map[string]interface{} `json:"context,omitempty"`
+
+	// UsageIdentifierKey is the API key for usage plans, if the API key
+	// source of the API is AUTHORIZER.
+	UsageIdentifierKey string `json:"usageIdentifierKey,omitempty"`
+}
+
+// Policy effects
+const (
+	EffectAllow = "Allow"
+	EffectDeny  = "Deny"
+)
+
+func authorizerHandler(h http.Handler, o *options) func(ctx context.Context, request events.APIGatewayCustomAuthorizerRequestTypeRequest) (events.APIGatewayCustomAuthorizerResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayCustomAuthorizerRequestTypeRequest) (events.APIGatewayCustomAuthorizerResponse, error) {
+		inv := newInvocation(o)
+		defer inv.release()
+		inv.request = authorizerProxyRequest(&request)
+		if err := normalizeRequest(&inv.request, o); err != nil {
+			return inv.failAuthorizer(err)
+		}
+		r, err := inv.newRequest(ctx, ctxKeyAuthorizer, &request)
+		if err != nil {
+			return inv.failAuthorizer(err)
+		}
+		// the response is decoded rather than returned, so it must
+		// not be compressed
+		r.Header.Del("Accept-Encoding")
+		w := inv.serve(h, r)
+		response, err := authorizerResponse(w, &request, o)
+		if err != nil {
+			return inv.failAuthorizer(err)
+		}
+		return response, nil
+	}
+}
+
+// authorizerProxyRequest returns the proxy request event for the request
+// being authorized.
+func authorizerProxyRequest(request *events.APIGatewayCustomAuthorizerRequestTypeRequest) events.APIGatewayProxyRequest {
+	rc := &request.RequestContext
+	return events.APIGatewayProxyRequest{
+		Resource:                        request.Resource,
+		Path:                            request.Path,
+		HTTPMethod:                      request.HTTPMethod,
+		Headers:                         request.Headers,
+		MultiValueHeaders:               request.MultiValueHeaders,
+		QueryStringParameters:           request.QueryStringParameters,
+		MultiValueQueryStringParameters: request.MultiValueQueryStringParameters,
+		PathParameters:                  request.PathParameters,
+		StageVariables:                  request.StageVariables,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:    rc.AccountID,
+			ResourceID:   rc.ResourceID,
+			Stage:        rc.Stage,
+			RequestID:    rc.RequestID,
+			ResourcePath: rc.ResourcePath,
+			HTTPMethod:   rc.HTTPMethod,
+			APIID:        rc.APIID,
+			Identity: events.APIGatewayRequestIdentity{
+				APIKey:   rc.Identity.APIKey,
+				SourceIP: rc.Identity.SourceIP,
+			},
+		},
+	}
+}
+
+// authorizerResponse maps the handler response to the authorizer response.
+func authorizerResponse(w *responseWriter, request *events.APIGatewayCustomAuthorizerRequestTypeRequest, o *options) (events.APIGatewayCustomAuthorizerResponse, error) {
+	status := w.response.StatusCode
+	var effect string
+	switch {
+	case status >= 200 && status <= 299:
+		effect = EffectAllow
+	case status == http.StatusUnauthorized:
+		return events.APIGatewayCustomAuthorizerResponse{}, ErrUnauthorized
+	case status >= 400 && status <= 499:
+		effect = EffectDeny
+	default:
+		return events.APIGatewayCustomAuthorizerResponse{}, kv.NewError("authorizer handler failed").With("status", status)
+	}
+
+	var result AuthorizerResult
+	if w.body != nil && w.body.Len() > 0 {
+		// a denied request can have an error message instead of a result
+		if err := o.jsonCodec.Unmarshal(w.body.Bytes(), &result); err != nil && effect == EffectAllow {
+			return events.APIGatewayCustomAuthorizerResponse{}, kv.Wrap(err, "cannot decode authorizer result").With("status", status)
+		}
+	}
+	switch result.Effect {
+	case "":
+	case EffectAllow, EffectDeny:
+		effect = result.Effect
+	default:
+		return events.APIGatewayCustomAuthorizerResponse{}, kv.NewError("invalid authorizer effect").With("effect", result.Effect)
+	}
+	resource := result.Resource
+	if len(resource) == 0 {
+		resource = []string{request.MethodArn}
+	}
+	return events.APIGatewayCustomAuthorizerResponse{
+		PrincipalID: result.PrincipalID,
+		PolicyDocument: events.APIGatewayCustomAuthorizerPolicy{
+			Version: "2012-10-17",
+			Statement: []events.IAMPolicyStatement{
+				{
+					Action:   []string{"execute-api:Invoke"},
+					Effect:   effect,
+					Resource: resource,
+				},
+			},
+		},
+		Context:            result.Context,
+		UsageIdentifierKey: result.UsageIdentifierKey,
+	}, nil
+}
+
+// failAuthorizer reports the error, and returns it to Lambda. An error
+// response is not returned, because API Gateway only accepts a policy.
+func (inv *invocation) failAuthorizer(err error) (events.APIGatewayCustomAuthorizerResponse, error) {
+	if err != ErrUnauthorized {
+		inv.reportError(err, inv.eventSnapshot)
+	}
+	return events.APIGatewayCustomAuthorizerResponse{}, err
+}
@@ -0,0 +1,88 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestAuthorizerHandler(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if AuthorizerRequest(r.Context()) == nil {
+			t.Error("got nil, want authorizer request")
+		}
+		switch r.Header.Get("Authorization") {
+		case "":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "Bearer good":
+			json.NewEncoder(w).Encode(AuthorizerResult{
+				PrincipalID: "user-" + r.URL.Query().Get("q"),
+				Context:     map[string]interface{}{"role": "admin"},
+			})
+		case "Bearer wildcard":
+			w.Write([]byte(`{"principalId":"any","resource":["arn:aws:execute-api:*"]}`))
+		case "Bearer denied":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"effect":"Deny","principalId":"user-2"}`))
+		case "Bearer broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		}
+	})
+	const methodArn = "arn:aws:execute-api:us-east-1:123456789012:abc/prod/GET/items"
+	tests := []struct {
+		token         string
+		wantErr       error
+		wantEffect    string
+		wantPrincipal string
+		wantResource  string
+	}{
+		{token: "", wantErr: ErrUnauthorized},
+		{token: "Bearer good", wantEffect: EffectAllow, wantPrincipal: "user-x", wantResource: methodArn},
+		{token: "Bearer wildcard", wantEffect: EffectAllow, wantPrincipal: "any", wantResource: "arn:aws:execute-api:*"},
+		{token: "Bearer denied", wantEffect: EffectDeny, wantPrincipal: "user-2", wantResource: methodArn},
+		{token: "Bearer other", wantEffect: EffectDeny, wantResource: methodArn},
+		{token: "Bearer broken", wantErr: errors.New("authorizer handler failed status=500")},
+	}
+	handler := authorizerHandler(h, newOptions(nil))
+	for i, tt := range tests {
+		request := events.APIGatewayCustomAuthorizerRequestTypeRequest{
+			Type:                  "REQUEST",
+			MethodArn:             methodArn,
+			Path:                  "/items",
+			HTTPMethod:            "GET",
+			Headers:               map[string]string{"Authorization": tt.token, "Accept-Encoding": "gzip"},
+			QueryStringParameters: map[string]string{"q": "x"},
+		}
+		response, err := handler(context.Background(), request)
+		if tt.wantErr != nil {
+			if err == nil || err.Error() != tt.wantErr.Error() {
+				t.Errorf("%d: got error %v, want %v", i, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		want := events.APIGatewayCustomAuthorizerPolicy{
+			Version: "2012-10-17",
+			Statement: []events.IAMPolicyStatement{{
+				Action:   []string{"execute-api:Invoke"},
+				Effect:   tt.wantEffect,
+				Resource: []string{tt.wantResource},
+			}},
+		}
+		if got := response.PolicyDocument; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got policy %+v, want %+v", i, got, want)
+		}
+		if got, want := response.PrincipalID, tt.wantPrincipal; got != want {
+			t.Errorf("%d: got principal %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/jjeffery/kv"
+)
+
+// Baggage contains the W3C baggage entries received in the baggage request
+// header, keyed by name. The values are percent-decoded. Any properties of
+// the entries are not kept. See https://www.w3.org/TR/baggage/.
+type Baggage map[string]string
+
+// RequestBaggage returns the baggage of the request associated with ctx,
+// or nil if the request did not have a valid baggage header.
+func RequestBaggage(ctx context.Context) Baggage {
+	b, _ := ctx.Value(ctxKeyBaggage).(Baggage)
+	return b
+}
+
+// ParseBaggage parses the value of a baggage header. Multiple baggage
+// headers should be joined with commas before parsing.
+func ParseBaggage(s string) (Baggage, error) {
+	b := make(Baggage)
+	for _, member := range strings.Split(s, ",") {
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			// properties are not kept
+			member = member[:i]
+		}
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		i := strings.IndexByte(member, '=')
+		if i <= 0 {
+			return nil, kv.NewError("invalid baggage member").With("member", member)
+		}
+		key := strings.TrimSpace(member[:i])
+		if !validHeaderName(key) {
+			return nil, kv.NewError("invalid baggage key").With("key", key)
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(member[i+1:]))
+		if err != nil {
+			return nil, kv.Wrap(err, "invalid baggage value").With("key", key)
+		}
+		b[key] = value
+	}
+	return b, nil
+}
+
+// String returns the value of the baggage header for b. The entries are
+// sorted by key.
+func (b Baggage) String() string {
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		writeBaggageValue(&sb, b[k])
+	}
+	return sb.String()
+}
+
+// Inject sets the baggage header in header, so that an outgoing request
+// propagates the baggage. If b is empty, the baggage header is removed.
+func (b Baggage) Inject(header http.Header) {
+	if len(b) == 0 {
+		header.Del("Baggage")
+		return
+	}
+	header.Set("Baggage", b.String())
+}
+
+// baggageHandler adds the baggage of the request, if any, to the request
+// context.
+func baggageHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if values := r.Header.Values("Baggage"); len(values) > 0 {
+			if b, err := ParseBaggage(strings.Join(values, ",")); err == nil && len(b) > 0 {
+				r = r.WithContext(context.WithValue(r.Context(), ctxKeyBaggage, b))
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// writeBaggageValue writes v, percent-encoding any characters that are not
+// permitted in a baggage value.
+func writeBaggageValue(sb *strings.Builder, v string) {
+	const hex = "0123456789ABCDEF"
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c > 0x20 && c < 0x7f && c != '"' && c != ',' && c != ';' && c != '\\' && c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(hex[c>>4])
+		sb.WriteByte(hex[c&0x0f])
+	}
+}
@@ -0,0 +1,84 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestParseBaggage(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    Baggage
+		wantErr bool
+	}{
+		{header: "tenant=acme", want: Baggage{"tenant": "acme"}},
+		{header: " tenant = acme , flag=on;ttl=60", want: Baggage{"tenant": "acme", "flag": "on"}},
+		{header: "user=J%C3%BCrgen%20M", want: Baggage{"user": "Jürgen M"}},
+		{header: "", want: Baggage{}},
+		{header: "tenant", wantErr: true},
+		{header: "ten ant=acme", wantErr: true},
+		{header: "tenant=%zz", wantErr: true},
+	}
+	for i, tt := range tests {
+		got, err := ParseBaggage(tt.header)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%d: got %v, want error", i, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: got %v, want no error", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d: got=%v, want=%v", i, got, tt.want)
+		}
+	}
+}
+
+func TestBaggageString(t *testing.T) {
+	b := Baggage{"user": "Jürgen M", "tenant": "acme", "list": "a,b;c%"}
+	if got, want := b.String(), "list=a%2Cb%3Bc%25,tenant=acme,user=J%C3%BCrgen%20M"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	parsed, err := ParseBaggage(b.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, b) {
+		t.Errorf("got=%v, want=%v", parsed, b)
+	}
+}
+
+func TestRequestBaggage(t *testing.T) {
+	var b Baggage
+	opts := newOptions(nil)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b = RequestBaggage(r.Context())
+	})
+	handler := apiGatewayHandler(opts.wrap(h), opts)
+	if _, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		Path:    "/",
+		Headers: map[string]string{"baggage": "tenant=acme,experiment=blue"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b, (Baggage{"tenant": "acme", "experiment": "blue"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	outgoing := make(http.Header)
+	b.Inject(outgoing)
+	if got, want := outgoing.Get("Baggage"), "experiment=blue,tenant=acme"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	Baggage(nil).Inject(outgoing)
+	if got := outgoing.Get("Baggage"); got != "" {
+		t.Errorf("got=%q, want no baggage header", got)
+	}
+}
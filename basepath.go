@@ -0,0 +1,117 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WithBasePathMapping configures the adapter for an API that is mapped to
+// basePath on a custom domain name, such as "/orders". Depending on how the
+// API is invoked, the path in the event may or may not include the base
+// path. When it does, the base path is removed, so the handler always sees
+// the same path, regardless of whether the request was made using the
+// custom domain name or the execute-api endpoint. Use BasePath to build
+// URLs that are visible to clients.
+func WithBasePathMapping(basePath string) Option {
+	basePath = strings.TrimSuffix(basePath, "/")
+	if basePath != "" && !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	return func(o *options) {
+		o.basePathMapping = basePath
+	}
+}
+
+// BasePath returns the part of the path visible to the client that precedes
+// the path in the HTTP request. When the API is invoked using the
+// execute-api endpoint, this is the stage name, such as "/prod". Otherwise
+// it is the base path configured by WithBasePathMapping. BasePath returns
+// an empty string if there is no such prefix, or if ctx is not associated
+// with a request event.
+//
+// The endpoint is detected using the domain name in the request context
+// of the event.
+func BasePath(ctx context.Context) string {
+	inv, ok := ctx.Value(ctxKeyInvocation).(*invocation)
+	if !ok {
+		return ""
+	}
+	rc := &inv.request.RequestContext
+	if strings.Contains(rc.DomainName, ".execute-api.") {
+		if rc.Stage == "" || rc.Stage == "$default" {
+			return ""
+		}
+		return "/" + rc.Stage
+	}
+	return inv.opts.basePathMapping
+}
+
+// AbsoluteURL returns the URL of the request as it is visible to the
+// client, for use in OAuth redirect URIs, links in responses and canonical
+// URL headers. The URL is assembled from the scheme, the host, BasePath,
+// the mount path configured by WithMountPath, and the path and query of
+// r.URL.
+//
+// The scheme is taken from r.URL if set, see WithURLScheme, otherwise from
+// the X-Forwarded-Proto header, defaulting to "https" in AWS Lambda. When
+// running as a conventional HTTP server, the scheme is "https" for a TLS
+// connection, or else the X-Forwarded-Proto header if present, or "http".
+// The host is the Host header, or the domain name in the request context
+// of the event if there is none.
+//
+// Any prefix removed by the handler itself, for example by http.StripPrefix,
+// is not restored, so pass the request that the handler received.
+func AbsoluteURL(r *http.Request) *url.URL {
+	ctx := r.Context()
+	inv, _ := ctx.Value(ctxKeyInvocation).(*invocation)
+	u := &url.URL{
+		Scheme:   r.URL.Scheme,
+		Host:     r.URL.Host,
+		RawQuery: r.URL.RawQuery,
+	}
+	if u.Scheme == "" {
+		switch {
+		case inv != nil:
+			u.Scheme = requestScheme(r.Header)
+		case r.TLS != nil:
+			u.Scheme = "https"
+		case r.Header.Get("X-Forwarded-Proto") != "":
+			u.Scheme = requestScheme(r.Header)
+		default:
+			u.Scheme = "http"
+		}
+	}
+	if u.Host == "" {
+		u.Host = r.Host
+		if u.Host == "" && inv != nil {
+			u.Host = inv.request.RequestContext.DomainName
+		}
+	}
+	var prefix string
+	if inv != nil {
+		prefix = BasePath(ctx) + inv.opts.mountPath
+	} else {
+		prefix, _ = ctx.Value(ctxKeyMountPath).(string)
+	}
+	rawPath := prefix + r.URL.EscapedPath()
+	if rawPath == "" {
+		rawPath = "/"
+	}
+	u.Path, _ = url.PathUnescape(rawPath)
+	if u.EscapedPath() != rawPath {
+		u.RawPath = rawPath
+	}
+	return u
+}
+
+// stripBasePath removes the base path mapping from path, if present.
+func stripBasePath(path string, o *options) string {
+	if o.basePathMapping != "" {
+		if stripped, ok := stripMountPath(path, o.basePathMapping); ok {
+			return stripped
+		}
+	}
+	return path
+}
@@ -0,0 +1,149 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestBasePathMapping(t *testing.T) {
+	var path, basePath string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		basePath = BasePath(r.Context())
+	})
+	handler := apiGatewayHandler(h, newOptions([]Option{WithBasePathMapping("orders/")}))
+
+	tests := []struct {
+		path         string
+		domainName   string
+		stage        string
+		wantPath     string
+		wantBasePath string
+	}{
+		{path: "/orders/123", domainName: "api.example.com", stage: "prod", wantPath: "/123", wantBasePath: "/orders"},
+		{path: "/123", domainName: "api.example.com", stage: "prod", wantPath: "/123", wantBasePath: "/orders"},
+		{path: "/orders", domainName: "api.example.com", stage: "prod", wantPath: "/", wantBasePath: "/orders"},
+		{path: "/ordersx/1", domainName: "api.example.com", stage: "prod", wantPath: "/ordersx/1", wantBasePath: "/orders"},
+		{path: "/123", domainName: "abc123.execute-api.us-east-1.amazonaws.com", stage: "prod", wantPath: "/123", wantBasePath: "/prod"},
+		{path: "/123", domainName: "abc123.execute-api.us-east-1.amazonaws.com", stage: "$default", wantPath: "/123"},
+	}
+	for i, tt := range tests {
+		if _, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Path:       tt.path,
+			RequestContext: events.APIGatewayProxyRequestContext{
+				DomainName: tt.domainName,
+				Stage:      tt.stage,
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := path, tt.wantPath; got != want {
+			t.Errorf("%d: got path %q, want %q", i, got, want)
+		}
+		if got, want := basePath, tt.wantBasePath; got != want {
+			t.Errorf("%d: got base path %q, want %q", i, got, want)
+		}
+	}
+	if got := BasePath(context.Background()); got != "" {
+		t.Errorf("got %q, want empty base path", got)
+	}
+}
+
+func TestAbsoluteURL(t *testing.T) {
+	var got string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = AbsoluteURL(r).String()
+	})
+	tests := []struct {
+		opts    []Option
+		request events.APIGatewayProxyRequest
+		want    string
+	}{
+		{
+			request: events.APIGatewayProxyRequest{
+				Path:                  "/users/a%2Fb",
+				Headers:               map[string]string{"Host": "api.example.com"},
+				QueryStringParameters: map[string]string{"q": "x y"},
+			},
+			want: "https://api.example.com/users/a%2Fb?q=x+y",
+		},
+		{
+			request: events.APIGatewayProxyRequest{
+				Path: "/users",
+				RequestContext: events.APIGatewayProxyRequestContext{
+					DomainName: "abc123.execute-api.us-east-1.amazonaws.com",
+					Stage:      "prod",
+				},
+			},
+			want: "https://abc123.execute-api.us-east-1.amazonaws.com/prod/users",
+		},
+		{
+			opts: []Option{WithBasePathMapping("/orders"), WithMountPath("/api")},
+			request: events.APIGatewayProxyRequest{
+				Path: "/orders/api/123",
+				Headers: map[string]string{
+					"Host":              "example.com",
+					"X-Forwarded-Proto": "http",
+				},
+			},
+			want: "http://example.com/orders/api/123",
+		},
+		{
+			opts: []Option{WithURLScheme()},
+			request: events.APIGatewayProxyRequest{
+				Path:    "/",
+				Headers: map[string]string{"Host": "example.com"},
+			},
+			want: "https://example.com/",
+		},
+	}
+	for i, tt := range tests {
+		o := newOptions(tt.opts)
+		got = ""
+		if _, err := apiGatewayHandler(o.wrap(h), o)(context.Background(), tt.request); err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got != tt.want {
+			t.Errorf("%d: got %q, want %q", i, got, tt.want)
+		}
+	}
+}
+
+func TestAbsoluteURLLocal(t *testing.T) {
+	var got string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = AbsoluteURL(r).String()
+	})
+	handler := Handler(h, WithMountPath("/api"))
+	tests := []struct {
+		tls   bool
+		proto string
+		want  string
+	}{
+		{want: "http://example.com/api/users?id=1"},
+		{tls: true, want: "https://example.com/api/users?id=1"},
+		{proto: "https", want: "https://example.com/api/users?id=1"},
+	}
+	for i, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/users?id=1", nil)
+		r.Host = "example.com"
+		r.TLS = nil
+		if tt.tls {
+			r.TLS = &tls.ConnectionState{}
+		}
+		if tt.proto != "" {
+			r.Header.Set("X-Forwarded-Proto", tt.proto)
+		}
+		got = ""
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		if got != tt.want {
+			t.Errorf("%d: got %q, want %q", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package apigatewayproxy
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// WithUnreadBodyHook configures the adapter to call hook when the handler
+// returns without reading all of a request body of at least minSize bytes.
+// The unread argument is the number of bytes that were not read. A handler
+// that ignores a large body often indicates a routing or authorization bug,
+// such as a request being rejected before its body is parsed.
+func WithUnreadBodyHook(minSize int64, hook func(r *http.Request, unread int64)) Option {
+	return func(o *options) {
+		o.unreadBodyMinSize = minSize
+		o.onUnreadBody = hook
+	}
+}
+
+// requestBody is the body of an HTTP request created from an event. It
+// counts the bytes read, and Close can be called any number of times.
+// The body is closed when the handler returns, because the buffer holding
+// a decoded body is returned to the pool. After the body is closed, Read
+// returns http.ErrBodyReadAfterClose.
+type requestBody struct {
+	mu     sync.Mutex
+	r      io.Reader
+	size   int64
+	read   int64
+	closed bool
+}
+
+func (b *requestBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, http.ErrBodyReadAfterClose
+	}
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+func (b *requestBody) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	return nil
+}
+
+// unread returns the number of bytes that have not been read.
+func (b *requestBody) unread() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size - b.read
+}
+
+// closeBody is called when the handler returns. It reports an unread body
+// if configured, and closes the body.
+func (inv *invocation) closeBody(r *http.Request) {
+	b := &inv.reqBody
+	if b.r == nil {
+		// no body
+		return
+	}
+	if o := inv.opts; o.onUnreadBody != nil && b.size >= o.unreadBodyMinSize {
+		if unread := b.unread(); unread > 0 {
+			o.onUnreadBody(r, unread)
+		}
+	}
+	b.Close()
+}
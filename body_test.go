@@ -0,0 +1,91 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestRequestBody(t *testing.T) {
+	var body io.ReadCloser
+	var contentLength int64
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = r.Body
+		contentLength = r.ContentLength
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("got %v, want no error", err)
+		}
+		if got, want := string(b), "hello world"; got != want {
+			t.Errorf("got=%q, want=%q", got, want)
+		}
+		for i := 0; i < 2; i++ {
+			if err := r.Body.Close(); err != nil {
+				t.Errorf("got %v, want no error on close", err)
+			}
+		}
+	})
+	handler := apiGatewayHandler(h, newOptions(nil))
+
+	for _, request := range []events.APIGatewayProxyRequest{
+		{Path: "/", Body: "hello world"},
+		{Path: "/", Body: base64.StdEncoding.EncodeToString([]byte("hello world")), IsBase64Encoded: true},
+	} {
+		if _, err := handler(context.Background(), request); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := contentLength, int64(11); got != want {
+			t.Errorf("got content length %d, want %d", got, want)
+		}
+		if _, err := body.Read(make([]byte, 1)); err != http.ErrBodyReadAfterClose {
+			t.Errorf("got %v, want %v", err, http.ErrBodyReadAfterClose)
+		}
+	}
+}
+
+func TestUnreadBodyHook(t *testing.T) {
+	var unread []int64
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/read" {
+			ioutil.ReadAll(r.Body)
+		} else {
+			r.Body.Read(make([]byte, 10))
+		}
+	})
+	handler := apiGatewayHandler(h, newOptions([]Option{
+		WithUnreadBodyHook(1000, func(r *http.Request, n int64) {
+			unread = append(unread, n)
+		}),
+	}))
+
+	tests := []struct {
+		path       string
+		body       string
+		wantUnread []int64
+	}{
+		{path: "/ignore", body: strings.Repeat("x", 1000), wantUnread: []int64{990}},
+		{path: "/read", body: strings.Repeat("x", 1000)},
+		{path: "/ignore", body: strings.Repeat("x", 999)},
+	}
+	for i, tt := range tests {
+		unread = nil
+		if _, err := handler(context.Background(), events.APIGatewayProxyRequest{Path: tt.path, Body: tt.body}); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := len(unread), len(tt.wantUnread); got != want {
+			t.Errorf("%d: got %v, want %v", i, unread, tt.wantUnread)
+			continue
+		}
+		for j := range unread {
+			if got, want := unread[j], tt.wantUnread[j]; got != want {
+				t.Errorf("%d: got %d, want %d", i, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"unicode/utf8"
+)
+
+// DefaultMaxLoggedBodySize is the default maximum number of bytes of each
+// request and response body included in body logging.
+const DefaultMaxLoggedBodySize = 64 * 1024
+
+// redactionMargin is the number of bytes captured after MaxBodySize, so
+// that fields spanning the truncation point are still redacted.
+const redactionMargin = 1024
+
+// BodyLogging configures the logging of request and response bodies.
+type BodyLogging struct {
+	// SampleRate is the fraction of requests that are logged, between 0 and 1.
+	SampleRate float64
+
+	// ErrorStatus is the lowest response status that is always logged,
+	// regardless of the sample rate. If zero, 500 is used. Set it to a
+	// negative value to log sampled requests only.
+	ErrorStatus int
+
+	// MaxBodySize is the maximum number of bytes of each body that is logged,
+	// after redaction. If zero, DefaultMaxLoggedBodySize is used.
+	MaxBodySize int
+
+	// RedactHeaders are the headers whose values are redacted, in addition to
+	// credentials such as Authorization, Cookie and Set-Cookie.
+	RedactHeaders []string
+
+	// Redact, if not nil, is called to redact each body before it is logged.
+	// See RedactPatterns.
+	Redact func(body []byte) []byte
+}
+
+// WithBodyLogging configures the adapter to log the request and response
+// bodies of a sample of requests, and of requests that fail. This is a
+// debugging facility: the bodies are logged using the logger returned by
+// Logger, so it is usually combined with WithLogger. Credentials in
+// headers are always redacted, and config.Redact can redact fields
+// in the bodies.
+func WithBodyLogging(config BodyLogging) Option {
+	if config.ErrorStatus == 0 {
+		config.ErrorStatus = http.StatusInternalServerError
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = DefaultMaxLoggedBodySize
+	}
+	redact := append([]string(nil), redactedHeaders...)
+	for _, name := range config.RedactHeaders {
+		redact = append(redact, textproto.CanonicalMIMEHeaderKey(name))
+	}
+	config.RedactHeaders = redact
+	return func(o *options) {
+		o.bodyLogging = &config
+	}
+}
+
+// RedactPatterns returns a redaction function for BodyLogging that replaces
+// each match of the patterns with "[REDACTED]". If a pattern has a
+// capturing group, only the first group is replaced, so the pattern
+// `"password":"([^"]*)"` redacts the value and keeps the field name.
+func RedactPatterns(patterns ...*regexp.Regexp) func(body []byte) []byte {
+	return func(body []byte) []byte {
+		for _, re := range patterns {
+			body = re.ReplaceAllFunc(body, func(match []byte) []byte {
+				if re.NumSubexp() == 0 {
+					return []byte(Redacted)
+				}
+				loc := re.FindSubmatchIndex(match)
+				if loc[2] < 0 {
+					return match
+				}
+				var b []byte
+				b = append(b, match[:loc[2]]...)
+				b = append(b, Redacted...)
+				return append(b, match[loc[3]:]...)
+			})
+		}
+		return body
+	}
+}
+
+// bodyLogHandler captures the request and response bodies, and logs them
+// if the request is sampled or the response status is an error.
+func bodyLogHandler(h http.Handler, config *BodyLogging) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sampled := config.SampleRate > 0 && rand.Float64() < config.SampleRate
+		if !sampled && config.ErrorStatus < 0 {
+			h.ServeHTTP(w, r)
+			return
+		}
+		capture := config.MaxBodySize + redactionMargin
+		requestBody := &limitedBuffer{max: capture}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = readCloser{io.TeeReader(r.Body, requestBody), r.Body}
+		}
+		bw := &bodyLogWriter{
+			ResponseWriter: w,
+			body:           limitedBuffer{max: capture},
+		}
+		h.ServeHTTP(bw, r)
+		if bw.status == 0 {
+			bw.status = http.StatusOK
+		}
+		if !sampled && bw.status < config.ErrorStatus {
+			return
+		}
+		Logger(r.Context()).LogAttrs(r.Context(), slog.LevelInfo, "http exchange",
+			slog.String("method", r.Method),
+			slog.String("url", r.URL.String()),
+			slog.Int("status", bw.status),
+			slog.Bool("sampled", sampled),
+			slog.Any("requestHeader", redactHeader(r.Header, config.RedactHeaders)),
+			loggedBody("requestBody", requestBody, config),
+			slog.Any("responseHeader", redactHeader(w.Header(), config.RedactHeaders)),
+			loggedBody("responseBody", &bw.body, config),
+		)
+	})
+}
+
+// loggedBody returns the attribute for a logged body. The body is redacted
+// before it is truncated. Bodies that are not valid UTF-8 are base64 encoded.
+func loggedBody(key string, body *limitedBuffer, config *BodyLogging) slog.Attr {
+	b := body.buf.Bytes()
+	if config.Redact != nil {
+		b = config.Redact(b)
+	}
+	truncated := body.size > body.buf.Len()
+	if len(b) > config.MaxBodySize {
+		b = b[:config.MaxBodySize]
+		truncated = true
+	}
+	attrs := []slog.Attr{slog.Int("size", body.size)}
+	if utf8.Valid(b) {
+		attrs = append(attrs, slog.String("text", string(b)))
+	} else {
+		attrs = append(attrs, slog.String("base64", base64.StdEncoding.EncodeToString(b)))
+	}
+	if truncated {
+		attrs = append(attrs, slog.Bool("truncated", true))
+	}
+	return slog.Attr{Key: key, Value: slog.GroupValue(attrs...)}
+}
+
+// limitedBuffer keeps the first max bytes written to it, and counts
+// the total number of bytes written.
+type limitedBuffer struct {
+	buf  bytes.Buffer
+	max  int
+	size int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.size += len(p)
+	if n := b.max - b.buf.Len(); n > 0 {
+		if len(p) > n {
+			b.buf.Write(p[:n])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyLogWriter captures the status and body of the response.
+type bodyLogWriter struct {
+	http.ResponseWriter
+	status int
+	body   limitedBuffer
+}
+
+func (w *bodyLogWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (w *bodyLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,115 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBodyLogging(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		w.Write(body)
+	})
+	tests := []struct {
+		config BodyLogging
+		path   string
+		logged bool
+	}{
+		{config: BodyLogging{}, path: "/ok", logged: false},
+		{config: BodyLogging{}, path: "/fail", logged: true},
+		{config: BodyLogging{SampleRate: 1}, path: "/ok", logged: true},
+		{config: BodyLogging{ErrorStatus: -1}, path: "/fail", logged: false},
+	}
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		tt.config.MaxBodySize = 40
+		tt.config.RedactHeaders = []string{"x-secret"}
+		tt.config.Redact = RedactPatterns(regexp.MustCompile(`"password":"([^"]*)"`))
+		handler := Handler(h,
+			WithLogger(func(RequestInfo) *slog.Logger { return logger }),
+			WithBodyLogging(tt.config),
+		)
+		body := `{"user":"alice","password":"hunter2","padding":"xxxxxxxxxxxxxxxx"}`
+		r := httptest.NewRequest("POST", tt.path, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer token")
+		r.Header.Set("X-Secret", "shh")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if got, want := w.Body.String(), body; got != want {
+			t.Errorf("%d: got response %q, want %q", i, got, want)
+		}
+		if !tt.logged {
+			if buf.Len() > 0 {
+				t.Errorf("%d: got log %s, want none", i, buf.String())
+			}
+			continue
+		}
+		var record struct {
+			Status         int
+			RequestHeader  map[string][]string
+			ResponseHeader map[string][]string
+			RequestBody    struct {
+				Size      int
+				Text      string
+				Truncated bool
+			}
+		}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("%d: cannot decode log %q: %v", i, buf.String(), err)
+		}
+		if got, want := record.Status, w.Code; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		for _, name := range []string{"Authorization", "X-Secret"} {
+			if got, want := record.RequestHeader[name], []string{Redacted}; len(got) != 1 || got[0] != want[0] {
+				t.Errorf("%d: got %s=%v, want %v", i, name, got, want)
+			}
+		}
+		if got := record.ResponseHeader["Set-Cookie"]; len(got) != 1 || got[0] != Redacted {
+			t.Errorf("%d: got Set-Cookie=%v, want redacted", i, got)
+		}
+		if got, want := record.RequestBody.Text, `{"user":"alice","password":"[REDACTED]",`; got != want {
+			t.Errorf("%d: got body %q, want %q", i, got, want)
+		}
+		if got, want := record.RequestBody.Size, len(body); got != want {
+			t.Errorf("%d: got size %d, want %d", i, got, want)
+		}
+		if !record.RequestBody.Truncated {
+			t.Errorf("%d: got not truncated, want truncated", i)
+		}
+	}
+}
+
+func TestBodyLoggingCapture(t *testing.T) {
+	large := strings.Repeat("x", 1<<20)
+	var captured int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+		captured = w.(*bodyLogWriter).body.buf.Len()
+	})
+	config := BodyLogging{SampleRate: 1, MaxBodySize: 100}
+	handler := Handler(h,
+		WithLogger(func(RequestInfo) *slog.Logger { return slog.New(slog.NewJSONHandler(io.Discard, nil)) }),
+		WithBodyLogging(config),
+	)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(large)))
+	if got, want := w.Body.Len(), len(large); got != want {
+		t.Errorf("got response size %d, want %d", got, want)
+	}
+	if got, want := captured, config.MaxBodySize+redactionMargin; got != want {
+		t.Errorf("got captured %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,157 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default circuit breaker settings.
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// CircuitBreaker configures a circuit breaker for the handler. After
+// Threshold consecutive failures the circuit opens, and requests receive
+// a 503 (Service Unavailable) response without calling the handler. After
+// Cooldown, one request is passed to the handler: if it succeeds the
+// circuit closes, otherwise it stays open for another Cooldown.
+type CircuitBreaker struct {
+	Threshold int           // consecutive failures that open the circuit, default DefaultBreakerThreshold
+	Cooldown  time.Duration // time the circuit stays open, default DefaultBreakerCooldown
+
+	// IsFailure reports whether a response status code is a failure. If
+	// IsFailure is nil, 5xx status codes are failures. Panics, and requests
+	// whose context deadline is exceeded, are always failures.
+	IsFailure func(status int) bool
+
+	// ContentType and Body are the response while the circuit is open. If
+	// Body is empty, the response is written using WriteError.
+	ContentType string
+	Body        string
+}
+
+// WithCircuitBreaker configures the adapter to protect the handler, and the
+// services it depends on, with a circuit breaker. The circuit breaker is
+// kept in memory, so it behaves the same in AWS Lambda and when running as
+// a conventional HTTP server, but each Lambda container has its own circuit.
+// Responses while the circuit is open include a Retry-After header.
+func WithCircuitBreaker(cb CircuitBreaker) Option {
+	if cb.Threshold <= 0 {
+		cb.Threshold = DefaultBreakerThreshold
+	}
+	if cb.Cooldown <= 0 {
+		cb.Cooldown = DefaultBreakerCooldown
+	}
+	if cb.IsFailure == nil {
+		cb.IsFailure = func(status int) bool { return status >= 500 }
+	}
+	return func(o *options) {
+		o.circuitBreaker = &cb
+	}
+}
+
+// breaker is the state of a circuit breaker.
+type breaker struct {
+	config   CircuitBreaker
+	mutex    sync.Mutex
+	failures int       // consecutive failures
+	openedAt time.Time // time the circuit opened, zero if closed
+	trial    bool      // a trial request is in progress
+	now      func() time.Time
+}
+
+func newBreaker(config CircuitBreaker) *breaker {
+	return &breaker{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// allow reports whether a request can be passed to the handler. If not,
+// it returns the time until the next trial request.
+func (b *breaker) allow() (bool, time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.openedAt.IsZero() {
+		return true, 0
+	}
+	if wait := b.openedAt.Add(b.config.Cooldown).Sub(b.now()); wait > 0 || b.trial {
+		return false, wait
+	}
+	b.trial = true
+	return true, 0
+}
+
+// done records the result of a request passed to the handler.
+func (b *breaker) done(failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.trial = false
+	if !failed {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= b.config.Threshold || !b.openedAt.IsZero() {
+		b.openedAt = b.now()
+	}
+}
+
+// breakerHandler passes requests to h while the circuit is closed.
+func breakerHandler(h http.Handler, b *breaker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := b.allow()
+		if !ok {
+			// while a trial request is in progress, wait is zero
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(wait.Seconds())))))
+			writeUnavailable(w, r, b.config.ContentType, b.config.Body, "circuit open")
+			return
+		}
+		bw := &statusWriter{ResponseWriter: w}
+		failed := true
+		defer func() {
+			// a panic is a failure, and continues to propagate
+			b.done(failed)
+		}()
+		h.ServeHTTP(bw, r)
+		failed = b.config.IsFailure(bw.statusCode()) || r.Context().Err() == context.DeadlineExceeded
+	})
+}
+
+// statusWriter records the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *statusWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
@@ -0,0 +1,91 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := newOptions([]Option{WithCircuitBreaker(CircuitBreaker{
+		Threshold:   2,
+		Cooldown:    10 * time.Second,
+		ContentType: "application/json",
+		Body:        `{"error":"unavailable"}`,
+	})})
+	config := *opts.circuitBreaker
+	b := newBreaker(config)
+	b.now = func() time.Time { return now }
+
+	var calls int
+	fail := true
+	h := breakerHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if fail {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}), b)
+
+	tests := []struct {
+		advance    time.Duration
+		fail       bool
+		wantStatus int
+		wantCalls  int
+		wantRetry  string
+	}{
+		{fail: true, wantStatus: 502, wantCalls: 1},
+		{fail: true, wantStatus: 502, wantCalls: 2},
+		{fail: false, wantStatus: 503, wantCalls: 2, wantRetry: "10"},
+		{advance: 5 * time.Second, wantStatus: 503, wantCalls: 2, wantRetry: "5"},
+		{advance: 5 * time.Second, fail: true, wantStatus: 502, wantCalls: 3},
+		{fail: false, wantStatus: 503, wantCalls: 3, wantRetry: "10"},
+		{advance: 10 * time.Second, fail: false, wantStatus: 200, wantCalls: 4},
+		{fail: true, wantStatus: 502, wantCalls: 5},
+		{fail: false, wantStatus: 200, wantCalls: 6},
+	}
+	for i, tt := range tests {
+		now = now.Add(tt.advance)
+		fail = tt.fail
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if got, want := w.Code, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if got, want := calls, tt.wantCalls; got != want {
+			t.Errorf("%d: got %d calls, want %d", i, got, want)
+		}
+		if got, want := w.Header().Get("Retry-After"), tt.wantRetry; got != want {
+			t.Errorf("%d: got Retry-After=%q, want %q", i, got, want)
+		}
+		if w.Code == http.StatusServiceUnavailable {
+			if got, want := w.Body.String(), config.Body; got != want {
+				t.Errorf("%d: got body %q, want %q", i, got, want)
+			}
+			if got, want := w.Header().Get("Content-Type"), "application/json"; got != want {
+				t.Errorf("%d: got content type %q, want %q", i, got, want)
+			}
+		}
+	}
+}
+
+func TestCircuitBreakerPanic(t *testing.T) {
+	b := newBreaker(CircuitBreaker{Threshold: 1, Cooldown: time.Minute, IsFailure: func(int) bool { return false }})
+	h := breakerHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), b)
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("got panic %v, want boom", p)
+			}
+		}()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if got, want := w.Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+}
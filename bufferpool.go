@@ -0,0 +1,80 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"sync"
+)
+
+// DefaultMaxBufferSize is the maximum capacity of a buffer that is retained
+// by the default buffer pool for reuse. Larger buffers are discarded after
+// use, so that one very large response does not pin memory for the
+// lifetime of the Lambda container.
+const DefaultMaxBufferSize = 1 << 20
+
+// BufferPool is a pool of buffers used for decoding request bodies and
+// for buffering response bodies.
+type BufferPool interface {
+	// Get returns an empty buffer from the pool.
+	Get() *bytes.Buffer
+
+	// Put returns a buffer to the pool when it is no longer used.
+	Put(buf *bytes.Buffer)
+}
+
+// WithBufferPool sets the pool used for request and response buffers. The
+// default pool is created using NewBufferPool(DefaultMaxBufferSize). If pool is
+// nil, buffers are allocated for each request and are not reused.
+func WithBufferPool(pool BufferPool) Option {
+	return func(o *options) {
+		o.bufferPool = pool
+	}
+}
+
+// NewBufferPool returns a buffer pool based on sync.Pool. Buffers with a
+// capacity larger than maxSize are not retained for reuse. If maxSize is
+// zero or negative, all buffers are retained.
+func NewBufferPool(maxSize int) BufferPool {
+	return &syncBufferPool{
+		maxSize: maxSize,
+		pool: sync.Pool{
+			New: func() interface{} {
+				return new(bytes.Buffer)
+			},
+		},
+	}
+}
+
+var defaultBufferPool = NewBufferPool(DefaultMaxBufferSize)
+
+type syncBufferPool struct {
+	maxSize int
+	pool    sync.Pool
+}
+
+func (p *syncBufferPool) Get() *bytes.Buffer {
+	return p.pool.Get().(*bytes.Buffer)
+}
+
+func (p *syncBufferPool) Put(buf *bytes.Buffer) {
+	if p.maxSize > 0 && buf.Cap() > p.maxSize {
+		// let the garbage collector reclaim very large buffers
+		return
+	}
+	buf.Reset()
+	p.pool.Put(buf)
+}
+
+// getBuffer returns a buffer from pool, which may be nil.
+func getBuffer(pool BufferPool) *bytes.Buffer {
+	if pool == nil {
+		return new(bytes.Buffer)
+	}
+	return pool.Get()
+}
+
+// putBuffer returns buf to pool, which may be nil.
+func putBuffer(pool BufferPool, buf *bytes.Buffer) {
+	if pool != nil && buf != nil {
+		pool.Put(buf)
+	}
+}
@@ -0,0 +1,74 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type countingPool struct {
+	gets int
+	puts int
+}
+
+func (p *countingPool) Get() *bytes.Buffer {
+	p.gets++
+	return new(bytes.Buffer)
+}
+
+func (p *countingPool) Put(buf *bytes.Buffer) {
+	p.puts++
+}
+
+func TestBufferPool(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	})
+	for _, pool := range []BufferPool{nil, &countingPool{}} {
+		handler := apiGatewayHandler(h, newOptions([]Option{WithBufferPool(pool)}))
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod:      "POST",
+			Path:            "/test",
+			Body:            "VGhpcyBpcyB0aGUgYm9keQo=",
+			IsBase64Encoded: true,
+		})
+		if err != nil {
+			t.Fatalf("got %v, want no error", err)
+		}
+		if got, want := response.Body, "This is the body\n"; got != want {
+			t.Errorf("got=%q, want=%q", got, want)
+		}
+		if pool, ok := pool.(*countingPool); ok {
+			if got, want := pool.gets, 2; got != want {
+				t.Errorf("gets: got=%d, want=%d", got, want)
+			}
+			if got, want := pool.puts, 2; got != want {
+				t.Errorf("puts: got=%d, want=%d", got, want)
+			}
+		}
+	}
+}
+
+func TestBufferPoolMaxSize(t *testing.T) {
+	pool := NewBufferPool(64)
+	small := pool.Get()
+	small.WriteString("small")
+	pool.Put(small)
+	if got := pool.Get(); got.Len() != 0 {
+		t.Errorf("got buffer with len=%d, want empty", got.Len())
+	}
+
+	large := pool.Get()
+	large.Write(make([]byte, 1024))
+	pool.Put(large)
+	for i := 0; i < 10; i++ {
+		if got := pool.Get(); got == large {
+			t.Fatal("large buffer was retained")
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CacheStatusHeader is the response header that reports whether the
+// response was served from the response cache: "Hit" or "Miss".
+const CacheStatusHeader = "X-Cache"
+
+// WithResponseCache configures the adapter to cache responses to GET and
+// HEAD requests in store for ttl, and to return cached responses without
+// calling the handler. Responses are cached by API Gateway stage, host,
+// method, path and query string, and by the values of the request headers
+// named in varyHeaders.
+//
+// Only 200 (OK) responses are cached. Responses with a Set-Cookie header,
+// or with a Cache-Control header containing "no-store" or "private", are
+// not cached. As for a shared cache (RFC 9111, section 3.5), responses to
+// requests with an Authorization or Cookie header are only cached if the
+// Cache-Control header contains "public" or "s-maxage". Responses are also
+// cached by the values of the request headers named in their Vary header,
+// and responses with "Vary: *" are not cached. Requests with a Cache-Control
+// header containing "no-cache" are passed to the handler, and the response
+// replaces the cached response.
+func WithResponseCache(store ResponseStore, ttl time.Duration, varyHeaders ...string) Option {
+	vary := make([]string, len(varyHeaders))
+	for i, name := range varyHeaders {
+		vary[i] = textproto.CanonicalMIMEHeaderKey(name)
+	}
+	return func(o *options) {
+		o.cacheStore = store
+		o.cacheTTL = ttl
+		o.cacheVary = vary
+	}
+}
+
+// cacheHandler returns cached responses for requests, and caches the
+// responses from h.
+func cacheHandler(h http.Handler, o *options) http.Handler {
+	store, ttl, vary := o.cacheStore, o.cacheTTL, o.cacheVary
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			h.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		key := cacheKey(r, vary)
+		if !hasDirective(r.Header, "no-cache") {
+			cached, err := store.Get(ctx, key)
+			if cached != nil && cached.StatusCode == 0 {
+				// the responses vary on the request headers named in the index
+				key = cacheKey(r, append(vary[:len(vary):len(vary)], cached.Header.Values("Vary")...))
+				cached, err = store.Get(ctx, key)
+			}
+			if err != nil {
+				Logger(ctx).Warn("cannot get cached response", "key", key, "error", err)
+			}
+			if cached != nil {
+				w.Header().Set(CacheStatusHeader, "Hit")
+				writeStoredResponse(w, cached)
+				return
+			}
+		}
+		w.Header().Set(CacheStatusHeader, "Miss")
+		rw := &recordingWriter{ResponseWriter: w}
+		h.ServeHTTP(rw, r)
+		response := rw.response()
+		if !cacheable(r, response) {
+			return
+		}
+		response.Header.Del(CacheStatusHeader)
+		key = cacheKey(r, vary)
+		names, ok := responseVary(response.Header, vary)
+		if !ok {
+			return
+		}
+		if len(names) > 0 {
+			// The response varies on request headers that are not part of
+			// the key, so an index naming them is stored with the key, and
+			// the response is stored with a key that includes their values.
+			index := &StoredResponse{Header: http.Header{"Vary": names}}
+			if err := store.Put(ctx, key, index, ttl); err != nil {
+				Logger(ctx).Warn("cannot cache response", "key", key, "error", err)
+				return
+			}
+			key = cacheKey(r, append(vary[:len(vary):len(vary)], names...))
+		}
+		if err := store.Put(ctx, key, response, ttl); err != nil {
+			Logger(ctx).Warn("cannot cache response", "key", key, "error", err)
+		}
+	})
+}
+
+// cacheKey returns the key for caching the response to r. The key includes
+// the stage and host, so that stages and custom domains mapped to the same
+// function do not share responses.
+func cacheKey(r *http.Request, vary []string) string {
+	var sb strings.Builder
+	if info := Info(r.Context()); info != nil {
+		sb.WriteString(info.Stage)
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(r.Host)
+	sb.WriteByte(' ')
+	sb.WriteString(r.Method)
+	sb.WriteByte(' ')
+	sb.WriteString(r.URL.EscapedPath())
+	if query := r.URL.Query(); len(query) > 0 {
+		// Encode sorts by key, so that parameter order does not matter
+		sb.WriteByte('?')
+		sb.WriteString(query.Encode())
+	}
+	for _, name := range vary {
+		sb.WriteByte('\n')
+		sb.WriteString(name)
+		sb.WriteByte(':')
+		sb.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+	return sb.String()
+}
+
+// cacheable reports whether the response to r can be cached.
+func cacheable(r *http.Request, response *StoredResponse) bool {
+	if response.StatusCode != http.StatusOK {
+		return false
+	}
+	if _, ok := response.Header["Set-Cookie"]; ok {
+		return false
+	}
+	if hasDirective(response.Header, "no-store") || hasDirective(response.Header, "private") {
+		return false
+	}
+	if r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "" {
+		// the response may be specific to the credentials
+		return hasDirective(response.Header, "public") || hasDirective(response.Header, "s-maxage")
+	}
+	return true
+}
+
+// responseVary returns the names of the request headers in the Vary header
+// of the response that are not in vary. It returns false if the response
+// varies on all request headers, so cannot be cached.
+func responseVary(header http.Header, vary []string) ([]string, bool) {
+	var names []string
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return nil, false
+			}
+			if name != "" && !slices.Contains(vary, name) && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, true
+}
+
+// hasDirective reports whether the Cache-Control header contains the
+// directive.
+func hasDirective(header http.Header, directive string) bool {
+	for _, v := range header.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			d = strings.TrimSpace(d)
+			if i := strings.IndexByte(d, '='); i >= 0 {
+				d = d[:i]
+			}
+			if strings.EqualFold(d, directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestResponseCache(t *testing.T) {
+	var calls int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		case "/shared":
+			w.Header().Set("Cache-Control", "s-maxage=60")
+		case "/version":
+			w.Header().Set("Vary", "x-version")
+		case "/any":
+			w.Header().Set("Vary", "*")
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprintf(w, "call %d", calls)
+	})
+	opts := newOptions([]Option{WithResponseCache(NewMemoryResponseStore(), time.Minute, "accept-language")})
+	handler := apiGatewayHandler(opts.wrap(h), opts)
+
+	tests := []struct {
+		method    string
+		path      string
+		query     map[string]string
+		headers   map[string]string
+		wantBody  string
+		wantCache string
+	}{
+		{method: "GET", path: "/items", query: map[string]string{"a": "1", "b": "2"}, wantBody: "call 1", wantCache: "Miss"},
+		{method: "GET", path: "/items", query: map[string]string{"b": "2", "a": "1"}, wantBody: "call 1", wantCache: "Hit"},
+		{method: "GET", path: "/items", wantBody: "call 2", wantCache: "Miss"},
+		{method: "GET", path: "/items", headers: map[string]string{"Accept-Language": "fr"}, wantBody: "call 3", wantCache: "Miss"},
+		{method: "GET", path: "/items", headers: map[string]string{"Accept-Language": "fr"}, wantBody: "call 3", wantCache: "Hit"},
+		{method: "GET", path: "/items", headers: map[string]string{"Cache-Control": "no-cache"}, wantBody: "call 4", wantCache: "Miss"},
+		{method: "GET", path: "/items", wantBody: "call 4", wantCache: "Hit"},
+		{method: "POST", path: "/items", wantBody: "call 5"},
+		{method: "GET", path: "/private", wantBody: "call 6", wantCache: "Miss"},
+		{method: "GET", path: "/private", wantBody: "call 7", wantCache: "Miss"},
+		{method: "GET", path: "/missing", wantBody: "call 8", wantCache: "Miss"},
+		{method: "GET", path: "/missing", wantBody: "call 9", wantCache: "Miss"},
+		{method: "GET", path: "/items", headers: map[string]string{"Authorization": "Bearer a"}, wantBody: "call 4", wantCache: "Hit"},
+		{method: "GET", path: "/auth", headers: map[string]string{"Authorization": "Bearer a"}, wantBody: "call 10", wantCache: "Miss"},
+		{method: "GET", path: "/auth", headers: map[string]string{"Authorization": "Bearer b"}, wantBody: "call 11", wantCache: "Miss"},
+		{method: "GET", path: "/auth", headers: map[string]string{"Cookie": "session=a"}, wantBody: "call 12", wantCache: "Miss"},
+		{method: "GET", path: "/auth", headers: map[string]string{"Cookie": "session=b"}, wantBody: "call 13", wantCache: "Miss"},
+		{method: "GET", path: "/public", headers: map[string]string{"Authorization": "Bearer a"}, wantBody: "call 14", wantCache: "Miss"},
+		{method: "GET", path: "/public", headers: map[string]string{"Authorization": "Bearer b"}, wantBody: "call 14", wantCache: "Hit"},
+		{method: "GET", path: "/shared", headers: map[string]string{"Cookie": "session=a"}, wantBody: "call 15", wantCache: "Miss"},
+		{method: "GET", path: "/shared", headers: map[string]string{"Cookie": "session=b"}, wantBody: "call 15", wantCache: "Hit"},
+		{method: "GET", path: "/version", headers: map[string]string{"X-Version": "1"}, wantBody: "call 16", wantCache: "Miss"},
+		{method: "GET", path: "/version", headers: map[string]string{"X-Version": "1"}, wantBody: "call 16", wantCache: "Hit"},
+		{method: "GET", path: "/version", headers: map[string]string{"X-Version": "2"}, wantBody: "call 17", wantCache: "Miss"},
+		{method: "GET", path: "/version", headers: map[string]string{"X-Version": "2"}, wantBody: "call 17", wantCache: "Hit"},
+		{method: "GET", path: "/version", headers: map[string]string{"X-Version": "1"}, wantBody: "call 16", wantCache: "Hit"},
+		{method: "GET", path: "/any", wantBody: "call 18", wantCache: "Miss"},
+		{method: "GET", path: "/any", wantBody: "call 19", wantCache: "Miss"},
+	}
+	for i, tt := range tests {
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod:            tt.method,
+			Path:                  tt.path,
+			QueryStringParameters: tt.query,
+			Headers:               tt.headers,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := response.Body, tt.wantBody; got != want {
+			t.Errorf("%d: got body %q, want %q", i, got, want)
+		}
+		if got, want := response.Headers[CacheStatusHeader], tt.wantCache; got != want {
+			t.Errorf("%d: got cache %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestResponseCacheStage(t *testing.T) {
+	var calls int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, "call %d", calls)
+	})
+	opts := newOptions([]Option{WithResponseCache(NewMemoryResponseStore(), time.Minute)})
+	handler := apiGatewayHandler(opts.wrap(h), opts)
+
+	tests := []struct {
+		stage     string
+		host      string
+		wantBody  string
+		wantCache string
+	}{
+		{stage: "dev", host: "api.example.com", wantBody: "call 1", wantCache: "Miss"},
+		{stage: "prod", host: "api.example.com", wantBody: "call 2", wantCache: "Miss"},
+		{stage: "dev", host: "api.example.com", wantBody: "call 1", wantCache: "Hit"},
+		{stage: "prod", host: "api.example.com", wantBody: "call 2", wantCache: "Hit"},
+		{stage: "prod", host: "www.example.com", wantBody: "call 3", wantCache: "Miss"},
+	}
+	for i, tt := range tests {
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod:     "GET",
+			Path:           "/items",
+			Headers:        map[string]string{"Host": tt.host},
+			RequestContext: events.APIGatewayProxyRequestContext{Stage: tt.stage},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := response.Body, tt.wantBody; got != want {
+			t.Errorf("%d: got body %q, want %q", i, got, want)
+		}
+		if got, want := response.Headers[CacheStatusHeader], tt.wantCache; got != want {
+			t.Errorf("%d: got cache %q, want %q", i, got, want)
+		}
+	}
+}
+
+type testDynamoDBResponseAPI struct {
+	items map[string]map[string]string
+}
+
+func (api *testDynamoDBResponseAPI) GetItem(ctx context.Context, table string, key map[string]string) (map[string]string, error) {
+	return api.items[table+"/"+key["key"]], nil
+}
+
+func (api *testDynamoDBResponseAPI) PutItem(ctx context.Context, table string, item map[string]string) error {
+	api.items[table+"/"+item["key"]] = item
+	return nil
+}
+
+func TestDynamoDBResponseStore(t *testing.T) {
+	ctx := context.Background()
+	store := &DynamoDBResponseStore{
+		API:       &testDynamoDBResponseAPI{items: make(map[string]map[string]string)},
+		TableName: "responses",
+	}
+	want := &StoredResponse{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"application/octet-stream"}},
+		Body:       []byte{0, 1, 2, 0xff},
+	}
+	if err := store.Put(ctx, "k", want, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.StatusCode != want.StatusCode || string(got.Body) != string(want.Body) || got.Header.Get("Content-Type") != "application/octet-stream" {
+		t.Errorf("got=%+v, want=%+v", got, want)
+	}
+	if err := store.Put(ctx, "expired", want, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.Get(ctx, "expired"); got != nil {
+		t.Errorf("got=%+v, want nil for expired response", got)
+	}
+	if got, _ := store.Get(ctx, "missing"); got != nil {
+		t.Errorf("got=%+v, want nil for missing response", got)
+	}
+}
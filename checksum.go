@@ -0,0 +1,150 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jjeffery/kv"
+)
+
+// WithChecksumValidation configures the adapter to verify the request body
+// against the Content-MD5 header and the x-amz-checksum-* headers used by
+// the AWS SDKs, which are the base64 encoded CRC32, CRC32C, CRC64NVME, SHA1
+// or SHA256 checksum of the body. If any checksum does not match the decoded
+// body, the request receives a 400 (Bad Request) response without calling
+// the handler. Requests without checksum headers are not affected.
+//
+// This detects bodies that have been corrupted or truncated, for example by
+// a client that base64 encodes the body incorrectly. The body is verified in
+// the same way when running as a conventional HTTP server, which means that
+// a body with checksum headers is read into memory before calling the
+// handler.
+func WithChecksumValidation() Option {
+	return func(o *options) {
+		o.checksums = true
+	}
+}
+
+// crc64NVMETable is the table for the CRC64NVME checksum.
+var crc64NVMETable = crc64.MakeTable(0x9a6c9329ac4bc9b5)
+
+// checksumHeaders are the request headers containing a checksum of the
+// body, and the hash that computes each checksum.
+var checksumHeaders = []struct {
+	name string
+	hash func() hash.Hash
+}{
+	{"Content-Md5", md5.New},
+	{"X-Amz-Checksum-Crc32", func() hash.Hash { return crc32.NewIEEE() }},
+	{"X-Amz-Checksum-Crc32c", func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) }},
+	{"X-Amz-Checksum-Crc64nvme", func() hash.Hash { return crc64.New(crc64NVMETable) }},
+	{"X-Amz-Checksum-Sha1", sha1.New},
+	{"X-Amz-Checksum-Sha256", sha256.New},
+}
+
+// hasChecksum reports whether the header contains a checksum of the body.
+func hasChecksum(header http.Header) bool {
+	for _, c := range checksumHeaders {
+		if _, ok := header[c.name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyChecksums returns an error wrapping ErrChecksumMismatch if the body
+// read from r does not match the checksum headers. The body is read once,
+// whatever the number of checksums.
+func verifyChecksums(header http.Header, r io.Reader) error {
+	type checksum struct {
+		name string
+		want []byte
+		hash hash.Hash
+	}
+	var checksums []checksum
+	var writers []io.Writer
+	for _, c := range checksumHeaders {
+		value := header.Get(c.name)
+		if value == "" {
+			continue
+		}
+		want, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return kv.Wrap(ErrChecksumMismatch, "invalid checksum header").With("header", c.name)
+		}
+		h := c.hash()
+		checksums = append(checksums, checksum{name: c.name, want: want, hash: h})
+		writers = append(writers, h)
+	}
+	if len(checksums) == 0 {
+		return nil
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return kv.Wrap(err, "cannot read request body")
+	}
+	for _, c := range checksums {
+		if !bytes.Equal(c.hash.Sum(nil), c.want) {
+			return kv.Wrap(ErrChecksumMismatch, "checksum does not match").With("header", c.name)
+		}
+	}
+	return nil
+}
+
+// verifyChecksums verifies the decoded body of the request event without
+// reading the HTTP request body, which is still to be read by the handler.
+func (inv *invocation) verifyChecksums(r *http.Request) error {
+	if !inv.opts.checksums || !hasChecksum(r.Header) {
+		return nil
+	}
+	request := &inv.request
+	var body io.Reader
+	switch {
+	case inv.body != nil:
+		body = bytes.NewReader(inv.body.Bytes())
+	case request.IsBase64Encoded:
+		// the body is decoded as it is read, see WithStreamingMultipart
+		body = base64.NewDecoder(inv.opts.base64Encoding, strings.NewReader(request.Body))
+	default:
+		body = strings.NewReader(request.Body)
+	}
+	return verifyChecksums(r.Header, body)
+}
+
+// checksumHandler verifies the body of requests that are not received from
+// Lambda. Lambda requests are verified before the handler is called, using
+// the decoded event body.
+func checksumHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(ctxKeyInvocation) != nil || !hasChecksum(r.Header) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err == nil {
+			err = verifyChecksums(r.Header, bytes.NewReader(body))
+		}
+		if err != nil {
+			writeChecksumError(w, r, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h.ServeHTTP(w, r)
+	})
+}
+
+// writeChecksumError logs the error, and writes the 400 (Bad Request)
+// response for a body that does not match its checksum.
+func writeChecksumError(w http.ResponseWriter, r *http.Request, err error) {
+	Logger(r.Context()).Warn("invalid request body", "error", err)
+	WriteError(w, r, http.StatusBadRequest, "request body does not match checksum")
+}
@@ -0,0 +1,124 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestChecksumValidation(t *testing.T) {
+	body := "hello, world"
+	sum := func(b []byte) string { return base64.StdEncoding.EncodeToString(b) }
+	md5Sum := md5.Sum([]byte(body))
+	sha256Sum := sha256.Sum256([]byte(body))
+	crc := binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE([]byte(body)))
+	crcc := binary.BigEndian.AppendUint32(nil, crc32.Checksum([]byte(body), crc32.MakeTable(crc32.Castagnoli)))
+	// CRC64NVME of the check string "123456789"
+	if got, want := sum(crcFor(t, "123456789")), "rosUhgp5mIg="; got != want {
+		t.Errorf("got CRC64NVME %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		headers map[string]string
+		body    string
+		base64  bool
+		status  int
+	}{
+		{status: http.StatusOK},
+		{headers: map[string]string{"Content-MD5": sum(md5Sum[:])}, status: http.StatusOK},
+		{headers: map[string]string{"Content-MD5": sum(md5Sum[:])}, base64: true, status: http.StatusOK},
+		{headers: map[string]string{"x-amz-checksum-sha256": sum(sha256Sum[:])}, status: http.StatusOK},
+		{headers: map[string]string{"x-amz-checksum-crc32": sum(crc)}, status: http.StatusOK},
+		{headers: map[string]string{"x-amz-checksum-crc32c": sum(crcc)}, status: http.StatusOK},
+		{headers: map[string]string{"Content-MD5": sum(md5Sum[:])}, body: "hello, world!", status: http.StatusBadRequest},
+		{headers: map[string]string{"x-amz-checksum-crc32": sum(crcc)}, status: http.StatusBadRequest},
+		{headers: map[string]string{"Content-MD5": sum(md5Sum[:]), "x-amz-checksum-crc32": sum(crcc)}, status: http.StatusBadRequest},
+		{headers: map[string]string{"Content-MD5": "not base64"}, status: http.StatusBadRequest},
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the handler reads the whole body
+		b, _ := io.ReadAll(r.Body)
+		w.Write(b)
+	})
+	handler := apiGatewayHandler(h, newOptions([]Option{WithChecksumValidation()}))
+	local := Handler(h, WithChecksumValidation())
+	for i, tt := range tests {
+		if tt.body == "" {
+			tt.body = body
+		}
+		request := events.APIGatewayProxyRequest{
+			HTTPMethod: "PUT",
+			Path:       "/upload",
+			Headers:    tt.headers,
+			Body:       tt.body,
+		}
+		if tt.base64 {
+			request.Body = base64.StdEncoding.EncodeToString([]byte(tt.body))
+			request.IsBase64Encoded = true
+		}
+		response, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.StatusCode, tt.status; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if tt.status == http.StatusOK && response.Body != tt.body {
+			t.Errorf("%d: got body %q, want %q", i, response.Body, tt.body)
+		}
+
+		// the same when running as an HTTP server
+		r := httptest.NewRequest("PUT", "/upload", strings.NewReader(tt.body))
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		local.ServeHTTP(w, r)
+		if got, want := w.Code, tt.status; got != want {
+			t.Errorf("%d: got local status %d, want %d", i, got, want)
+		}
+		if tt.status == http.StatusOK && w.Body.String() != tt.body {
+			t.Errorf("%d: got local body %q, want %q", i, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestChecksumNotConfigured(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := apiGatewayHandler(h, newOptions(nil))
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "PUT",
+		Path:       "/upload",
+		Headers:    map[string]string{"Content-MD5": "AAAA"},
+		Body:       "hello",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := response.StatusCode, http.StatusOK; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+}
+
+func crcFor(t *testing.T, s string) []byte {
+	t.Helper()
+	for _, c := range checksumHeaders {
+		if c.name == "X-Amz-Checksum-Crc64nvme" {
+			h := c.hash()
+			io.WriteString(h, s)
+			return h.Sum(nil)
+		}
+	}
+	t.Fatal("no CRC64NVME checksum")
+	return nil
+}
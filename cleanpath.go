@@ -0,0 +1,166 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// TrailingSlash determines how request paths with a trailing slash are
+// handled.
+type TrailingSlash int
+
+// Trailing slash policies
+const (
+	// TrailingSlashPreserve passes the path to the handler unchanged. This
+	// is the default.
+	TrailingSlashPreserve TrailingSlash = iota
+
+	// TrailingSlashRedirect responds to a request for a path with a trailing
+	// slash, such as "/users/", with a 308 (Permanent Redirect) to the path
+	// without it, so that each resource has a single URL. The query string
+	// is preserved, and so is the method, because the status is 308. The
+	// root path "/" is not redirected.
+	TrailingSlashRedirect
+
+	// TrailingSlashStrip removes a trailing slash from the request path
+	// before calling the handler, so that "/users/" is handled as "/users".
+	// For requests received from Lambda, the slash is removed when the HTTP
+	// request is created from the event, so RequestURI and the path seen by
+	// all middleware are the same as when running as a conventional HTTP
+	// server. The root path "/" is not changed.
+	TrailingSlashStrip
+)
+
+// WithPathNormalization configures the adapter to normalize the request
+// path before calling the handler, as many reverse proxies do. Duplicate
+// slashes are collapsed, and "." and ".." segments are resolved, including
+// segments escaped as "%2e". For example, "/a//b/./c/../d" becomes "/a/b/d".
+// The URL and RequestURI fields of the request are updated, and a trailing
+// slash is preserved.
+//
+// This means that the path seen by the router is the same path that is used
+// by caches and authorization rules elsewhere, so that a request such as
+// "/public/../admin" cannot match one rule in API Gateway and another in the
+// handler. Escaped slashes ("%2F") are not treated as separators.
+func WithPathNormalization() Option {
+	return func(o *options) {
+		o.pathNormalization = true
+	}
+}
+
+// WithTrailingSlash sets the policy for request paths with a trailing
+// slash. API Gateway matches "/users" and "/users/" to the same resource or
+// route, whereas http.ServeMux treats a pattern with a trailing slash as a
+// subtree and redirects "/users" to "/users/" when only the subtree is
+// registered. Choosing TrailingSlashStrip or TrailingSlashRedirect means
+// the handler only needs to route paths without a trailing slash, in AWS
+// Lambda and when running as a conventional HTTP server. When used with
+// WithPathNormalization, the policy applies to the normalized path.
+func WithTrailingSlash(policy TrailingSlash) Option {
+	return func(o *options) {
+		o.trailingSlash = policy
+	}
+}
+
+// decodeDots decodes escaped dots, which are equivalent to unescaped dots
+// (RFC 3986 section 6.2.2.2), so that escaped dot segments are resolved.
+var decodeDots = strings.NewReplacer("%2e", ".", "%2E", ".")
+
+// cleanPath returns the normalized form of the escaped path p, which
+// preserves any trailing slash. If p is already normalized, it is returned
+// without allocating.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] == '/' && !strings.Contains(p, "//") && !strings.Contains(p, "/.") && !strings.Contains(p, "%2") {
+		return p
+	}
+	p = decodeDots.Replace(p)
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
+
+// normalizePathHandler applies the path normalization and trailing slash
+// policy before calling h.
+func normalizePathHandler(h http.Handler, o *options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		escaped := r.URL.EscapedPath()
+		p := escaped
+		if o.pathNormalization {
+			p = cleanPath(p)
+		}
+		switch o.trailingSlash {
+		case TrailingSlashStrip:
+			// requests from Lambda have already been stripped
+			p = stripTrailingSlash(p)
+		case TrailingSlashRedirect:
+			if stripped := stripTrailingSlash(p); stripped != p {
+				if WebSocketRequest(r.Context()) != nil {
+					// a WebSocket client cannot follow a redirect
+					p = stripped
+					break
+				}
+				redirectPath(w, r, stripped)
+				return
+			}
+		}
+		if p != escaped {
+			r = withEscapedPath(r, p)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// stripTrailingSlash removes any trailing slashes from the path p, unless
+// it is the root path.
+func stripTrailingSlash(p string) string {
+	if len(p) <= 1 || p[len(p)-1] != '/' {
+		return p
+	}
+	if p = strings.TrimRight(p, "/"); p == "" {
+		p = "/"
+	}
+	return p
+}
+
+// redirectPath sends a 308 (Permanent Redirect) response to the path p
+// with the query string of r.
+func redirectPath(w http.ResponseWriter, r *http.Request, p string) {
+	// the location is the path visible to the client
+	location := BasePath(r.Context()) + p
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, location, http.StatusPermanentRedirect)
+}
+
+// withEscapedPath returns a shallow copy of r with the escaped path p.
+func withEscapedPath(r *http.Request, p string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	if unescaped, err := url.PathUnescape(p); err == nil {
+		r2.URL.Path = unescaped
+	} else {
+		r2.URL.Path = p
+	}
+	r2.URL.RawPath = ""
+	if r2.URL.EscapedPath() != p {
+		r2.URL.RawPath = p
+	}
+	r2.RequestURI = p
+	if r.URL.RawQuery != "" {
+		r2.RequestURI += "?" + r.URL.RawQuery
+	}
+	return r2
+}
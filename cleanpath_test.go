@@ -0,0 +1,186 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "", want: "/"},
+		{path: "/", want: "/"},
+		{path: "/a/b", want: "/a/b"},
+		{path: "/a/b/", want: "/a/b/"},
+		{path: "//a///b", want: "/a/b"},
+		{path: "/a/./b/../c", want: "/a/c"},
+		{path: "/a/%2e%2E/b", want: "/b"},
+		{path: "/../../a", want: "/a"},
+		{path: "/a/b/..", want: "/a"},
+		{path: "/a%2F..%2Fb", want: "/a%2F..%2Fb"},
+		{path: "/.well-known/x", want: "/.well-known/x"},
+		{path: "a/b", want: "/a/b"},
+	}
+	for i, tt := range tests {
+		if got, want := cleanPath(tt.path), tt.want; got != want {
+			t.Errorf("%d: got %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestPathNormalization(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.RequestURI))
+	})
+	tests := []struct {
+		opts         []Option
+		path         string
+		stage        string
+		wantStatus   int
+		wantBody     string
+		wantLocation string
+	}{
+		{
+			path:       "//a/../b",
+			wantStatus: http.StatusOK,
+			wantBody:   "//a/../b //a/../b",
+		},
+		{
+			opts:       []Option{WithPathNormalization()},
+			path:       "//a/b",
+			wantStatus: http.StatusOK,
+			wantBody:   "/a/b /a/b?q=1",
+		},
+		{
+			opts:       []Option{WithPathNormalization()},
+			path:       "//admin/../public/./x",
+			wantStatus: http.StatusOK,
+			wantBody:   "/public/x /public/x?q=1",
+		},
+		{
+			opts:       []Option{WithPathNormalization()},
+			path:       "/a%20b//c/",
+			wantStatus: http.StatusOK,
+			wantBody:   "/a b/c/ /a%20b/c/?q=1",
+		},
+		{
+			opts:         []Option{WithTrailingSlash(TrailingSlashRedirect)},
+			path:         "/users/",
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "/users?q=1",
+		},
+		{
+			opts:         []Option{WithPathNormalization(), WithTrailingSlash(TrailingSlashRedirect)},
+			path:         "/users/./1//",
+			stage:        "prod",
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "/prod/users/1?q=1",
+		},
+		{
+			opts:       []Option{WithTrailingSlash(TrailingSlashStrip)},
+			path:       "/users/",
+			wantStatus: http.StatusOK,
+			wantBody:   "/users /users?q=1",
+		},
+		{
+			opts:       []Option{WithPathNormalization(), WithTrailingSlash(TrailingSlashStrip)},
+			path:       "/users/./",
+			wantStatus: http.StatusOK,
+			wantBody:   "/users /users?q=1",
+		},
+		{
+			opts:       []Option{WithTrailingSlash(TrailingSlashRedirect)},
+			path:       "/",
+			wantStatus: http.StatusOK,
+			wantBody:   "/ /?q=1",
+		},
+	}
+	for i, tt := range tests {
+		o := newOptions(tt.opts)
+		query := map[string]string{"q": "1"}
+		if len(tt.opts) == 0 {
+			query = nil
+		}
+		response, err := apiGatewayHandler(o.wrap(h), o)(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod:            "GET",
+			Path:                  tt.path,
+			QueryStringParameters: query,
+			RequestContext: events.APIGatewayProxyRequestContext{
+				DomainName: "abc123.execute-api.us-east-1.amazonaws.com",
+				Stage:      tt.stage,
+			},
+		})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.StatusCode, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if got, want := response.Headers["Location"], tt.wantLocation; got != want {
+			t.Errorf("%d: got location %q, want %q", i, got, want)
+		}
+		if tt.wantBody != "" {
+			if got, want := response.Body, tt.wantBody; got != want {
+				t.Errorf("%d: got body %q, want %q", i, got, want)
+			}
+		}
+	}
+}
+
+func TestPathNormalizationLocal(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	})
+	handler := Handler(h, WithPathNormalization(), WithMountPath("/api"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api//x/../users", nil))
+	if got, want := w.Body.String(), "/users"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTrailingSlashLocal(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.RequestURI))
+	})
+	tests := []struct {
+		policy       TrailingSlash
+		target       string
+		wantStatus   int
+		wantBody     string
+		wantLocation string
+	}{
+		{policy: TrailingSlashPreserve, target: "/users/", wantStatus: http.StatusOK, wantBody: "/users/ /users/"},
+		{policy: TrailingSlashStrip, target: "/users/?q=1", wantStatus: http.StatusOK, wantBody: "/users /users?q=1"},
+		{policy: TrailingSlashStrip, target: "/", wantStatus: http.StatusOK, wantBody: "/ /"},
+		{policy: TrailingSlashRedirect, target: "/users//?q=1", wantStatus: http.StatusPermanentRedirect, wantLocation: "/users?q=1"},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		Handler(h, WithTrailingSlash(tt.policy)).ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+		if got, want := w.Code, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if got, want := w.Header().Get("Location"), tt.wantLocation; got != want {
+			t.Errorf("%d: got location %q, want %q", i, got, want)
+		}
+		if tt.wantBody != "" {
+			if got, want := w.Body.String(), tt.wantBody; got != want {
+				t.Errorf("%d: got body %q, want %q", i, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkCleanPath(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cleanPath("/api/v1/users/123")
+	}
+}
@@ -0,0 +1,152 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// defaultClientIPHeaders are the headers consulted by ClientIP, in order
+// of precedence, when the request is received from a trusted proxy. The
+// names are in canonical form so they can be used as header map keys.
+var defaultClientIPHeaders = []string{"True-Client-Ip", "X-Forwarded-For"}
+
+// WithTrustedProxies configures the proxies that ClientIP trusts to report
+// the client IP address. Each proxy is an IP address or a CIDR range, such
+// as "10.0.0.0/8". WithTrustedProxies panics if a proxy cannot be parsed,
+// so that a configuration error is detected at startup.
+//
+// A typical reason to trust a proxy is when API Gateway is behind a CDN
+// such as CloudFront, in which case the source IP of the request is the
+// CDN, not the client.
+func WithTrustedProxies(proxies ...string) Option {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				panic("apigatewayproxy: invalid trusted proxy: " + proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			panic("apigatewayproxy: invalid trusted proxy: " + proxy)
+		}
+		nets = append(nets, ipnet)
+	}
+	return func(o *options) {
+		o.trustedProxies = append(o.trustedProxies, nets...)
+	}
+}
+
+// WithClientIPHeaders sets the headers that ClientIP consults, in order of
+// precedence, when the request is received from a trusted proxy. The
+// default headers are "True-Client-IP" and "X-Forwarded-For".
+func WithClientIPHeaders(names ...string) Option {
+	headers := make([]string, len(names))
+	for i, name := range names {
+		headers[i] = textproto.CanonicalMIMEHeaderKey(name)
+	}
+	return func(o *options) {
+		o.clientIPHeaders = headers
+	}
+}
+
+// ClientIP returns the IP address of the client that sent the request.
+//
+// When running in Lambda, the address starts as the source IP reported by
+// API Gateway; otherwise it is the address of the remote peer in
+// r.RemoteAddr. Request headers are only consulted if that address is a
+// trusted proxy (see WithTrustedProxies), so clients cannot spoof their
+// address by sending the headers themselves. When the headers are
+// consulted, X-Forwarded-For is read from right to left, skipping
+// trusted proxies, and the first untrusted address is returned.
+//
+// When running in a local HTTP server, the trusted proxies are only known
+// if the handler was created by Handler.
+func ClientIP(r *http.Request) string {
+	o, ip := clientIPConfig(r)
+	if o == nil || !o.trustedProxy(ip) {
+		return ip
+	}
+	headers := o.clientIPHeaders
+	if headers == nil {
+		headers = defaultClientIPHeaders
+	}
+	for _, name := range headers {
+		values := r.Header[name]
+		if len(values) == 0 {
+			continue
+		}
+		if name != "X-Forwarded-For" {
+			if v := strings.TrimSpace(values[0]); net.ParseIP(v) != nil {
+				return v
+			}
+			continue
+		}
+		addrs := strings.Split(strings.Join(values, ","), ",")
+		for i := len(addrs) - 1; i >= 0; i-- {
+			addr := strings.TrimSpace(addrs[i])
+			if net.ParseIP(addr) == nil {
+				break
+			}
+			ip = addr
+			if !o.trustedProxy(addr) {
+				return addr
+			}
+		}
+	}
+	return ip
+}
+
+// clientIPConfig returns the options and the address of the immediate
+// peer for the request.
+func clientIPConfig(r *http.Request) (*options, string) {
+	ctx := r.Context()
+	if inv, ok := ctx.Value(ctxKeyInvocation).(*invocation); ok {
+		return inv.opts, inv.request.RequestContext.Identity.SourceIP
+	}
+	o, _ := ctx.Value(ctxKeyOptions).(*options)
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return o, host
+}
+
+// trustedProxy reports whether addr is a trusted proxy.
+func (o *options) trustedProxy(addr string) bool {
+	if len(o.trustedProxies) == 0 {
+		return false
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, ipnet := range o.trustedProxies {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIPHandler makes the options available to ClientIP for requests
+// that were not created by the Lambda adapter, such as when running in
+// a local HTTP server.
+func clientIPHandler(h http.Handler, o *options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(ctxKeyInvocation) == nil {
+			r = r.WithContext(context.WithValue(r.Context(), ctxKeyOptions, o))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,119 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestClientIP(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ClientIP(r)))
+	})
+	newRequest := func(sourceIP string, headers map[string]string) events.APIGatewayProxyRequest {
+		return events.APIGatewayProxyRequest{
+			Path:    "/",
+			Headers: headers,
+			RequestContext: events.APIGatewayProxyRequestContext{
+				Identity: events.APIGatewayRequestIdentity{SourceIP: sourceIP},
+			},
+		}
+	}
+	tests := []struct {
+		opts    []Option
+		request events.APIGatewayProxyRequest
+		want    string
+	}{
+		{
+			// headers are ignored without trusted proxies
+			request: newRequest("203.0.113.1", map[string]string{
+				"True-Client-IP":  "198.51.100.1",
+				"X-Forwarded-For": "198.51.100.1, 203.0.113.1",
+			}),
+			want: "203.0.113.1",
+		},
+		{
+			opts: []Option{WithTrustedProxies("10.0.0.0/8")},
+			request: newRequest("10.1.2.3", map[string]string{
+				"True-Client-IP": "198.51.100.1",
+			}),
+			want: "198.51.100.1",
+		},
+		{
+			opts: []Option{WithTrustedProxies("10.0.0.0/8", "192.0.2.1")},
+			request: newRequest("10.1.2.3", map[string]string{
+				"X-Forwarded-For": "1.1.1.1, 198.51.100.1, 192.0.2.1, 10.1.2.3",
+			}),
+			want: "198.51.100.1",
+		},
+		{
+			opts: []Option{WithTrustedProxies("10.0.0.0/8")},
+			request: newRequest("10.1.2.3", map[string]string{
+				"X-Forwarded-For": "not-an-ip, 10.0.0.1",
+			}),
+			want: "10.0.0.1",
+		},
+		{
+			opts: []Option{
+				WithTrustedProxies("10.0.0.0/8"),
+				WithClientIPHeaders("x-real-ip"),
+			},
+			request: newRequest("10.1.2.3", map[string]string{
+				"True-Client-IP": "198.51.100.1",
+				"X-Real-IP":      "198.51.100.2",
+			}),
+			want: "198.51.100.2",
+		},
+		{
+			// untrusted source
+			opts: []Option{WithTrustedProxies("10.0.0.0/8")},
+			request: newRequest("203.0.113.1", map[string]string{
+				"True-Client-IP": "198.51.100.1",
+			}),
+			want: "203.0.113.1",
+		},
+	}
+	for i, tt := range tests {
+		handler := apiGatewayHandler(h, newOptions(tt.opts))
+		response, err := handler(context.Background(), tt.request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestClientIPHandler(t *testing.T) {
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ClientIP(r)))
+	}), WithTrustedProxies("127.0.0.1"))
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if got, want := string(body), "198.51.100.1"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestWithTrustedProxiesPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic, want panic")
+		}
+	}()
+	WithTrustedProxies("not-a-proxy")
+}
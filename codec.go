@@ -0,0 +1,40 @@
+package apigatewayproxy
+
+import "encoding/json"
+
+// JSONCodec encodes and decodes Lambda event and response payloads. For a
+// typical API Gateway event and a handler that does no work, decoding the
+// event and encoding the response with encoding/json takes about three
+// quarters of the time spent in this package (see BenchmarkJSONInvoke), so
+// a faster JSON implementation reduces the billed duration. The interface
+// is satisfied by the Marshal and Unmarshal functions of packages such as
+// github.com/segmentio/encoding/json and github.com/json-iterator/go, so it
+// is defined here rather than requiring a dependency on any of them.
+type JSONCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// WithJSONCodec sets the codec used to decode Lambda events and encode the
+// responses, by the Start functions, StartWithContext, InvokeJSON and
+// NewInProcessTransport. The codec must be compatible with encoding/json,
+// which is the default. If codec is nil, the default is used.
+func WithJSONCodec(codec JSONCodec) Option {
+	if codec == nil {
+		codec = stdJSONCodec{}
+	}
+	return func(o *options) {
+		o.jsonCodec = codec
+	}
+}
+
+// stdJSONCodec is a JSONCodec that uses encoding/json.
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,146 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// countingCodec is a JSONCodec that counts calls.
+type countingCodec struct {
+	marshal, unmarshal int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshal++
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshal++
+	return json.Unmarshal(data, v)
+}
+
+func TestWithJSONCodec(t *testing.T) {
+	codec := &countingCodec{}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	payload := []byte(`{"httpMethod":"GET","path":"/","requestContext":{}}`)
+	if _, err := InvokeJSON(context.Background(), h, payload, WithJSONCodec(codec)); err != nil {
+		t.Fatal(err)
+	}
+	// InvokeJSON probes the event kind with encoding/json
+	if codec.unmarshal != 1 || codec.marshal != 1 {
+		t.Errorf("got %d unmarshal and %d marshal calls, want 1 of each", codec.unmarshal, codec.marshal)
+	}
+
+	client := &http.Client{Transport: NewInProcessTransport(h, WithJSONCodec(codec))}
+	resp, err := client.Get("http://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if codec.unmarshal != 2 || codec.marshal != 2 {
+		t.Errorf("got %d unmarshal and %d marshal calls, want 2 of each", codec.unmarshal, codec.marshal)
+	}
+}
+
+// BenchmarkJSONInvoke compares the time taken to decode a typical API
+// Gateway event and encode the response with the time taken to handle the
+// request, for a handler that does no work.
+func BenchmarkJSONInvoke(b *testing.B) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":"hello"}`))
+	})
+	o := newOptions(nil)
+	handler := apiGatewayHandler(o.wrap(h), o)
+	event := benchmarkEvent()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		b.Fatal(err)
+	}
+	response, err := handler(context.Background(), event)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+
+	b.Run("codec", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var ev events.APIGatewayProxyRequest
+			if err := json.Unmarshal(payload, &ev); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := json.Marshal(response); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("handler", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := handler(ctx, event); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("invoke", func(b *testing.B) {
+		invoke := jsonInvoke(o.jsonCodec, handler)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := invoke(ctx, payload); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// benchmarkEvent returns an event with the headers and request context
+// typically sent by API Gateway.
+func benchmarkEvent() events.APIGatewayProxyRequest {
+	headers := map[string]string{
+		"Accept":                       "application/json",
+		"Accept-Encoding":              "gzip, deflate, br",
+		"Accept-Language":              "en-US,en;q=0.9",
+		"CloudFront-Forwarded-Proto":   "https",
+		"CloudFront-Is-Desktop-Viewer": "true",
+		"CloudFront-Viewer-Country":    "AU",
+		"Host":                         "abc123.execute-api.ap-southeast-2.amazonaws.com",
+		"User-Agent":                   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36",
+		"Via":                          "2.0 f0e1d2c3b4a5.cloudfront.net (CloudFront)",
+		"X-Amz-Cf-Id":                  "Zs4x4Zq2n0sLw0-1fA9WQZsd2xo5hA0y0b0l3bSZ3lJ0Sxk2w4N0Ew==",
+		"X-Amzn-Trace-Id":              "Root=1-5f84c7a0-5c1b2a3d4e5f60718293a4b5",
+		"X-Forwarded-For":              "203.0.113.10, 130.176.0.1",
+		"X-Forwarded-Port":             "443",
+		"X-Forwarded-Proto":            "https",
+	}
+	multi := make(map[string][]string, len(headers))
+	for k, v := range headers {
+		multi[k] = []string{v}
+	}
+	return events.APIGatewayProxyRequest{
+		Resource:          "/users/{id}",
+		Path:              "/users/123",
+		HTTPMethod:        "GET",
+		Headers:           headers,
+		MultiValueHeaders: multi,
+		PathParameters:    map[string]string{"id": "123"},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:    "123456789012",
+			ResourceID:   "abc123",
+			Stage:        "prod",
+			RequestID:    "c6af9ac6-7b61-11e6-9a41-93e8deadbeef",
+			ResourcePath: "/users/{id}",
+			HTTPMethod:   "GET",
+			APIID:        "abc123",
+			DomainName:   "abc123.execute-api.ap-southeast-2.amazonaws.com",
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  "203.0.113.10",
+				UserAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36",
+			},
+		},
+	}
+}
@@ -0,0 +1,144 @@
+package apigatewayproxy
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strings"
+)
+
+// DefaultCompressionMinSize is the default minimum size of a response body
+// that will be compressed.
+const DefaultCompressionMinSize = 1024
+
+// WithCompression configures the adapter to compress response bodies of at
+// least minSize bytes, using gzip or deflate, but only when the request
+// Accept-Encoding header indicates that the client accepts the encoding.
+// Responses that already have a Content-Encoding are not compressed. If minSize
+// is zero or negative, DefaultCompressionMinSize is used.
+func WithCompression(minSize int) Option {
+	if minSize <= 0 {
+		minSize = DefaultCompressionMinSize
+	}
+	return func(o *options) {
+		o.compressionMinSize = minSize
+	}
+}
+
+// supportedEncodings are the content codings supported for compression, in
+// order of preference when the client accepts more than one with equal quality.
+var supportedEncodings = []string{"gzip", "deflate"}
+
+// negotiateEncoding returns the supported content coding with the highest
+// quality value in the Accept-Encoding header, or an empty string if the
+// client does not accept any supported coding.
+func negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	var best string
+	var bestQ float64
+	for _, encoding := range supportedEncodings {
+		q := encodingQuality(acceptEncoding, encoding)
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// encodingQuality returns the quality value for encoding in the
+// Accept-Encoding header, as described in RFC 7231 section 5.3.4.
+// An explicit entry for the encoding takes precedence over "*".
+func encodingQuality(acceptEncoding string, encoding string) float64 {
+	q, wildcard := -1.0, -1.0
+	for _, spec := range parseAccept(acceptEncoding) {
+		switch spec.value {
+		case encoding:
+			q = spec.q
+		case "*":
+			wildcard = spec.q
+		}
+	}
+	if q < 0 {
+		q = wildcard
+	}
+	if q < 0 {
+		return 0
+	}
+	return q
+}
+
+// compress compresses the response body if the client accepts a supported
+// encoding and the body is large enough.
+func (w *responseWriter) compress() {
+	if w.opts.compressionMinSize <= 0 || w.body == nil || w.body.Len() < w.opts.compressionMinSize {
+		return
+	}
+	if isContentEncoded(&w.response) || w.opts.plainText(w.response.StatusCode) {
+		return
+	}
+	if w.partialContent() || w.acceptsRanges() {
+		return
+	}
+
+	// the response depends on the Accept-Encoding header whether or not
+	// it is compressed
+	w.setResponseHeader("Vary", addVary(w.header.Values("Vary"), "Accept-Encoding"))
+	if w.preferredEncoding == "" {
+		return
+	}
+
+	compressed := getBuffer(w.opts.bufferPool)
+	var cw io.WriteCloser
+	switch w.preferredEncoding {
+	case "gzip":
+		cw = gzip.NewWriter(compressed)
+	case "deflate":
+		// the HTTP deflate coding is the zlib format (RFC 9110)
+		cw = zlib.NewWriter(compressed)
+	}
+	cw.Write(w.body.Bytes())
+	cw.Close()
+	// both buffers are held until one is returned to the pool
+	w.bufferCap = max(w.bufferCap, w.body.Cap()+compressed.Cap())
+	if compressed.Len() >= w.body.Len() {
+		// compression did not help
+		putBuffer(w.opts.bufferPool, compressed)
+		return
+	}
+	putBuffer(w.opts.bufferPool, w.body)
+	w.body = compressed
+	w.binary = true
+	w.setResponseHeader("Content-Encoding", w.preferredEncoding)
+	w.deleteResponseHeader("Content-Length")
+}
+
+// setResponseHeader sets a single value header in the response after
+// the header has been written.
+func (w *responseWriter) setResponseHeader(key, value string) {
+	w.response.Headers[key] = value
+	w.response2.Headers[key] = value
+	delete(w.response2.MultiValueHeaders, key)
+}
+
+// deleteResponseHeader removes a header from the response after the
+// header has been written.
+func (w *responseWriter) deleteResponseHeader(key string) {
+	delete(w.response.Headers, key)
+	delete(w.response2.Headers, key)
+	delete(w.response2.MultiValueHeaders, key)
+}
+
+// addVary returns the Vary header values joined into one value, with name
+// added unless it is already present.
+func addVary(values []string, name string) string {
+	for _, v := range values {
+		for _, field := range strings.Split(v, ",") {
+			if field = strings.TrimSpace(field); field == "*" || strings.EqualFold(field, name) {
+				return strings.Join(values, ", ")
+			}
+		}
+	}
+	return strings.Join(append(values[:len(values):len(values)], name), ", ")
+}
@@ -0,0 +1,109 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"br", ""},
+		{"gzip, deflate, br", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"gzip;q=0", ""},
+		{"*", "gzip"},
+		{"*;q=0.1, gzip;q=0", "deflate"},
+		{"identity", ""},
+		{"GZIP;Q=0.8", "gzip"},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.acceptEncoding); got != tt.want {
+			t.Errorf("%q: got=%q, want=%q", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	large := strings.Repeat("hello world ", 200)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		if r.URL.Path == "/vary" {
+			w.Header().Add("Vary", "Origin")
+			w.Header().Add("Vary", "Accept-Language")
+		}
+		if r.URL.Path == "/small" {
+			w.Write([]byte("hello"))
+		} else {
+			w.Write([]byte(large))
+		}
+	})
+	handler := apiGatewayHandler(h, newOptions([]Option{WithCompression(0)}))
+
+	tests := []struct {
+		path           string
+		acceptEncoding string
+		wantEncoding   string
+		wantVary       string
+	}{
+		{path: "/large", acceptEncoding: "gzip", wantEncoding: "gzip", wantVary: "Accept-Encoding"},
+		{path: "/large", acceptEncoding: "deflate", wantEncoding: "deflate", wantVary: "Accept-Encoding"},
+		{path: "/vary", acceptEncoding: "gzip", wantEncoding: "gzip", wantVary: "Origin, Accept-Language, Accept-Encoding"},
+		{path: "/large", acceptEncoding: "br", wantVary: "Accept-Encoding"},
+		{path: "/large", wantVary: "Accept-Encoding"},
+		{path: "/small", acceptEncoding: "gzip"},
+	}
+	for i, tt := range tests {
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			Path:    tt.path,
+			Headers: map[string]string{"Accept-Encoding": tt.acceptEncoding},
+		})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Headers["Content-Encoding"], tt.wantEncoding; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if got, want := response.Headers["Vary"], tt.wantVary; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if tt.wantEncoding == "" {
+			if response.IsBase64Encoded {
+				t.Errorf("%d: got base64 encoded body, want text", i)
+			}
+			continue
+		}
+		if !response.IsBase64Encoded {
+			t.Fatalf("%d: got text body, want base64 encoded", i)
+		}
+		b, _ := base64.StdEncoding.DecodeString(response.Body)
+		var zr io.Reader
+		if tt.wantEncoding == "deflate" {
+			zr, err = zlib.NewReader(bytes.NewReader(b))
+		} else {
+			zr, err = gzip.NewReader(bytes.NewReader(b))
+		}
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		body, _ := ioutil.ReadAll(zr)
+		if got, want := string(body), large; got != want {
+			t.Errorf("%d: got body len=%d, want len=%d", i, len(got), len(want))
+		}
+	}
+}
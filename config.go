@@ -0,0 +1,190 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jjeffery/kv"
+)
+
+// Configuration is a set of configuration values, keyed by name. It is
+// shared by concurrent requests, so it must not be modified.
+type Configuration map[string]string
+
+// Get returns the value of the named configuration value, or an empty
+// string if there is no such value.
+func (c Configuration) Get(name string) string {
+	return c[name]
+}
+
+// Config returns the configuration loaded by the source configured using
+// WithConfig, or nil if there is no configuration.
+func Config(ctx context.Context) Configuration {
+	c, _ := ctx.Value(ctxKeyConfig).(Configuration)
+	return c
+}
+
+// ConfigSource loads configuration values. ParameterStoreSource and
+// AppConfigSource are implementations for AWS Systems Manager Parameter Store
+// and AWS AppConfig. A ConfigSource can also be called directly during init,
+// to load configuration that determines the options passed to Start.
+type ConfigSource interface {
+	LoadConfig(ctx context.Context) (Configuration, error)
+}
+
+// WithConfig configures the adapter to load configuration from source when
+// the handler is created, which is during init for Lambda functions. The
+// configuration is available to handlers using Config. When running in a warm
+// Lambda container, the configuration is loaded again by the first request
+// received after ttl has elapsed. If ttl is zero or negative, the configuration
+// is only loaded once.
+//
+// If the configuration cannot be loaded during init, it is loaded by the
+// first request. If a later load fails, the error is logged and the previous
+// configuration continues to be used until the next attempt. Requests are
+// handled with a nil configuration until the configuration has been loaded.
+func WithConfig(source ConfigSource, ttl time.Duration) Option {
+	return func(o *options) {
+		o.configSource = source
+		o.configTTL = ttl
+	}
+}
+
+// configLoader loads and caches configuration.
+type configLoader struct {
+	source   ConfigSource
+	ttl      time.Duration
+	mutex    sync.Mutex
+	config   Configuration
+	loadedAt time.Time // zero if the configuration has not been loaded
+	now      func() time.Time
+}
+
+func newConfigLoader(source ConfigSource, ttl time.Duration) *configLoader {
+	return &configLoader{
+		source: source,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// get returns the configuration, loading it if it has not been loaded or
+// if it has expired.
+func (l *configLoader) get(ctx context.Context) (Configuration, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.now()
+	if !l.loadedAt.IsZero() && (l.ttl <= 0 || now.Sub(l.loadedAt) < l.ttl) {
+		return l.config, nil
+	}
+	config, err := l.source.LoadConfig(ctx)
+	if err != nil {
+		if !l.loadedAt.IsZero() {
+			// try again after another ttl, rather than on every request
+			l.loadedAt = now
+		}
+		return l.config, kv.Wrap(err, "cannot load config")
+	}
+	l.config = config
+	l.loadedAt = now
+	return config, nil
+}
+
+// configHandler installs the configuration in the request context.
+func configHandler(h http.Handler, l *configLoader) http.Handler {
+	if _, err := l.get(context.Background()); err != nil {
+		Logger(context.Background()).Warn("cannot load config during init", "error", err)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		config, err := l.get(ctx)
+		if err != nil {
+			Logger(ctx).Warn("cannot refresh config", "error", err)
+		}
+		if config != nil {
+			r = r.WithContext(context.WithValue(ctx, ctxKeyConfig, config))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// ParameterStoreAPI is the subset of the AWS Systems Manager API used by
+// ParameterStoreSource. The interface is defined here so that this package
+// does not depend on the AWS SDK.
+type ParameterStoreAPI interface {
+	// GetParametersByPath returns the values of all parameters in the
+	// hierarchy below path, recursively, keyed by parameter name. SecureString
+	// parameters are decrypted. Implementations must follow NextToken until
+	// all parameters have been returned.
+	GetParametersByPath(ctx context.Context, path string) (map[string]string, error)
+}
+
+// ParameterStoreSource is a ConfigSource that loads the parameters in a
+// Parameter Store hierarchy. The name of each configuration value is the
+// parameter name relative to Path, so with a Path of "/myapp/prod", the
+// parameter "/myapp/prod/db/host" has the name "db/host".
+type ParameterStoreSource struct {
+	API  ParameterStoreAPI
+	Path string
+}
+
+// LoadConfig implements the ConfigSource interface.
+func (s *ParameterStoreSource) LoadConfig(ctx context.Context) (Configuration, error) {
+	params, err := s.API.GetParametersByPath(ctx, s.Path)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot get parameters").With("path", s.Path)
+	}
+	prefix := strings.TrimSuffix(s.Path, "/") + "/"
+	config := make(Configuration, len(params))
+	for name, value := range params {
+		config[strings.TrimPrefix(name, prefix)] = value
+	}
+	return config, nil
+}
+
+// AppConfigAPI is the subset of the AWS AppConfig Data API used by
+// AppConfigSource. The interface is defined here so that this package does
+// not depend on the AWS SDK.
+type AppConfigAPI interface {
+	// GetLatestConfiguration returns the latest deployed configuration.
+	// Implementations start a configuration session when first called, and
+	// keep the configuration token between calls. The AppConfig Data API
+	// returns an empty configuration when it has not changed since the
+	// previous call, in which case implementations return the previous
+	// configuration.
+	GetLatestConfiguration(ctx context.Context) ([]byte, error)
+}
+
+// AppConfigSource is a ConfigSource that loads a freeform AppConfig
+// configuration profile. The configuration must be a JSON object. String
+// values are used as is, and other values are converted to their JSON
+// encoding, so nested objects can be decoded by the handler.
+type AppConfigSource struct {
+	API AppConfigAPI
+}
+
+// LoadConfig implements the ConfigSource interface.
+func (s *AppConfigSource) LoadConfig(ctx context.Context) (Configuration, error) {
+	data, err := s.API.GetLatestConfiguration(ctx)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot get configuration")
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, kv.Wrap(err, "cannot decode configuration")
+	}
+	config := make(Configuration, len(values))
+	for name, raw := range values {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			config[name] = s
+		} else {
+			config[name] = string(raw)
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,128 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeConfigSource struct {
+	config Configuration
+	err    error
+	loads  int
+}
+
+func (s *fakeConfigSource) LoadConfig(ctx context.Context) (Configuration, error) {
+	s.loads++
+	return s.config, s.err
+}
+
+func TestConfigLoader(t *testing.T) {
+	source := &fakeConfigSource{err: errors.New("unavailable")}
+	l := newConfigLoader(source, time.Minute)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// not loaded, so every request tries again
+	if _, err := l.get(ctx); err == nil {
+		t.Fatal("got nil, want error")
+	}
+	source.config, source.err = Configuration{"a": "1"}, nil
+	if c, err := l.get(ctx); err != nil || c.Get("a") != "1" {
+		t.Fatalf("got %v, %v, want a=1", c, err)
+	}
+
+	// cached until ttl elapses
+	source.config = Configuration{"a": "2"}
+	now = now.Add(59 * time.Second)
+	if c, _ := l.get(ctx); c.Get("a") != "1" {
+		t.Errorf("got a=%q, want cached value", c.Get("a"))
+	}
+	now = now.Add(time.Second)
+	if c, _ := l.get(ctx); c.Get("a") != "2" {
+		t.Errorf("got a=%q, want refreshed value", c.Get("a"))
+	}
+
+	// a failed refresh keeps the previous values
+	source.err = errors.New("unavailable")
+	now = now.Add(time.Minute)
+	if c, err := l.get(ctx); err == nil || c.Get("a") != "2" {
+		t.Errorf("got a=%q, %v, want previous value and error", c.Get("a"), err)
+	}
+	loads := source.loads
+	l.get(ctx)
+	if source.loads != loads {
+		t.Errorf("got %d loads, want %d", source.loads, loads)
+	}
+}
+
+func TestWithConfig(t *testing.T) {
+	source := &fakeConfigSource{config: Configuration{"greeting": "hello"}}
+	var got string
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = Config(r.Context()).Get("greeting")
+	}), WithConfig(source, 0))
+	if source.loads != 1 {
+		t.Errorf("got %d loads during init, want 1", source.loads)
+	}
+	for i := 0; i < 2; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+	if got != "hello" {
+		t.Errorf("got %q, want %q", got, "hello")
+	}
+	if source.loads != 1 {
+		t.Errorf("got %d loads, want 1", source.loads)
+	}
+	if c := Config(context.Background()); c != nil {
+		t.Errorf("got %v, want nil", c)
+	}
+}
+
+type fakeParameterStore map[string]string
+
+func (s fakeParameterStore) GetParametersByPath(ctx context.Context, path string) (map[string]string, error) {
+	return s, nil
+}
+
+type fakeAppConfig []byte
+
+func (c fakeAppConfig) GetLatestConfiguration(ctx context.Context) ([]byte, error) {
+	return c, nil
+}
+
+func TestConfigSources(t *testing.T) {
+	ctx := context.Background()
+	ps := &ParameterStoreSource{
+		API:  fakeParameterStore{"/myapp/prod/db/host": "db.example.com", "/myapp/prod/debug": "false"},
+		Path: "/myapp/prod",
+	}
+	c, err := ps.LoadConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Get("db/host"), "db.example.com"; got != want {
+		t.Errorf("got db/host=%q, want %q", got, want)
+	}
+	if got, want := c.Get("debug"), "false"; got != want {
+		t.Errorf("got debug=%q, want %q", got, want)
+	}
+
+	ac := &AppConfigSource{API: fakeAppConfig(`{"name":"x","limit":10,"nested":{"a":true}}`)}
+	c, err = ac.LoadConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"name": "x", "limit": "10", "nested": `{"a":true}`} {
+		if got := c.Get(name); got != want {
+			t.Errorf("got %s=%q, want %q", name, got, want)
+		}
+	}
+	if _, err := (&AppConfigSource{API: fakeAppConfig(`[]`)}).LoadConfig(ctx); err == nil {
+		t.Error("got nil, want error for non-object configuration")
+	}
+}
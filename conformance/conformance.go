@@ -0,0 +1,248 @@
+// Package conformance verifies that an event converter preserves HTTP
+// semantics when requests and responses pass through Lambda events.
+//
+// The converters for the event types supported by package apigatewayproxy
+// are provided, and third-party converters and future payload versions can
+// be validated by implementing EventConverter and calling Run from a test.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Request describes a request independent of the event payload format.
+type Request struct {
+	Method string
+	Path   string
+	Query  map[string][]string
+	Header map[string]string
+	Body   []byte
+}
+
+// Response describes a response independent of the event payload format.
+// Header values that the payload format combines into one value are
+// returned as a single comma-separated value.
+type Response struct {
+	Status int
+	Header map[string][]string
+	Body   []byte
+}
+
+// EventConverter converts requests into event payloads, passes the event
+// payloads to a handler, and decodes the response payloads.
+type EventConverter interface {
+	// Event returns the JSON event payload for the request.
+	Event(request *Request) ([]byte, error)
+
+	// Invoke passes the event payload to the handler in the same way as
+	// the Lambda runtime, and returns the JSON response payload.
+	Invoke(ctx context.Context, h http.Handler, payload []byte) ([]byte, error)
+
+	// Response decodes the JSON response payload.
+	Response(payload []byte) (*Response, error)
+}
+
+// received is the request as seen by the handler, returned as the
+// body of the echo handler's response.
+type received struct {
+	Method string
+	Path   string
+	Query  map[string][]string
+	Header map[string]string
+	Body   []byte
+}
+
+// echoHandler returns the request it receives as JSON, with the status
+// and response headers requested in the request headers.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	switch r.URL.Query().Get("echo") {
+	case "raw":
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(body)
+		return
+	case "empty":
+		w.Header().Set("X-Empty", "true")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	rcv := received{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: make(map[string]string),
+		Body:   body,
+	}
+	for k := range r.Header {
+		rcv.Header[k] = strings.Join(r.Header.Values(k), ",")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Add("X-Multi", "a")
+	w.Header().Add("X-Multi", "b")
+	w.Header().Add("Set-Cookie", "a=1")
+	w.Header().Add("Set-Cookie", "b=2")
+	status := http.StatusOK
+	if r.Header.Get("X-Status") == "teapot" {
+		status = http.StatusTeapot
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(rcv)
+})
+
+// Run verifies that c preserves request and response headers, presents
+// request header names in canonical form however they are cased in the
+// event, preserves request and response bodies byte for byte (including
+// binary bodies that are base64 encoded in the payload, and empty bodies),
+// retains multi-value query parameters in order, and propagates the
+// response status. Each invariant is run as a subtest of t.
+func Run(t *testing.T, c EventConverter) {
+	binary := make([]byte, 256)
+	for i := range binary {
+		binary[i] = byte(i)
+	}
+
+	t.Run("RequestHeaders", func(t *testing.T) {
+		rcv := roundTrip(t, c, &Request{
+			Method: "GET",
+			Path:   "/headers",
+			Header: map[string]string{"X-One": "1", "Accept": "application/json", "X-Unicode": "ü"},
+		})
+		for k, want := range map[string]string{"X-One": "1", "Accept": "application/json", "X-Unicode": "ü"} {
+			if got := rcv.Header[k]; got != want {
+				t.Errorf("got %s=%q, want %q", k, got, want)
+			}
+		}
+	})
+
+	t.Run("RequestHeaderCasing", func(t *testing.T) {
+		// API Gateway passes header names as sent by the client, and
+		// HTTP/2 clients send them in lower case
+		rcv := roundTrip(t, c, &Request{
+			Method: "GET",
+			Path:   "/headers",
+			Header: map[string]string{"x-lower-case": "1", "X-UPPER-CASE": "2"},
+		})
+		for k, want := range map[string]string{"X-Lower-Case": "1", "X-Upper-Case": "2"} {
+			if got := rcv.Header[k]; got != want {
+				t.Errorf("got %s=%q, want %q", k, got, want)
+			}
+		}
+	})
+
+	t.Run("ResponseHeaders", func(t *testing.T) {
+		resp := invoke(t, c, &Request{Method: "GET", Path: "/headers"})
+		if got, want := strings.Join(resp.Header["X-Multi"], ","), "a,b"; got != want {
+			t.Errorf("got X-Multi=%q, want %q", got, want)
+		}
+		if got, want := resp.Header["Set-Cookie"], []string{"a=1", "b=2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got Set-Cookie=%q, want %q", got, want)
+		}
+	})
+
+	t.Run("RequestBody", func(t *testing.T) {
+		for _, body := range [][]byte{nil, []byte("hello, world"), []byte("ü 日本"), binary} {
+			rcv := roundTrip(t, c, &Request{Method: "POST", Path: "/body", Body: body})
+			if !bytes.Equal(rcv.Body, body) {
+				t.Errorf("got body %q, want %q", rcv.Body, body)
+			}
+		}
+	})
+
+	t.Run("ResponseBody", func(t *testing.T) {
+		for _, body := range [][]byte{[]byte("hello, world"), []byte("ü 日本"), binary} {
+			resp := invoke(t, c, &Request{
+				Method: "POST",
+				Path:   "/body",
+				Query:  map[string][]string{"echo": {"raw"}},
+				Body:   body,
+			})
+			if !bytes.Equal(resp.Body, body) {
+				t.Errorf("got body %q, want %q", resp.Body, body)
+			}
+		}
+	})
+
+	t.Run("EmptyResponseBody", func(t *testing.T) {
+		resp := invoke(t, c, &Request{
+			Method: "PUT",
+			Path:   "/empty",
+			Query:  map[string][]string{"echo": {"empty"}},
+			Body:   []byte("ignored"),
+		})
+		if got, want := resp.Status, http.StatusNoContent; got != want {
+			t.Errorf("got status %d, want %d", got, want)
+		}
+		if len(resp.Body) != 0 {
+			t.Errorf("got body %q, want empty", resp.Body)
+		}
+		if got, want := strings.Join(resp.Header["X-Empty"], ","), "true"; got != want {
+			t.Errorf("got X-Empty=%q, want %q", got, want)
+		}
+	})
+
+	t.Run("QueryMultiValues", func(t *testing.T) {
+		query := map[string][]string{"q": {"3", "1", "2"}, "x y": {"a&b"}}
+		rcv := roundTrip(t, c, &Request{Method: "GET", Path: "/query", Query: query})
+		if !reflect.DeepEqual(rcv.Query, query) {
+			t.Errorf("got query %v, want %v", rcv.Query, query)
+		}
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		resp := invoke(t, c, &Request{
+			Method: "DELETE",
+			Path:   "/status",
+			Header: map[string]string{"X-Status": "teapot"},
+		})
+		if got, want := resp.Status, http.StatusTeapot; got != want {
+			t.Errorf("got status %d, want %d", got, want)
+		}
+	})
+
+	t.Run("MethodAndPath", func(t *testing.T) {
+		rcv := roundTrip(t, c, &Request{Method: "PATCH", Path: "/a/b c/ü"})
+		if rcv.Method != "PATCH" || rcv.Path != "/a/b c/ü" {
+			t.Errorf("got %s %s, want PATCH /a/b c/ü", rcv.Method, rcv.Path)
+		}
+	})
+}
+
+// invoke passes the request through the converter to the echo handler.
+func invoke(t *testing.T, c EventConverter, request *Request) *Response {
+	t.Helper()
+	payload, err := c.Event(request)
+	if err != nil {
+		t.Fatalf("cannot create event: %v", err)
+	}
+	b, err := c.Invoke(context.Background(), echoHandler, payload)
+	if err != nil {
+		t.Fatalf("cannot invoke handler: %v", err)
+	}
+	resp, err := c.Response(b)
+	if err != nil {
+		t.Fatalf("cannot decode response: %v", err)
+	}
+	return resp
+}
+
+// roundTrip returns the request received by the echo handler.
+func roundTrip(t *testing.T, c EventConverter, request *Request) *received {
+	t.Helper()
+	resp := invoke(t, c, request)
+	var rcv received
+	if err := json.Unmarshal(resp.Body, &rcv); err != nil {
+		t.Fatalf("cannot decode echo response %q: %v", resp.Body, err)
+	}
+	return &rcv
+}
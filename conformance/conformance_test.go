@@ -0,0 +1,15 @@
+package conformance
+
+import "testing"
+
+func TestV1(t *testing.T) {
+	Run(t, V1())
+}
+
+func TestV2(t *testing.T) {
+	Run(t, V2())
+}
+
+func TestALB(t *testing.T) {
+	Run(t, ALB())
+}
@@ -0,0 +1,169 @@
+package conformance
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/apigatewayproxy"
+	"github.com/jjeffery/kv"
+)
+
+// V1 returns the converter for API Gateway proxy events (payload format
+// version 1.0), as handled by apigatewayproxy.Start.
+func V1(opts ...apigatewayproxy.Option) EventConverter {
+	return converter{format: formatV1, opts: opts}
+}
+
+// V2 returns the converter for API Gateway HTTP API events (payload
+// format version 2.0), as handled by apigatewayproxy.StartV2.
+func V2(opts ...apigatewayproxy.Option) EventConverter {
+	return converter{format: formatV2, opts: opts}
+}
+
+// ALB returns the converter for Application Load Balancer events, with
+// multi-value headers enabled, as handled by apigatewayproxy.StartALB.
+func ALB(opts ...apigatewayproxy.Option) EventConverter {
+	return converter{format: formatALB, opts: opts}
+}
+
+type format int
+
+const (
+	formatV1 format = iota
+	formatV2
+	formatALB
+)
+
+// converter is the EventConverter for the payload formats supported by
+// package apigatewayproxy. All of them are invoked using InvokeJSON.
+type converter struct {
+	format format
+	opts   []apigatewayproxy.Option
+}
+
+func (c converter) Event(request *Request) ([]byte, error) {
+	body, isBase64 := encodeBody(request.Body)
+	escapedPath := (&url.URL{Path: request.Path}).EscapedPath()
+	var ev interface{}
+	switch c.format {
+	case formatV2:
+		ev = events.APIGatewayV2HTTPRequest{
+			Version:         "2.0",
+			RawPath:         escapedPath,
+			RawQueryString:  url.Values(request.Query).Encode(),
+			Headers:         request.Header,
+			Body:            body,
+			IsBase64Encoded: isBase64,
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				RequestID: "conformance",
+				HTTP:      events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: request.Method},
+			},
+		}
+	case formatALB:
+		// ALB does not decode the query string parameters
+		query := make(map[string][]string, len(request.Query))
+		for k, vv := range request.Query {
+			for _, v := range vv {
+				query[url.QueryEscape(k)] = append(query[url.QueryEscape(k)], url.QueryEscape(v))
+			}
+		}
+		// ALB always sends the host header, and an empty multi-value
+		// header map would be omitted, disabling multi-value mode
+		header := map[string][]string{"host": {"conformance.example.com"}}
+		for k, v := range request.Header {
+			header[k] = []string{v}
+		}
+		ev = events.ALBTargetGroupRequest{
+			HTTPMethod:                      request.Method,
+			Path:                            escapedPath,
+			MultiValueQueryStringParameters: query,
+			MultiValueHeaders:               header,
+			Body:                            body,
+			IsBase64Encoded:                 isBase64,
+			RequestContext: events.ALBTargetGroupRequestContext{
+				ELB: events.ELBContext{TargetGroupArn: "conformance"},
+			},
+		}
+	default:
+		var query map[string]string
+		if len(request.Query) > 0 {
+			query = make(map[string]string, len(request.Query))
+			for k, vv := range request.Query {
+				query[k] = vv[len(vv)-1]
+			}
+		}
+		ev = events.APIGatewayProxyRequest{
+			HTTPMethod:                      request.Method,
+			Path:                            request.Path,
+			QueryStringParameters:           query,
+			MultiValueQueryStringParameters: request.Query,
+			Headers:                         request.Header,
+			Body:                            body,
+			IsBase64Encoded:                 isBase64,
+			RequestContext: events.APIGatewayProxyRequestContext{
+				RequestID: "conformance",
+			},
+		}
+	}
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot marshal event")
+	}
+	return b, nil
+}
+
+func (c converter) Invoke(ctx context.Context, h http.Handler, payload []byte) ([]byte, error) {
+	return apigatewayproxy.InvokeJSON(ctx, h, payload, c.opts...)
+}
+
+func (c converter) Response(payload []byte) (*Response, error) {
+	// the fields common to all of the response formats
+	var r struct {
+		StatusCode        int                 `json:"statusCode"`
+		Headers           map[string]string   `json:"headers"`
+		MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+		Body              string              `json:"body"`
+		IsBase64Encoded   bool                `json:"isBase64Encoded"`
+		Cookies           []string            `json:"cookies"`
+	}
+	if err := json.Unmarshal(payload, &r); err != nil {
+		return nil, kv.Wrap(err, "cannot unmarshal response")
+	}
+	resp := &Response{
+		Status: r.StatusCode,
+		Header: make(map[string][]string),
+	}
+	for k, v := range r.Headers {
+		resp.Header[http.CanonicalHeaderKey(k)] = []string{v}
+	}
+	// API Gateway and ALB prefer the multi-value headers
+	for k, vv := range r.MultiValueHeaders {
+		resp.Header[http.CanonicalHeaderKey(k)] = vv
+	}
+	if len(r.Cookies) > 0 {
+		resp.Header["Set-Cookie"] = r.Cookies
+	}
+	if r.IsBase64Encoded {
+		body, err := base64.StdEncoding.DecodeString(r.Body)
+		if err != nil {
+			return nil, kv.Wrap(err, "cannot decode base64 body")
+		}
+		resp.Body = body
+	} else {
+		resp.Body = []byte(r.Body)
+	}
+	return resp, nil
+}
+
+// encodeBody returns the body for an event, base64 encoding binary bodies.
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
@@ -0,0 +1,212 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jjeffery/kv"
+)
+
+// DefaultIgnoreHeaders are the headers that ParityChecker ignores by default.
+// The adapter synthesizes the X-Forwarded headers, because API Gateway
+// provides them, and the local server sets the Date response header.
+var DefaultIgnoreHeaders = []string{
+	"Date",
+	"X-Forwarded-For",
+	"X-Forwarded-Port",
+	"X-Forwarded-Proto",
+}
+
+// Difference is a difference between the behavior of a handler when running
+// in a local HTTP server and when running behind the event conversion.
+type Difference struct {
+	Field  string // such as "request Host" or "response header Content-Type"
+	Local  string // value observed in the local HTTP server
+	Lambda string // value observed through the event conversion
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: local %q, lambda %q", d.Field, d.Local, d.Lambda)
+}
+
+// ParityChecker runs the same request through a local HTTP server and
+// through an event converter, and reports the differences in the request
+// received by the handler and in the response. It catches divergences such
+// as a missing Host, RemoteAddr or Content-Length before they cause
+// problems in production.
+type ParityChecker struct {
+	// Converter is the event converter. If nil, V1 is used.
+	Converter EventConverter
+
+	// IgnoreHeaders are the request and response headers that are not compared.
+	// If nil, DefaultIgnoreHeaders is used.
+	IgnoreHeaders []string
+}
+
+// observed contains the request received by the handler, and the
+// response, as a map of field name to value
+type observed struct {
+	fields map[string]string
+}
+
+// Check runs req through a local HTTP server and through the converter
+// using h, and returns the differences. The request URL must have a path,
+// and may have a query; the scheme and host are ignored.
+func (p *ParityChecker) Check(h http.Handler, req *http.Request) ([]Difference, error) {
+	c := p.Converter
+	if c == nil {
+		c = V1()
+	}
+	ignore := make(map[string]bool)
+	names := p.IgnoreHeaders
+	if names == nil {
+		names = DefaultIgnoreHeaders
+	}
+	for _, name := range names {
+		ignore[http.CanonicalHeaderKey(name)] = true
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, kv.Wrap(err, "cannot read request body")
+		}
+	}
+	host := req.Host
+	if host == "" {
+		host = "example.com"
+	}
+
+	local, err := p.local(h, req, host, body, ignore)
+	if err != nil {
+		return nil, err
+	}
+	lambda, err := p.lambda(c, h, req, host, body, ignore)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool)
+	for k := range local.fields {
+		keys[k] = true
+	}
+	for k := range lambda.fields {
+		keys[k] = true
+	}
+	var diffs []Difference
+	for k := range keys {
+		if local.fields[k] != lambda.fields[k] {
+			diffs = append(diffs, Difference{Field: k, Local: local.fields[k], Lambda: lambda.fields[k]})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+	return diffs, nil
+}
+
+// observe wraps h to record the request as received by the handler.
+func observe(h http.Handler, o *observed, ignore map[string]bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		o.fields["request Method"] = r.Method
+		o.fields["request Path"] = r.URL.Path
+		o.fields["request RawQuery"] = r.URL.RawQuery
+		o.fields["request Host"] = r.Host
+		o.fields["request RemoteAddr set"] = strconv.FormatBool(r.RemoteAddr != "")
+		o.fields["request ContentLength"] = strconv.FormatInt(r.ContentLength, 10)
+		o.fields["request Body"] = string(body)
+		for k, vv := range r.Header {
+			if !ignore[k] {
+				o.fields["request header "+k] = strings.Join(vv, ",")
+			}
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (o *observed) response(status int, header http.Header, body []byte, ignore map[string]bool) {
+	o.fields["response Status"] = strconv.Itoa(status)
+	o.fields["response Body"] = string(body)
+	for k, vv := range header {
+		if !ignore[k] {
+			o.fields["response header "+k] = strings.Join(vv, ",")
+		}
+	}
+}
+
+func (p *ParityChecker) local(h http.Handler, req *http.Request, host string, body []byte, ignore map[string]bool) (*observed, error) {
+	o := &observed{fields: make(map[string]string)}
+	server := httptest.NewServer(observe(h, o, ignore))
+	defer server.Close()
+
+	r, err := http.NewRequestWithContext(context.Background(), req.Method, server.URL+req.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot create local request")
+	}
+	r.Header = req.Header.Clone()
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	if _, ok := r.Header["User-Agent"]; !ok {
+		// prevent the client from adding its own user agent
+		r.Header["User-Agent"] = []string{""}
+	}
+	if len(body) == 0 {
+		r.Body = http.NoBody
+		r.ContentLength = 0
+	}
+	r.Host = host
+	client := &http.Client{
+		Transport: &http.Transport{DisableCompression: true},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(r)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot send local request")
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot read local response")
+	}
+	o.response(resp.StatusCode, resp.Header, respBody, ignore)
+	return o, nil
+}
+
+func (p *ParityChecker) lambda(c EventConverter, h http.Handler, req *http.Request, host string, body []byte, ignore map[string]bool) (*observed, error) {
+	o := &observed{fields: make(map[string]string)}
+	header := map[string]string{"Host": host}
+	for k, vv := range req.Header {
+		header[k] = strings.Join(vv, ",")
+	}
+	payload, err := c.Event(&Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query(),
+		Header: header,
+		Body:   body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	b, err := c.Invoke(context.Background(), observe(h, o, ignore), payload)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Response(b)
+	if err != nil {
+		return nil, err
+	}
+	o.response(resp.Status, http.Header(resp.Header), resp.Body, ignore)
+	return o, nil
+}
@@ -0,0 +1,42 @@
+package conformance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParityChecker(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(r.Method + " " + r.Host + " " + r.URL.RequestURI()))
+	})
+	req := httptest.NewRequest("POST", "/path?q=1", strings.NewReader("body"))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("X-Custom", "1")
+
+	var p ParityChecker
+	diffs, err := p.Check(h, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]Difference)
+	for _, d := range diffs {
+		got[d.Field] = d
+	}
+	// known divergences between a local server and the Lambda path
+	for _, field := range []string{
+		"request RemoteAddr set",
+		"request header Content-Length",
+		"response header Content-Length",
+	} {
+		if _, ok := got[field]; !ok {
+			t.Errorf("missing difference %q in %v", field, diffs)
+		}
+		delete(got, field)
+	}
+	for _, d := range got {
+		t.Errorf("unexpected difference: %v", d)
+	}
+}
@@ -0,0 +1,160 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jjeffery/kv"
+)
+
+// Connection describes a connected WebSocket client.
+type Connection struct {
+	ID          string    // connection ID
+	ConnectedAt time.Time // time the client connected
+	DomainName  string    // domain name of the WebSocket API
+	Stage       string    // API Gateway stage
+}
+
+// ConnectionStore records the WebSocket clients that are currently connected.
+// When a ConnectionStore is configured using WithConnectionStore, the WebSocket
+// adapter adds a connection when the handler accepts a "$connect" route,
+// and removes the connection on the "$disconnect" route.
+type ConnectionStore interface {
+	// Add records a connected client.
+	Add(ctx context.Context, conn Connection) error
+
+	// Remove removes the connection with the given ID. It is not an
+	// error if the connection does not exist.
+	Remove(ctx context.Context, connectionID string) error
+
+	// List returns all connections.
+	List(ctx context.Context) ([]Connection, error)
+}
+
+// WithConnectionStore configures the WebSocket adapter to record
+// connections in store.
+func WithConnectionStore(store ConnectionStore) Option {
+	return func(o *options) {
+		o.connectionStore = store
+	}
+}
+
+// MemoryConnectionStore is a ConnectionStore that keeps connections in
+// memory. It is intended for local development and testing, because each
+// Lambda container has its own memory. Use NewMemoryConnectionStore to
+// create a MemoryConnectionStore.
+type MemoryConnectionStore struct {
+	mutex sync.Mutex
+	conns map[string]Connection
+}
+
+// NewMemoryConnectionStore returns an empty in-memory connection store.
+func NewMemoryConnectionStore() *MemoryConnectionStore {
+	return &MemoryConnectionStore{
+		conns: make(map[string]Connection),
+	}
+}
+
+// Add implements the ConnectionStore interface.
+func (s *MemoryConnectionStore) Add(ctx context.Context, conn Connection) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.conns[conn.ID] = conn
+	return nil
+}
+
+// Remove implements the ConnectionStore interface.
+func (s *MemoryConnectionStore) Remove(ctx context.Context, connectionID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.conns, connectionID)
+	return nil
+}
+
+// List implements the ConnectionStore interface. Connections are
+// returned in the order in which they connected.
+func (s *MemoryConnectionStore) List(ctx context.Context) ([]Connection, error) {
+	s.mutex.Lock()
+	conns := make([]Connection, 0, len(s.conns))
+	for _, conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mutex.Unlock()
+	sort.Slice(conns, func(i, j int) bool {
+		if !conns[i].ConnectedAt.Equal(conns[j].ConnectedAt) {
+			return conns[i].ConnectedAt.Before(conns[j].ConnectedAt)
+		}
+		return conns[i].ID < conns[j].ID
+	})
+	return conns, nil
+}
+
+// DynamoDBItemAPI is the subset of the DynamoDB API used by
+// DynamoDBConnectionStore. Items are represented as maps of string
+// attributes. It is easily implemented using the DynamoDB client in
+// the AWS SDK; it is defined here so that this package does not depend
+// on the AWS SDK.
+type DynamoDBItemAPI interface {
+	// PutItem creates or replaces the item in the table.
+	PutItem(ctx context.Context, table string, item map[string]string) error
+
+	// DeleteItem deletes the item with the given key from the table.
+	DeleteItem(ctx context.Context, table string, key map[string]string) error
+
+	// Scan returns all items in the table.
+	Scan(ctx context.Context, table string) ([]map[string]string, error)
+}
+
+// DynamoDBConnectionStore is a reference implementation of a ConnectionStore
+// that stores connections in a DynamoDB table. The table must have a partition
+// key named "connectionId" of type string.
+type DynamoDBConnectionStore struct {
+	API       DynamoDBItemAPI
+	TableName string
+}
+
+// Add implements the ConnectionStore interface.
+func (s *DynamoDBConnectionStore) Add(ctx context.Context, conn Connection) error {
+	item := map[string]string{
+		"connectionId": conn.ID,
+		"connectedAt":  conn.ConnectedAt.UTC().Format(time.RFC3339Nano),
+		"domainName":   conn.DomainName,
+		"stage":        conn.Stage,
+	}
+	if err := s.API.PutItem(ctx, s.TableName, item); err != nil {
+		return kv.Wrap(err, "cannot put connection").With("connectionId", conn.ID)
+	}
+	return nil
+}
+
+// Remove implements the ConnectionStore interface.
+func (s *DynamoDBConnectionStore) Remove(ctx context.Context, connectionID string) error {
+	key := map[string]string{
+		"connectionId": connectionID,
+	}
+	if err := s.API.DeleteItem(ctx, s.TableName, key); err != nil {
+		return kv.Wrap(err, "cannot delete connection").With("connectionId", connectionID)
+	}
+	return nil
+}
+
+// List implements the ConnectionStore interface.
+func (s *DynamoDBConnectionStore) List(ctx context.Context) ([]Connection, error) {
+	items, err := s.API.Scan(ctx, s.TableName)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot scan connections").With("table", s.TableName)
+	}
+	conns := make([]Connection, 0, len(items))
+	for _, item := range items {
+		connectedAt, _ := time.Parse(time.RFC3339Nano, item["connectedAt"])
+		conns = append(conns, Connection{
+			ID:          item["connectionId"],
+			ConnectedAt: connectedAt,
+			DomainName:  item["domainName"],
+			Stage:       item["stage"],
+		})
+	}
+	return conns, nil
+}
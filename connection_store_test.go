@@ -0,0 +1,87 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type fakeDynamoDB struct {
+	items map[string]map[string]string
+}
+
+func (db *fakeDynamoDB) PutItem(ctx context.Context, table string, item map[string]string) error {
+	db.items[item["connectionId"]] = item
+	return nil
+}
+
+func (db *fakeDynamoDB) DeleteItem(ctx context.Context, table string, key map[string]string) error {
+	delete(db.items, key["connectionId"])
+	return nil
+}
+
+func (db *fakeDynamoDB) Scan(ctx context.Context, table string) ([]map[string]string, error) {
+	var items []map[string]string
+	for _, item := range db.items {
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func TestConnectionStore(t *testing.T) {
+	stores := []ConnectionStore{
+		NewMemoryConnectionStore(),
+		&DynamoDBConnectionStore{
+			API:       &fakeDynamoDB{items: make(map[string]map[string]string)},
+			TableName: "connections",
+		},
+	}
+
+	for i, store := range stores {
+		h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" && r.URL.Path == "/ws/connect" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		})
+		handler := webSocketHandler(h, newOptions([]Option{WithConnectionStore(store)}))
+		event := func(connectionID, routeKey, eventType string, headers map[string]string) events.APIGatewayWebsocketProxyRequest {
+			var request events.APIGatewayWebsocketProxyRequest
+			request.Headers = headers
+			request.RequestContext.ConnectionID = connectionID
+			request.RequestContext.RouteKey = routeKey
+			request.RequestContext.EventType = eventType
+			request.RequestContext.ConnectedAt = 1635724800000
+			request.RequestContext.Stage = "prod"
+			return request
+		}
+		authorized := map[string]string{"Authorization": "token"}
+		ctx := context.Background()
+		handler(ctx, event("conn-1", "$connect", "CONNECT", authorized))
+		handler(ctx, event("conn-2", "$connect", "CONNECT", authorized))
+		handler(ctx, event("conn-3", "$connect", "CONNECT", nil))
+		handler(ctx, event("conn-1", "$disconnect", "DISCONNECT", nil))
+
+		conns, err := store.List(ctx)
+		if err != nil {
+			t.Errorf("%d: got %v, want no error", i, err)
+			continue
+		}
+		want := []Connection{
+			{
+				ID:          "conn-2",
+				ConnectedAt: time.Unix(1635724800, 0),
+				Stage:       "prod",
+			},
+		}
+		if len(conns) == 1 && conns[0].ConnectedAt.Equal(want[0].ConnectedAt) {
+			conns[0].ConnectedAt = want[0].ConnectedAt
+		}
+		if !reflect.DeepEqual(conns, want) {
+			t.Errorf("%d: got=%v, want=%v", i, conns, want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package apigatewayproxy
+
+import "strconv"
+
+// WithContentLength configures the adapter to set the Content-Length header
+// of each response to the length of the response body, after compression
+// and before any base64 encoding. Some clients and proxies behave better
+// when the header is present, and because the response is buffered the
+// length is known. A Content-Length set by the handler is replaced, because
+// it must match the body that is returned.
+//
+// The header is not set for responses whose status code does not allow a
+// body, and responses to HEAD requests keep the Content-Length set by the
+// handler, if any.
+func WithContentLength() Option {
+	return func(o *options) {
+		o.contentLength = true
+	}
+}
+
+// setContentLength sets the Content-Length header to the length of the body.
+func (w *responseWriter) setContentLength(length int) {
+	if w.head || !bodyAllowedForStatus(w.response.StatusCode) {
+		return
+	}
+	w.setResponseHeader("Content-Length", strconv.Itoa(length))
+}
@@ -0,0 +1,70 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestWithContentLength(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wrong":
+			w.Header().Set("Content-Length", "1")
+		case "/empty":
+			return
+		case "/nocontent":
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case "/head":
+			w.Header().Set("Content-Length", "100")
+			return
+		case "/binary":
+			w.Write([]byte{0, 1, 2, 3, 0xff})
+			return
+		}
+		w.Write([]byte(strings.Repeat("hello ", 100)))
+	})
+	tests := []struct {
+		method  string
+		path    string
+		headers map[string]string
+		want    string
+	}{
+		{path: "/", want: "600"},
+		{path: "/wrong", want: "600"},
+		{path: "/empty", want: "0"},
+		{path: "/nocontent", want: ""},
+		{method: "HEAD", path: "/head", want: "100"},
+		{method: "HEAD", path: "/empty", want: ""},
+		{path: "/binary", want: "5"},
+		{path: "/", headers: map[string]string{"Accept-Encoding": "gzip"}, want: "gzip"},
+	}
+	handler := apiGatewayHandler(h, newOptions([]Option{WithContentLength(), WithCompression(100)}))
+	for i, tt := range tests {
+		if tt.method == "" {
+			tt.method = "GET"
+		}
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: tt.method,
+			Path:       tt.path,
+			Headers:    tt.headers,
+		})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		got := response.Headers["Content-Length"]
+		if tt.want == "gzip" {
+			// the length of the compressed body
+			body, _ := newOptions(nil).base64Encoding.DecodeString(response.Body)
+			tt.want = strconv.Itoa(len(body))
+		}
+		if got != tt.want {
+			t.Errorf("%d: got Content-Length %q, want %q", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package apigatewayproxy
+
+import "net/http"
+
+// WithoutContentTypeSniffing configures the adapter to leave the
+// Content-Type header unset when the handler does not set it. By default
+// the content type is detected from the response body using
+// http.DetectContentType, as net/http does. Use this option for APIs that
+// intentionally send responses without a content type, or that rely on
+// API Gateway mapping templates to set it.
+//
+// When running as a conventional HTTP server, the option also stops
+// net/http from detecting the content type, so that the handler behaves
+// the same way in both modes.
+func WithoutContentTypeSniffing() Option {
+	return func(o *options) {
+		o.noSniff = true
+	}
+}
+
+// noSniffHandler stops net/http from detecting the content type of the
+// responses to requests that are not from Lambda.
+func noSniffHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(ctxKeyInvocation) == nil {
+			if _, ok := w.Header()["Content-Type"]; !ok {
+				// a nil value prevents sniffing, and is not written
+				w.Header()["Content-Type"] = nil
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,58 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestWithoutContentTypeSniffing(t *testing.T) {
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/typed" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.Write([]byte("<html></html>"))
+	}), WithoutContentTypeSniffing())
+	server := httptest.NewServer(h)
+	defer server.Close()
+	handler := apiGatewayHandler(h, newOptions([]Option{WithoutContentTypeSniffing()}))
+
+	for _, tt := range []struct {
+		path string
+		want []string
+	}{
+		{path: "/"},
+		{path: "/typed", want: []string{"application/json"}},
+	} {
+		resp, err := http.Get(server.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := resp.Header["Content-Type"]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: net/http: got %q, want %q", tt.path, got, tt.want)
+		}
+
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Path:       tt.path,
+		})
+		if err != nil {
+			t.Fatalf("%s: got %v, want no error", tt.path, err)
+		}
+		var got []string
+		if v, ok := response.Headers["Content-Type"]; ok {
+			got = []string{v}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: adapter: got %q, want %q", tt.path, got, tt.want)
+		}
+		if _, ok := response.MultiValueHeaders["Content-Type"]; ok {
+			t.Errorf("%s: adapter: got multi value Content-Type, want none", tt.path)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// DefaultDeadlineThreshold is the default time before the Lambda deadline
+// at which the deadline warning is called.
+const DefaultDeadlineThreshold = time.Second
+
+// WithDeadlineWarning configures the adapter to call onDeadlineApproaching
+// when a request is still being handled threshold before the Lambda
+// invocation times out. This makes it possible to log a record of the
+// in-flight request, because Lambda terminates the invocation when it
+// times out without any indication of what it was doing. If threshold is
+// zero or negative, DefaultDeadlineThreshold is used.
+//
+// The callback is called on a separate goroutine, with the request context
+// and the time remaining. Use Info or Logger with the context to report
+// the route. The callback is not called for requests without a deadline,
+// such as when running as a conventional HTTP server.
+func WithDeadlineWarning(threshold time.Duration, onDeadlineApproaching func(ctx context.Context, remaining time.Duration)) Option {
+	if threshold <= 0 {
+		threshold = DefaultDeadlineThreshold
+	}
+	return func(o *options) {
+		o.deadlineThreshold = threshold
+		o.onDeadline = onDeadlineApproaching
+	}
+}
+
+// deadlineHandler calls the deadline warning for requests that are still
+// being handled when the deadline approaches.
+func deadlineHandler(h http.Handler, o *options) http.Handler {
+	threshold, onDeadline := o.deadlineThreshold, o.onDeadline
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		timer := time.AfterFunc(time.Until(deadline)-threshold, func() {
+			remaining := time.Until(deadline)
+			if remaining < 0 {
+				remaining = 0
+			}
+			onDeadline(ctx, remaining)
+		})
+		defer timer.Stop()
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,53 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestDeadlineWarning(t *testing.T) {
+	type warning struct {
+		path      string
+		remaining time.Duration
+	}
+	warnings := make(chan warning, 1)
+	opts := newOptions([]Option{
+		WithDeadlineWarning(50*time.Millisecond, func(ctx context.Context, remaining time.Duration) {
+			warnings <- warning{path: Info(ctx).RawPath, remaining: remaining}
+		}),
+	})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+		}
+	})
+	handler := apiGatewayHandler(opts.wrap(h), opts)
+
+	for _, path := range []string{"/fast", "/slow"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		if _, err := handler(ctx, events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: path}); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+	}
+	select {
+	case w := <-warnings:
+		if got, want := w.path, "/slow"; got != want {
+			t.Errorf("got path %q, want %q", got, want)
+		}
+		if w.remaining > 50*time.Millisecond {
+			t.Errorf("got remaining %v, want at most 50ms", w.remaining)
+		}
+	default:
+		t.Fatal("got no warning, want warning")
+	}
+	select {
+	case w := <-warnings:
+		t.Errorf("got unexpected warning for %q", w.path)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
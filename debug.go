@@ -0,0 +1,182 @@
+package apigatewayproxy
+
+import (
+	"crypto/subtle"
+	"expvar"
+	"fmt"
+	"html"
+	"net/http"
+	"runtime/pprof"
+	"runtime/trace"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DebugSecretHeader is the name of the request header that must contain the
+// secret passed to WithDebug before the debug endpoints are served.
+const DebugSecretHeader = "X-Debug-Secret"
+
+// WithDebug configures the adapter to serve runtime profiling data and
+// exported variables under path. The debug endpoints are:
+//
+//	{path}/pprof/          index of available profiles
+//	{path}/pprof/profile   CPU profile ("seconds" query parameter, default 10, at most 30)
+//	{path}/pprof/trace     execution trace ("seconds" query parameter, default 1, at most 30)
+//	{path}/pprof/{name}    named profile, eg heap, goroutine, allocs ("debug" query parameter)
+//	{path}/vars            exported variables in JSON format (see package expvar)
+//
+// If secret is not empty, the debug endpoints are only served if the request
+// has a DebugSecretHeader header matching secret. When running in AWS Lambda,
+// the debug endpoints are disabled if secret is empty. Requests that are not
+// authorized are passed to the HTTP handler as if the debug endpoints
+// did not exist.
+//
+// The profiles are served without registering the net/http/pprof handlers
+// with http.DefaultServeMux. WithDebug panics if path is empty or the root
+// path, which would hide every other path from the HTTP handler.
+func WithDebug(path, secret string) Option {
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		panic("apigatewayproxy: debug path cannot be the root path")
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return func(o *options) {
+		o.debugPath = path
+		o.debugSecret = secret
+	}
+}
+
+// debugHandler serves the debug endpoints under prefix, and passes all
+// other requests to h.
+func debugHandler(h http.Handler, prefix string, secret string) http.Handler {
+	if secret == "" && IsLambda() {
+		// never expose debug endpoints in Lambda without a secret
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, prefix+"/") || !debugAuthorized(r, secret) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		name := strings.TrimPrefix(r.URL.Path, prefix+"/")
+		switch {
+		case name == "vars":
+			expvar.Handler().ServeHTTP(w, r)
+		case name == "pprof/":
+			debugIndex(w)
+		case name == "pprof/profile":
+			debugCPUProfile(w, r)
+		case name == "pprof/trace":
+			debugTrace(w, r)
+		case strings.HasPrefix(name, "pprof/"):
+			debugProfile(w, r, strings.TrimPrefix(name, "pprof/"))
+		default:
+			WriteError(w, r, http.StatusNotFound, "")
+		}
+	})
+}
+
+func debugAuthorized(r *http.Request, secret string) bool {
+	if secret == "" {
+		return true
+	}
+	got := r.Header.Get(DebugSecretHeader)
+	return subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1
+}
+
+func debugIndex(w http.ResponseWriter) {
+	profiles := pprof.Profiles()
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name() < profiles[j].Name()
+	})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintln(w, "<html><head><title>profiles</title></head><body><table>")
+	for _, p := range profiles {
+		name := html.EscapeString(p.Name())
+		fmt.Fprintf(w, "<tr><td>%d</td><td><a href=\"%s?debug=1\">%s</a></td></tr>\n", p.Count(), name, name)
+	}
+	fmt.Fprintln(w, "<tr><td></td><td><a href=\"profile\">profile</a></td></tr>")
+	fmt.Fprintln(w, "<tr><td></td><td><a href=\"trace\">trace</a></td></tr>")
+	fmt.Fprintln(w, "</table></body></html>")
+}
+
+func debugProfile(w http.ResponseWriter, r *http.Request, name string) {
+	p := pprof.Lookup(name)
+	if p == nil {
+		WriteError(w, r, http.StatusNotFound, "")
+		return
+	}
+	debug, _ := strconv.Atoi(r.FormValue("debug"))
+	if debug > 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	}
+	p.WriteTo(w, debug)
+}
+
+func debugCPUProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="profile"`)
+	if err := pprof.StartCPUProfile(w); err != nil {
+		w.Header().Del("Content-Disposition")
+		WriteError(w, r, http.StatusInternalServerError, "cannot start CPU profile: "+err.Error())
+		return
+	}
+	debugSleep(r, 10)
+	pprof.StopCPUProfile()
+}
+
+func debugTrace(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="trace"`)
+	if err := trace.Start(w); err != nil {
+		w.Header().Del("Content-Disposition")
+		WriteError(w, r, http.StatusInternalServerError, "cannot start trace: "+err.Error())
+		return
+	}
+	debugSleep(r, 1)
+	trace.Stop()
+}
+
+// maxDebugDuration is the longest time that a CPU profile or execution
+// trace is collected for.
+const maxDebugDuration = 30 * time.Second
+
+// debugSleep sleeps for the duration returned by debugDuration, or until
+// the request is cancelled.
+func debugSleep(r *http.Request, defaultSeconds int) {
+	select {
+	case <-time.After(debugDuration(r, defaultSeconds)):
+	case <-r.Context().Done():
+	}
+}
+
+// debugDuration returns the number of seconds in the "seconds" query
+// parameter, at most maxDebugDuration. If the request context has a
+// deadline, such as the Lambda deadline, the duration ends
+// DefaultDeadlineThreshold before it, so that there is time to send
+// the response.
+func debugDuration(r *http.Request, defaultSeconds int) time.Duration {
+	seconds, err := strconv.Atoi(r.FormValue("seconds"))
+	if err != nil || seconds <= 0 {
+		seconds = defaultSeconds
+	}
+	d := maxDebugDuration
+	if seconds < int(maxDebugDuration/time.Second) {
+		d = time.Duration(seconds) * time.Second
+	}
+	if deadline, ok := r.Context().Deadline(); ok {
+		if remaining := time.Until(deadline) - DefaultDeadlineThreshold; remaining < d {
+			d = max(remaining, 0)
+		}
+	}
+	return d
+}
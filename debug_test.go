@@ -0,0 +1,109 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDebug(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("handler"))
+	})
+	tests := []struct {
+		lambda  bool
+		secret  string
+		path    string
+		header  string
+		status  int
+		handler bool
+		prefix  string
+	}{
+		{path: "/debug/vars", status: 200, prefix: "{"},
+		{path: "/debug/pprof/", status: 200, prefix: "<html>"},
+		{path: "/debug/pprof/goroutine?debug=1", status: 200, prefix: "goroutine profile"},
+		{path: "/debug/pprof/no-such-profile", status: 404},
+		{path: "/other", status: 200, handler: true},
+		{secret: "s3cret", path: "/debug/vars", status: 200, handler: true},
+		{secret: "s3cret", path: "/debug/vars", header: "wrong", status: 200, handler: true},
+		{secret: "s3cret", path: "/debug/vars", header: "s3cret", status: 200, prefix: "{"},
+		{lambda: true, path: "/debug/vars", status: 200, handler: true},
+		{lambda: true, secret: "s3cret", path: "/debug/vars", header: "s3cret", status: 200, prefix: "{"},
+	}
+
+	defer os.Unsetenv("_LAMBDA_SERVER_PORT")
+	for i, tt := range tests {
+		if tt.lambda {
+			os.Setenv("_LAMBDA_SERVER_PORT", "3000")
+		} else {
+			os.Unsetenv("_LAMBDA_SERVER_PORT")
+		}
+		h := Handler(next, WithDebug("/debug", tt.secret))
+		r := httptest.NewRequest("GET", tt.path, nil)
+		if tt.header != "" {
+			r.Header.Set(DebugSecretHeader, tt.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got, want := w.Code, tt.status; got != want {
+			t.Errorf("%d: got=%d, want=%d", i, got, want)
+		}
+		body := w.Body.String()
+		if got, want := body == "handler", tt.handler; got != want {
+			t.Errorf("%d: got handler=%v, want=%v", i, got, want)
+		}
+		if !strings.HasPrefix(body, tt.prefix) {
+			t.Errorf("%d: got %q, want prefix %q", i, body, tt.prefix)
+		}
+	}
+}
+
+func TestWithDebugPath(t *testing.T) {
+	for _, path := range []string{"/debug", "/debug/", "debug"} {
+		o := newOptions([]Option{WithDebug(path, "")})
+		if got, want := o.debugPath, "/debug"; got != want {
+			t.Errorf("%s: got=%q, want=%q", path, got, want)
+		}
+	}
+	for _, path := range []string{"/", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%q: got no panic, want panic", path)
+				}
+			}()
+			WithDebug(path, "")
+		}()
+	}
+}
+
+func TestDebugDuration(t *testing.T) {
+	tests := []struct {
+		query   string
+		timeout time.Duration
+		min     time.Duration
+		max     time.Duration
+	}{
+		{query: "", min: 10 * time.Second, max: 10 * time.Second},
+		{query: "seconds=x", min: 10 * time.Second, max: 10 * time.Second},
+		{query: "seconds=5", min: 5 * time.Second, max: 5 * time.Second},
+		{query: "seconds=3600", min: maxDebugDuration, max: maxDebugDuration},
+		{query: "seconds=20", timeout: 5 * time.Second, min: 3 * time.Second, max: 4 * time.Second},
+		{query: "seconds=20", timeout: time.Second / 2, min: 0, max: 0},
+	}
+	for i, tt := range tests {
+		r := httptest.NewRequest("GET", "/debug/pprof/profile?"+tt.query, nil)
+		if tt.timeout != 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), tt.timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+		if got := debugDuration(r, 10); got < tt.min || got > tt.max {
+			t.Errorf("%d: got %v, want between %v and %v", i, got, tt.min, tt.max)
+		}
+	}
+}
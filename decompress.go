@@ -0,0 +1,161 @@
+package apigatewayproxy
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jjeffery/kv"
+)
+
+// Default limits for request decompression.
+const (
+	DefaultDecompressionMaxSize  = 10 << 20 // 10 MiB
+	DefaultDecompressionMaxRatio = 100
+)
+
+// DecompressionLimits limits the size of decompressed request bodies, so
+// that a small compressed body cannot exhaust the memory of the function.
+type DecompressionLimits struct {
+	// MaxSize is the maximum size of a decompressed body, in bytes. If
+	// zero or negative, DefaultDecompressionMaxSize is used.
+	MaxSize int64
+
+	// MaxRatio is the maximum ratio of the size of the decompressed body to
+	// the size of the compressed body. If zero or negative,
+	// DefaultDecompressionMaxRatio is used.
+	MaxRatio int64
+}
+
+// WithRequestDecompression configures the adapter to decompress request
+// bodies with a Content-Encoding of gzip or deflate before calling the
+// handler, which receives the decompressed body without the
+// Content-Encoding header. As specified by RFC 9110, a deflate body is in the
+// zlib format, but bodies in the raw deflate format sent by some clients are
+// also accepted. Bodies with other content codings are passed to the
+// handler unchanged.
+//
+// The body is decompressed into memory before the handler is called, and
+// decompression stops as soon as the body exceeds either of the limits, in
+// which case the request receives a 413 (Request Entity Too Large)
+// response. A body that cannot be decompressed receives a 400 (Bad Request)
+// response. Requests are decompressed in the same way when running as a
+// conventional HTTP server.
+func WithRequestDecompression(limits DecompressionLimits) Option {
+	if limits.MaxSize <= 0 {
+		limits.MaxSize = DefaultDecompressionMaxSize
+	}
+	if limits.MaxRatio <= 0 {
+		limits.MaxRatio = DefaultDecompressionMaxRatio
+	}
+	return func(o *options) {
+		o.decompression = &limits
+	}
+}
+
+// errDecompressionLimit is returned when a decompressed body exceeds the
+// decompression limits.
+var errDecompressionLimit = errors.New("decompressed request body too large")
+
+// decompressHandler decompresses request bodies before calling h.
+func decompressHandler(h http.Handler, limits *DecompressionLimits) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+		if encoding != "gzip" && encoding != "x-gzip" && encoding != "deflate" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body, err := decompressBody(r.Body, encoding, limits)
+		r.Body.Close()
+		if err != nil {
+			Logger(r.Context()).Warn("cannot decompress request body", "error", err)
+			if errors.Is(err, errDecompressionLimit) {
+				WriteError(w, r, http.StatusRequestEntityTooLarge, "decompressed request body too large")
+			} else {
+				WriteError(w, r, http.StatusBadRequest, "invalid compressed request body")
+			}
+			return
+		}
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.Header = r.Header.Clone()
+		r2.Header.Del("Content-Encoding")
+		r2.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		r2.ContentLength = int64(len(body))
+		r2.Body = io.NopCloser(bytes.NewReader(body))
+		h.ServeHTTP(w, r2)
+	})
+}
+
+// decompressBody reads and decompresses body, and returns an error wrapping
+// errDecompressionLimit as soon as the result exceeds the limits.
+func decompressBody(body io.Reader, encoding string, limits *DecompressionLimits) ([]byte, error) {
+	compressed := &countingReader{r: body}
+	var zr io.ReadCloser
+	var err error
+	if encoding == "deflate" {
+		br := bufio.NewReader(compressed)
+		if header, _ := br.Peek(2); isZlibHeader(header) {
+			if zr, err = zlib.NewReader(br); err != nil {
+				return nil, kv.Wrap(err, "cannot read zlib header")
+			}
+		} else {
+			zr = flate.NewReader(br)
+		}
+	} else {
+		if zr, err = gzip.NewReader(compressed); err != nil {
+			return nil, kv.Wrap(err, "cannot read gzip header")
+		}
+	}
+	defer zr.Close()
+	var buf bytes.Buffer
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := zr.Read(chunk)
+		buf.Write(chunk[:n])
+		size := int64(buf.Len())
+		if size > limits.MaxSize {
+			return nil, kv.Wrap(errDecompressionLimit, "exceeds maximum size").With("maxSize", limits.MaxSize)
+		}
+		// the compressed size read so far is at least the size of the input
+		// that produced the output so far
+		if size > limits.MaxRatio*max(compressed.n, 1) {
+			return nil, kv.Wrap(errDecompressionLimit, "exceeds maximum ratio").With("maxRatio", limits.MaxRatio)
+		}
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, kv.Wrap(err, "cannot decompress body").With("encoding", encoding)
+		}
+	}
+}
+
+// isZlibHeader reports whether header starts with a valid zlib header
+// (RFC 1950): the deflate compression method, and a check value that makes
+// the first two bytes a multiple of 31.
+func isZlibHeader(header []byte) bool {
+	if len(header) < 2 {
+		return false
+	}
+	return header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
+
+// countingReader counts the bytes read.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,150 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func gzipString(s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.Bytes()
+}
+
+func deflateString(s string) []byte {
+	var buf bytes.Buffer
+	zw, _ := flate.NewWriter(&buf, flate.BestCompression)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.Bytes()
+}
+
+func zlibString(s string) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestRequestDecompression(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Header.Get("Content-Encoding") + "|" + r.Header.Get("Content-Length") + "|" + string(body)))
+	})
+	text := strings.Repeat("hello, world ", 10)
+	tests := []struct {
+		limits     DecompressionLimits
+		encoding   string
+		body       []byte
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			encoding:   "gzip",
+			body:       gzipString(text),
+			wantStatus: http.StatusOK,
+			wantBody:   "|130|" + text,
+		},
+		{
+			encoding:   "deflate",
+			body:       zlibString(text),
+			wantStatus: http.StatusOK,
+			wantBody:   "|130|" + text,
+		},
+		{
+			// raw deflate, as sent by some clients
+			encoding:   "deflate",
+			body:       deflateString(text),
+			wantStatus: http.StatusOK,
+			wantBody:   "|130|" + text,
+		},
+		{
+			encoding:   "br",
+			body:       []byte("not decoded"),
+			wantStatus: http.StatusOK,
+			wantBody:   "br||not decoded",
+		},
+		{
+			limits:     DecompressionLimits{MaxSize: 100},
+			encoding:   "gzip",
+			body:       gzipString(text),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			// a zip bomb is stopped by the ratio
+			encoding:   "gzip",
+			body:       gzipString(strings.Repeat("a", 1<<20)),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			limits:     DecompressionLimits{MaxRatio: 10000},
+			encoding:   "gzip",
+			body:       gzipString(strings.Repeat("a", 1<<20)),
+			wantStatus: http.StatusOK,
+		},
+		{
+			encoding:   "gzip",
+			body:       []byte("not gzip"),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for i, tt := range tests {
+		o := newOptions([]Option{WithRequestDecompression(tt.limits)})
+		response, err := apiGatewayHandler(o.wrap(h), o)(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod:      "POST",
+			Path:            "/",
+			Headers:         map[string]string{"Content-Encoding": tt.encoding},
+			Body:            base64.StdEncoding.EncodeToString(tt.body),
+			IsBase64Encoded: true,
+		})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.StatusCode, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if tt.wantBody != "" {
+			if got, want := response.Body, tt.wantBody; got != want {
+				t.Errorf("%d: got body %q, want %q", i, got, want)
+			}
+		}
+	}
+}
+
+func TestRequestDecompressionLocal(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+	handler := Handler(h, WithRequestDecompression(DecompressionLimits{MaxSize: 1000}))
+
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(gzipString("hello")))
+	r.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if got, want := w.Body.String(), "hello"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	r = httptest.NewRequest("POST", "/", bytes.NewReader(gzipString(strings.Repeat("a", 2000))))
+	r.Header.Set("Content-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if got, want := w.Code, http.StatusRequestEntityTooLarge; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+}
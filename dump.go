@@ -0,0 +1,78 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httputil"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// DumpProxyRequest returns the wire representation of the HTTP request that
+// the handler receives for the request event, in the same format as
+// httputil.DumpRequest. If body is true, the body is included, decoded
+// from base64 if necessary. The request is converted using the default
+// options, so it includes any headers synthesized by the adapter, such
+// as X-Forwarded-Proto.
+func DumpProxyRequest(ev *events.APIGatewayProxyRequest, body bool) ([]byte, error) {
+	o := newOptions([]Option{WithBufferPool(nil)})
+	inv := newInvocation(o)
+	defer inv.release()
+	inv.request = *ev
+	if err := normalizeRequest(&inv.request, o); err != nil {
+		return nil, err
+	}
+	r, err := inv.newRequest(context.Background(), ctxKeyEventContext, &inv.request)
+	if err != nil {
+		return nil, err
+	}
+	b, err := httputil.DumpRequest(r, body)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot dump request")
+	}
+	return b, nil
+}
+
+// DumpProxyResponse returns the wire representation of the response event,
+// in the same format as httputil.DumpResponse. If body is true, the body is
+// included, decoded from base64 if necessary.
+func DumpProxyResponse(resp *events.APIGatewayProxyResponse, body bool) ([]byte, error) {
+	var content []byte
+	if resp.IsBase64Encoded {
+		var err error
+		if content, err = base64.StdEncoding.DecodeString(resp.Body); err != nil {
+			return nil, kv.Wrap(newConversionError(ErrInvalidBase64Body, err), "cannot decode response body")
+		}
+	} else {
+		content = []byte(resp.Body)
+	}
+	header := make(http.Header, len(resp.Headers)+len(resp.MultiValueHeaders))
+	for k, vv := range resp.MultiValueHeaders {
+		header[http.CanonicalHeaderKey(k)] = vv
+	}
+	for k, v := range resp.Headers {
+		// API Gateway merges the single value headers into the multi-value headers
+		k = http.CanonicalHeaderKey(k)
+		if _, ok := header[k]; !ok {
+			header[k] = []string{v}
+		}
+	}
+	r := &http.Response{
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(content)),
+		ContentLength: int64(len(content)),
+	}
+	b, err := httputil.DumpResponse(r, body)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot dump response")
+	}
+	return b, nil
+}
@@ -0,0 +1,76 @@
+package apigatewayproxy
+
+import (
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestDumpProxyRequest(t *testing.T) {
+	ev := &events.APIGatewayProxyRequest{
+		HTTPMethod:            "POST",
+		Path:                  "/users",
+		QueryStringParameters: map[string]string{"q": "1"},
+		Headers: map[string]string{
+			"host":              "api.example.com",
+			"content-type":      "text/plain",
+			"x-forwarded-for":   "203.0.113.1",
+			"x-forwarded-proto": "https",
+			"x-forwarded-port":  "443",
+		},
+		Body:            "aGVsbG8=",
+		IsBase64Encoded: true,
+	}
+	b, err := DumpProxyRequest(ev, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "POST /users?q=1 HTTP/1.1\r\n" +
+		"Host: api.example.com\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"X-Forwarded-For: 203.0.113.1\r\n" +
+		"X-Forwarded-Port: 443\r\n" +
+		"X-Forwarded-Proto: https\r\n" +
+		"\r\n" +
+		"hello"
+	if got := string(b); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	b, err = DumpProxyRequest(ev, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), want[:len(want)-len("hello")]; got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+
+	if _, err := DumpProxyRequest(&events.APIGatewayProxyRequest{Path: "/", Body: "!", IsBase64Encoded: true}, true); err == nil {
+		t.Error("got no error, want error")
+	}
+}
+
+func TestDumpProxyResponse(t *testing.T) {
+	resp := &events.APIGatewayProxyResponse{
+		StatusCode: 201,
+		Headers:    map[string]string{"content-type": "application/json"},
+		MultiValueHeaders: map[string][]string{
+			"Set-Cookie": {"a=1", "b=2"},
+		},
+		Body: `{"id":1}`,
+	}
+	b, err := DumpProxyResponse(resp, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "HTTP/1.1 201 Created\r\n" +
+		"Content-Length: 8\r\n" +
+		"Content-Type: application/json\r\n" +
+		"Set-Cookie: a=1\r\n" +
+		"Set-Cookie: b=2\r\n" +
+		"\r\n" +
+		`{"id":1}`
+	if got := string(b); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"net/textproto"
+
+	"github.com/jjeffery/kv"
+)
+
+// WithErrorHook configures the adapter to call hook when an invocation
+// fails: when the event cannot be converted into an HTTP request or the
+// response cannot be converted back, when the HTTP handler panics, and when
+// the response is too large to return to Lambda. The snapshot contains the
+// request details with credentials redacted, so hook can pass it straight
+// to an error reporting service.
+//
+// A panic in the handler is recovered and a 500 (Internal Server Error)
+// response is returned, as for WithPanicHook. Otherwise the hook does not
+// change how the failure is handled. Use WithPanicHook for access to the
+// stack trace of a panic.
+func WithErrorHook(hook func(err error, snapshot RequestSnapshot)) Option {
+	return func(o *options) {
+		o.onError = hook
+	}
+}
+
+// newRequestSnapshot returns a snapshot of r with credentials redacted.
+func newRequestSnapshot(r *http.Request) RequestSnapshot {
+	return RequestSnapshot{
+		Method:     r.Method,
+		URL:        r.URL.String(),
+		Header:     redactHeader(r.Header, redactedHeaders),
+		RemoteAddr: ClientIP(r),
+		Info:       Info(r.Context()),
+	}
+}
+
+// eventSnapshot returns a snapshot of the request in the event, for
+// failures where there may not be an HTTP request.
+func (inv *invocation) eventSnapshot() RequestSnapshot {
+	request := &inv.request
+	header := make(http.Header, len(request.Headers))
+	for k, vs := range request.MultiValueHeaders {
+		header[textproto.CanonicalMIMEHeaderKey(k)] = vs
+	}
+	for k, v := range request.Headers {
+		header.Set(k, v)
+	}
+	uri := request.Path
+	if query := requestQuery("", request, &options{}); query != "" {
+		uri += "?" + query
+	}
+	snapshot := RequestSnapshot{
+		Method:     request.HTTPMethod,
+		URL:        uri,
+		Header:     redactHeader(header, redactedHeaders),
+		RemoteAddr: request.RequestContext.Identity.SourceIP,
+	}
+	if inv.ctx.Context != nil {
+		snapshot.Info = Info(&inv.ctx)
+	}
+	return snapshot
+}
+
+// reportError calls the error hook, if configured.
+func (inv *invocation) reportError(err error, snapshot func() RequestSnapshot) {
+	if inv.opts.onError != nil {
+		inv.opts.onError(err, snapshot())
+	}
+}
+
+// panicError returns the error reported to the error hook when the
+// handler panics.
+func panicError(value interface{}) error {
+	if err, ok := value.(error); ok {
+		return kv.Wrap(err, "handler panicked")
+	}
+	return kv.NewError("handler panicked").With("panic", value)
+}
@@ -0,0 +1,82 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestErrorHook(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/panic":
+			panic("boom")
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", MaxResponseSize)))
+		}
+	})
+	type report struct {
+		err      error
+		snapshot RequestSnapshot
+	}
+	var reports []report
+	handler := apiGatewayHandler(h, newOptions([]Option{
+		WithErrorHook(func(err error, snapshot RequestSnapshot) {
+			reports = append(reports, report{err: err, snapshot: snapshot})
+		}),
+	}))
+
+	tests := []struct {
+		request   events.APIGatewayProxyRequest
+		wantErr   bool
+		wantError string
+		wantURL   string
+	}{
+		{
+			request: events.APIGatewayProxyRequest{
+				HTTPMethod:            "GET",
+				Path:                  "/decode",
+				QueryStringParameters: map[string]string{"q": "1"},
+				Body:                  "not base64!",
+				IsBase64Encoded:       true,
+			},
+			wantErr:   true,
+			wantError: "invalid base64 body",
+			wantURL:   "/decode?q=1",
+		},
+		{
+			request:   events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/panic"},
+			wantError: "handler panicked",
+			wantURL:   "/panic",
+		},
+		{
+			request:   events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/large"},
+			wantError: "response body too large",
+			wantURL:   "/large",
+		},
+	}
+	for i, tt := range tests {
+		reports = nil
+		tt.request.Headers = map[string]string{"authorization": "secret"}
+		_, err := handler(context.Background(), tt.request)
+		if got, want := err != nil, tt.wantErr; got != want {
+			t.Errorf("%d: got error %v, want error=%v", i, err, want)
+		}
+		if len(reports) != 1 {
+			t.Fatalf("%d: got %d reports, want 1", i, len(reports))
+		}
+		r := reports[0]
+		if got := r.err.Error(); !strings.Contains(got, tt.wantError) {
+			t.Errorf("%d: got error %q, want %q", i, got, tt.wantError)
+		}
+		if got, want := r.snapshot.URL, tt.wantURL; got != want {
+			t.Errorf("%d: got url %q, want %q", i, got, want)
+		}
+		if got, want := r.snapshot.Header.Get("Authorization"), Redacted; got != want {
+			t.Errorf("%d: got Authorization=%q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"text/template"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// InternalError is the data passed to the error response template.
+type InternalError struct {
+	Status    int    // HTTP status code
+	Message   string // status text, such as "Internal Server Error"
+	RequestID string // API Gateway request ID, if known
+}
+
+// WithErrorResponse configures the response returned when the adapter
+// itself fails: for example when the request event cannot be converted,
+// the response is too large to return, or the handler panics. Without this
+// option the error is returned to Lambda, and API Gateway returns its
+// default 502 (Bad Gateway) response.
+//
+// The body is a text/template executed with an InternalError. The template
+// function "json" encodes a value as JSON, which is useful for building a
+// JSON body that matches an API's error schema:
+//
+//	WithErrorResponse("application/json",
+//		`{"error":{"code":{{.Status}},"message":{{json .Message}},"requestId":{{json .RequestID}}}}`)
+//
+// WithErrorResponse panics if the template cannot be parsed, so that a
+// configuration error is detected at startup. If contentType is empty,
+// "text/plain; charset=utf-8" is used.
+func WithErrorResponse(contentType string, body string) Option {
+	tmpl := template.Must(template.New("error").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(body))
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	return func(o *options) {
+		o.errorTemplate = tmpl
+		o.errorContentType = contentType
+	}
+}
+
+// errorResponse returns the status, headers and body of the configured
+// error response. If there is no error response configured, or the
+// template fails, a plain text body is returned.
+func (o *options) errorResponse(status int, request *events.APIGatewayProxyRequest) (int, map[string]string, string) {
+	data := InternalError{
+		Status:    status,
+		Message:   http.StatusText(status),
+		RequestID: request.RequestContext.RequestID,
+	}
+	var buf bytes.Buffer
+	if o.errorTemplate == nil || o.errorTemplate.Execute(&buf, data) != nil {
+		return status, map[string]string{
+			"Content-Type": "text/plain; charset=utf-8",
+		}, strconv.Itoa(status) + " " + data.Message + "\n"
+	}
+	return status, map[string]string{
+		"Content-Type":  o.errorContentType,
+		"Cache-Control": "no-store",
+	}, buf.String()
+}
@@ -0,0 +1,87 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestErrorResponse(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/panic":
+			w.Header().Set("X-Partial", "1")
+			w.Write([]byte("partial"))
+			panic("boom")
+		case "/large":
+			w.Write(bytes.Repeat([]byte("x"), MaxResponseSize))
+		default:
+			w.Write([]byte("ok"))
+		}
+	})
+	opts := newOptions([]Option{
+		WithErrorResponse("application/json", `{"code":{{.Status}},"message":{{json .Message}},"requestId":{{json .RequestID}}}`),
+	})
+	const want = `{"code":500,"message":"Internal Server Error","requestId":"req-1"}`
+	tests := []struct {
+		request events.APIGatewayProxyRequest
+		want    string
+	}{
+		{
+			request: events.APIGatewayProxyRequest{Path: "/", Body: "!", IsBase64Encoded: true},
+			want:    want,
+		},
+		{
+			request: events.APIGatewayProxyRequest{Path: "/panic"},
+			want:    want,
+		},
+		{
+			request: events.APIGatewayProxyRequest{Path: "/large"},
+			want:    want,
+		},
+		{
+			request: events.APIGatewayProxyRequest{Path: "/"},
+			want:    "ok",
+		},
+	}
+	for i, tt := range tests {
+		tt.request.RequestContext.RequestID = "req-1"
+		handler := apiGatewayHandler(h, opts)
+		response, err := handler(context.Background(), tt.request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if tt.want == want {
+			if got, want := response.StatusCode, http.StatusInternalServerError; got != want {
+				t.Errorf("%d: got status %d, want %d", i, got, want)
+			}
+			if got, want := response.Headers["Content-Type"], "application/json"; got != want {
+				t.Errorf("%d: got content type %q, want %q", i, got, want)
+			}
+			if _, ok := response.Headers["X-Partial"]; ok {
+				t.Errorf("%d: got partial response header", i)
+			}
+		}
+	}
+
+	// without the option, the error is returned to Lambda
+	handler := apiGatewayHandler(h, newOptions(nil))
+	if _, err := handler(context.Background(), events.APIGatewayProxyRequest{Path: "/", Body: "!", IsBase64Encoded: true}); err == nil {
+		t.Error("got no error, want error")
+	}
+}
+
+func TestWithErrorResponsePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic, want panic")
+		}
+	}()
+	WithErrorResponse("", "{{.Status")
+}
@@ -0,0 +1,67 @@
+package apigatewayproxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Errors returned when converting between Lambda events and HTTP requests
+// and responses. The errors returned by this package wrap these errors, and
+// the underlying cause if any, so use errors.Is to check for them, and
+// errors.As to obtain the cause.
+var (
+	ErrInvalidBase64Body = errors.New("invalid base64 body")
+	ErrInvalidPath       = errors.New("invalid path")
+	ErrInvalidMethod     = errors.New("invalid method")
+	ErrBodyTooLarge      = errors.New("response body too large")
+)
+
+// ErrUnsupported is wrapped by the errors returned for unsupported features
+// configured as strict using WithStrict. It wraps http.ErrNotSupported, so
+// errors.Is reports true for either.
+var ErrUnsupported = fmt.Errorf("%w by API Gateway", http.ErrNotSupported)
+
+// ErrUnauthorized is returned to Lambda by StartAuthorizer when the handler
+// responds with a 401 (Unauthorized) status code. API Gateway requires the
+// error message to be exactly "Unauthorized".
+var ErrUnauthorized = errors.New("Unauthorized")
+
+// ErrChecksumMismatch is wrapped by the error logged when a request body
+// does not match its checksum headers, see WithChecksumValidation.
+var ErrChecksumMismatch = errors.New("request body checksum mismatch")
+
+// conversionError wraps one of the Err values and the underlying cause,
+// so that errors.Is and errors.As work for both.
+type conversionError struct {
+	kind  error
+	cause error
+}
+
+// newConversionError returns an error that wraps kind and cause.
+func newConversionError(kind, cause error) error {
+	if cause == nil {
+		return kind
+	}
+	return &conversionError{kind: kind, cause: cause}
+}
+
+func (e *conversionError) Error() string {
+	return e.kind.Error() + ": " + e.cause.Error()
+}
+
+func (e *conversionError) Unwrap() []error {
+	return []error{e.kind, e.cause}
+}
+
+// requestError returns the error for the cause returned by http.NewRequest,
+// which is a *url.Error if the path cannot be parsed, otherwise an invalid
+// method.
+func requestError(cause error) error {
+	var urlErr *url.Error
+	if errors.As(cause, &urlErr) {
+		return newConversionError(ErrInvalidPath, cause)
+	}
+	return newConversionError(ErrInvalidMethod, cause)
+}
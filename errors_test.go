@@ -0,0 +1,54 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestConversionErrors(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		request events.APIGatewayProxyRequest
+		opts    []Option
+		want    error
+		cause   interface{}
+	}{
+		{
+			request: events.APIGatewayProxyRequest{HTTPMethod: "POST", Path: "/", Body: "not base64!", IsBase64Encoded: true},
+			want:    ErrInvalidBase64Body,
+			cause:   new(base64.CorruptInputError),
+		},
+		{
+			request: events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/%zz"},
+			opts:    []Option{WithOriginalRequestURI()},
+			want:    ErrInvalidPath,
+			cause:   new(*url.Error),
+		},
+		{
+			request: events.APIGatewayProxyRequest{HTTPMethod: "BAD METHOD", Path: "/"},
+			want:    ErrInvalidMethod,
+		},
+		{
+			request: events.APIGatewayProxyRequest{Path: "/"},
+			opts:    []Option{WithStrictEvents()},
+			want:    ErrInvalidMethod,
+		},
+	}
+	for i, tt := range tests {
+		o := newOptions(tt.opts)
+		_, err := apiGatewayHandler(h, o)(context.Background(), tt.request)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%d: got %v, want %v", i, err, tt.want)
+			continue
+		}
+		if tt.cause != nil && !errors.As(err, tt.cause) {
+			t.Errorf("%d: got %v, want cause %T", i, err, tt.cause)
+		}
+	}
+}
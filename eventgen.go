@@ -0,0 +1,98 @@
+package apigatewayproxy
+
+import (
+	"encoding/base64"
+	"math/rand"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// generator building blocks, chosen to exercise the edge cases of the
+// event conversion
+var (
+	genMethods  = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "PROPFIND"}
+	genSegments = []string{"", "a", "users", "123", "a b", "é", "日本語", "💡", "a%2Fb", "%E2%9C%93", "..", ".", "a;b", "a+b", "~x", "a=b"}
+	genNames    = []string{"a", "q", "id", "x-y", "ü", "a b", "&", "="}
+	genValues   = []string{"", "1", "x y", "a&b=c", "ü", "%", "+", ";", "日本", "\t"}
+	genHeaders  = []string{"Accept", "content-type", "X-Custom", "x-custom", "X-DUP", "x-dup", "Cookie", "User-Agent"}
+)
+
+// GenerateEvent returns a randomized but valid API Gateway proxy event,
+// using rnd as the source of randomness. The events have a variety of
+// methods, paths containing escaped and non-ASCII characters, multi-value
+// query parameters, duplicate headers with different casing, and text
+// and binary bodies.
+//
+// GenerateEvent is deterministic for a given source, so it works well with
+// native Go fuzzing:
+//
+//	func FuzzHandler(f *testing.F) {
+//		f.Add(int64(1))
+//		f.Fuzz(func(t *testing.T, seed int64) {
+//			ev := apigatewayproxy.GenerateEvent(rand.New(rand.NewSource(seed)))
+//			payload, _ := json.Marshal(ev)
+//			apigatewayproxy.InvokeJSON(context.Background(), handler, payload)
+//		})
+//	}
+func GenerateEvent(rnd *rand.Rand) *events.APIGatewayProxyRequest {
+	pick := func(values []string) string {
+		return values[rnd.Intn(len(values))]
+	}
+	ev := &events.APIGatewayProxyRequest{
+		HTTPMethod: pick(genMethods),
+	}
+
+	segments := make([]string, 1+rnd.Intn(4))
+	for i := range segments {
+		segments[i] = pick(genSegments)
+		if !strings.Contains(segments[i], "%") {
+			// escape some of the segments, but not those containing
+			// escape sequences already
+			if rnd.Intn(2) == 0 {
+				segments[i] = url.PathEscape(segments[i])
+			}
+		}
+	}
+	ev.Path = "/" + strings.Join(segments, "/")
+
+	if n := rnd.Intn(4); n > 0 {
+		ev.QueryStringParameters = make(map[string]string)
+		ev.MultiValueQueryStringParameters = make(map[string][]string)
+		for i := 0; i < n; i++ {
+			name := pick(genNames)
+			value := pick(genValues)
+			ev.QueryStringParameters[name] = value
+			ev.MultiValueQueryStringParameters[name] = append(ev.MultiValueQueryStringParameters[name], value)
+		}
+	}
+
+	if n := rnd.Intn(5); n > 0 {
+		ev.Headers = make(map[string]string)
+		ev.MultiValueHeaders = make(map[string][]string)
+		for i := 0; i < n; i++ {
+			name := pick(genHeaders)
+			value := pick(genValues)
+			ev.Headers[name] = value
+			ev.MultiValueHeaders[name] = append(ev.MultiValueHeaders[name], value)
+		}
+	}
+
+	switch rnd.Intn(3) {
+	case 1:
+		ev.Body = pick(genValues) + pick(genSegments)
+	case 2:
+		body := make([]byte, rnd.Intn(64))
+		rnd.Read(body)
+		ev.Body = base64.StdEncoding.EncodeToString(body)
+		ev.IsBase64Encoded = true
+	}
+
+	ev.RequestContext = events.APIGatewayProxyRequestContext{
+		RequestID:  strings.ToLower(base64.RawURLEncoding.EncodeToString([]byte{byte(rnd.Intn(256)), byte(rnd.Intn(256))})),
+		HTTPMethod: ev.HTTPMethod,
+		Identity:   events.APIGatewayRequestIdentity{SourceIP: "203.0.113.1"},
+	}
+	return ev
+}
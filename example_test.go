@@ -44,3 +44,20 @@ func ExampleRequest() {
 		http.ListenAndServe(":8080", h)
 	}
 }
+
+func ExampleHandler() {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello world\n"))
+	})
+
+	// the same options apply in both modes
+	opts := []apigatewayproxy.Option{
+		apigatewayproxy.WithHealthCheck("/healthz"),
+	}
+
+	if apigatewayproxy.IsLambda() {
+		apigatewayproxy.Start(h, opts...)
+	} else {
+		http.ListenAndServe(":8080", apigatewayproxy.Handler(h, opts...))
+	}
+}
@@ -0,0 +1,114 @@
+// Package fasthttpproxy adapts fasthttp request handlers so that services
+// written using github.com/valyala/fasthttp can run in AWS Lambda behind
+// API Gateway. The handler is called from the net/http path of package
+// apigatewayproxy, so event parsing, the encoding of the response and the
+// hooks configured using options are shared with net/http handlers.
+//
+// This package is a separate module, so that services that do not use
+// fasthttp do not depend on it.
+package fasthttpproxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/jjeffery/apigatewayproxy"
+	"github.com/valyala/fasthttp"
+)
+
+// Start starts handling AWS Lambda API Gateway proxy requests by passing
+// each request to the fasthttp request handler. It is equivalent to
+// calling apigatewayproxy.Start with Handler(h).
+func Start(h fasthttp.RequestHandler, opts ...apigatewayproxy.Option) {
+	apigatewayproxy.Start(Handler(h), opts...)
+}
+
+// StartV2 is like Start, for API Gateway HTTP APIs using payload format
+// version 2.0.
+func StartV2(h fasthttp.RequestHandler, opts ...apigatewayproxy.Option) {
+	apigatewayproxy.StartV2(Handler(h), opts...)
+}
+
+// Handler returns an HTTP handler that converts each HTTP request to a
+// fasthttp request, calls h, and writes the fasthttp response. Use Context
+// to obtain the context of the HTTP request in h, which is needed to call
+// functions such as apigatewayproxy.Request and apigatewayproxy.Logger.
+//
+// The request body is read into memory before calling h, and a response
+// body stream is read to the end after h returns, because the response
+// is buffered anyway.
+func Handler(h fasthttp.RequestHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(req)
+		if err := newRequest(req, r); err != nil {
+			apigatewayproxy.WriteError(w, r, http.StatusBadRequest, "cannot read request body")
+			return
+		}
+		var ctx fasthttp.RequestCtx
+		ctx.Init(req, remoteAddr(r), nil)
+		ctx.SetUserValue(contextKey{}, r.Context())
+		h(&ctx)
+		writeResponse(w, &ctx.Response)
+	})
+}
+
+// contextKey is the user value key for the context of the HTTP request.
+type contextKey struct{}
+
+// Context returns the context of the HTTP request that ctx was created from,
+// or ctx itself if it was not created by Handler.
+func Context(ctx *fasthttp.RequestCtx) context.Context {
+	if c, ok := ctx.UserValue(contextKey{}).(context.Context); ok {
+		return c
+	}
+	return ctx
+}
+
+// newRequest copies the HTTP request to req.
+func newRequest(req *fasthttp.Request, r *http.Request) error {
+	req.Header.SetMethod(r.Method)
+	req.SetRequestURI(r.URL.RequestURI())
+	req.Header.SetHost(r.Host)
+	for name, values := range r.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	req.SetBody(body)
+	return nil
+}
+
+// remoteAddr returns the address of the client of the HTTP request.
+func remoteAddr(r *http.Request) net.Addr {
+	ip := net.ParseIP(apigatewayproxy.ClientIP(r))
+	if ip == nil {
+		return nil
+	}
+	return &net.TCPAddr{IP: ip}
+}
+
+// writeResponse copies the fasthttp response to w.
+func writeResponse(w http.ResponseWriter, resp *fasthttp.Response) {
+	header := w.Header()
+	resp.Header.VisitAll(func(key, value []byte) {
+		switch string(key) {
+		case fasthttp.HeaderContentLength, fasthttp.HeaderConnection:
+			// the length is determined by the body, and API Gateway
+			// manages the connection
+			return
+		}
+		header.Add(string(key), string(value))
+	})
+	w.WriteHeader(resp.StatusCode())
+	w.Write(resp.Body())
+}
@@ -0,0 +1,77 @@
+package fasthttpproxy
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/apigatewayproxy"
+	"github.com/valyala/fasthttp"
+)
+
+func TestHandler(t *testing.T) {
+	h := Handler(func(ctx *fasthttp.RequestCtx) {
+		if got, want := apigatewayproxy.Request(Context(ctx)).RequestContext.RequestID, "request-id"; got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+		if got, want := ctx.RemoteIP().String(), "10.1.2.3"; got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+		ctx.Response.Header.Set("X-Method", string(ctx.Method()))
+		ctx.Response.Header.Set("X-Query", string(ctx.QueryArgs().Peek("q")))
+		ctx.Response.Header.Set("X-Header", string(ctx.Request.Header.Peek("X-Test")))
+		ctx.SetStatusCode(fasthttp.StatusCreated)
+		ctx.SetContentType("application/json")
+		ctx.Write(ctx.PostBody())
+	})
+
+	payload, err := json.Marshal(events.APIGatewayProxyRequest{
+		HTTPMethod:            "POST",
+		Path:                  "/things",
+		QueryStringParameters: map[string]string{"q": "search"},
+		Headers:               map[string]string{"X-Test": "header-value"},
+		Body:                  `{"a":1}`,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID: "request-id",
+			Identity:  events.APIGatewayRequestIdentity{SourceIP: "10.1.2.3"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := apigatewayproxy.InvokeJSON(context.Background(), h, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response events.APIGatewayProxyResponse
+	if err := json.Unmarshal(b, &response); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := response.StatusCode, fasthttp.StatusCreated; got != want {
+		t.Errorf("status: got=%d want=%d", got, want)
+	}
+	if got, want := response.Body, `{"a":1}`; got != want {
+		t.Errorf("body: got=%q want=%q", got, want)
+	}
+	for name, want := range map[string]string{
+		"Content-Type": "application/json",
+		"X-Method":     "POST",
+		"X-Query":      "search",
+		"X-Header":     "header-value",
+	} {
+		if got := response.Headers[name]; got != want {
+			t.Errorf("%s: got=%q want=%q", name, got, want)
+		}
+	}
+	if got, ok := response.Headers["Content-Length"]; ok {
+		t.Errorf("Content-Length: got=%q want none", got)
+	}
+}
+
+func TestContext(t *testing.T) {
+	var ctx fasthttp.RequestCtx
+	if got, want := Context(&ctx), context.Context(&ctx); got != want {
+		t.Errorf("got=%v want=%v", got, want)
+	}
+}
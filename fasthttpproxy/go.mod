@@ -0,0 +1,18 @@
+module github.com/jjeffery/apigatewayproxy/fasthttpproxy
+
+go 1.21
+
+require (
+	github.com/aws/aws-lambda-go v1.27.1
+	github.com/jjeffery/apigatewayproxy v0.0.0-00010101000000-000000000000
+	github.com/valyala/fasthttp v1.57.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/jjeffery/kv v0.8.1 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+)
+
+replace github.com/jjeffery/apigatewayproxy => ../
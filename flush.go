@@ -0,0 +1,78 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"time"
+
+	"github.com/jjeffery/kv"
+)
+
+// DefaultFlushTimeout is the default time allowed for the functions added
+// using WithTelemetryFlush.
+const DefaultFlushTimeout = 500 * time.Millisecond
+
+// WithTelemetryFlush configures the adapter to call flush after each Lambda
+// invocation has been handled, after SendingResponse and the span hooks have
+// been called, but before the response is returned to the Lambda runtime.
+// Lambda freezes the execution environment as soon as the response is
+// returned, so metrics and traces buffered by asynchronous exporters, such
+// as the OpenTelemetry batch span processor, are not exported until the
+// next invocation, if at all, unless they are flushed first.
+//
+// The context passed to flush is cancelled after timeout, or at the
+// deadline of the invocation if that is sooner. If timeout is zero or
+// negative, DefaultFlushTimeout is used. The response is returned when the
+// timeout expires even if flush has not returned. If flush returns an error,
+// it is logged and the response is returned as usual. If the option is
+// specified more than once, the functions are called in order, each with its
+// own timeout.
+//
+// The time taken by flush is included in the duration of the invocation.
+// When using StartStreaming, the body has been sent to the client before
+// flush is called, but the response does not end until flush returns. The
+// functions are not called when running as a conventional HTTP server.
+func WithTelemetryFlush(flush func(ctx context.Context) error, timeout time.Duration) Option {
+	if timeout <= 0 {
+		timeout = DefaultFlushTimeout
+	}
+	return func(o *options) {
+		o.flushes = append(o.flushes, telemetryFlush{flush: flush, timeout: timeout})
+	}
+}
+
+// telemetryFlush is a function added using WithTelemetryFlush.
+type telemetryFlush struct {
+	flush   func(ctx context.Context) error
+	timeout time.Duration
+}
+
+// flushInvoke returns an invokeFunc that calls the flush functions after
+// each invocation, or invoke itself if there are none.
+func (o *options) flushInvoke(invoke invokeFunc) invokeFunc {
+	if len(o.flushes) == 0 {
+		return invoke
+	}
+	return func(ctx context.Context, payload []byte) ([]byte, error) {
+		response, err := invoke(ctx, payload)
+		o.flush(ctx)
+		return response, err
+	}
+}
+
+// flush calls each of the flush functions in turn.
+func (o *options) flush(ctx context.Context) {
+	// the functions are called even if the invocation has been cancelled,
+	// but not after its deadline
+	deadline, hasDeadline := ctx.Deadline()
+	ctx = context.WithoutCancel(ctx)
+	if hasDeadline {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	for i, f := range o.flushes {
+		if err := callCheck(ctx, f.flush, f.timeout); err != nil {
+			Logger(ctx).Warn("telemetry flush failed", "error", kv.Wrap(err, "cannot flush").With("flush", i))
+		}
+	}
+}
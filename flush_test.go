@@ -0,0 +1,73 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestTelemetryFlush(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	call := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, name)
+	}
+	defer func(fn func(*events.APIGatewayProxyRequest, *events.APIGatewayProxyResponse)) {
+		SendingResponse = fn
+	}(SendingResponse)
+	SendingResponse = func(*events.APIGatewayProxyRequest, *events.APIGatewayProxyResponse) {
+		call("sending")
+	}
+	release := make(chan struct{})
+	defer close(release)
+	o := newOptions([]Option{
+		WithSpanHooks(SpanHooks{Finish: func(context.Context, interface{}, error) {
+			call("finish")
+		}}),
+		WithTelemetryFlush(func(ctx context.Context) error {
+			call("metrics")
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("got no deadline, want deadline")
+			}
+			return errors.New("exporter unavailable")
+		}, 0),
+		WithTelemetryFlush(func(ctx context.Context) error {
+			call("traces")
+			// ignores the context
+			<-release
+			return nil
+		}, 20*time.Millisecond),
+	})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call("handler")
+	})
+	invoke := o.flushInvoke(jsonInvoke(o.jsonCodec, withSpan(o, apiGatewayHandler(o.wrap(h), o))))
+	payload, _ := json.Marshal(events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	response, err := invoke(ctx, payload)
+	cancel()
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if len(response) == 0 {
+		t.Error("got empty response")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("got %v, want flush to time out", elapsed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"handler", "sending", "finish", "metrics", "traces"}; !equalStrings(calls, want) {
+		t.Errorf("got %q, want %q", calls, want)
+	}
+}
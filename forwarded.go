@@ -0,0 +1,168 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// forwardedHeaderCount is the number of headers set by addForwardedHeaders.
+const forwardedHeaderCount = 3
+
+// addForwardedHeaders adds the X-Forwarded-For, X-Forwarded-Proto and
+// X-Forwarded-Port headers if they are not present in the request event.
+// API Gateway usually provides them, but some sources, such as the console
+// test invoke, do not. Synthesizing the headers from the request context
+// means that middleware that relies on them behaves the same way
+// regardless of how the lambda is invoked.
+//
+// The values slice is storage for the header values. If it is too short,
+// the storage is allocated.
+func addForwardedHeaders(header http.Header, request *events.APIGatewayProxyRequest, values []string) {
+	const (
+		forwardedFor   = "X-Forwarded-For"
+		forwardedProto = "X-Forwarded-Proto"
+		forwardedPort  = "X-Forwarded-Port"
+	)
+	sourceIP := request.RequestContext.Identity.SourceIP
+	_, hasFor := header[forwardedFor]
+	_, hasProto := header[forwardedProto]
+	_, hasPort := header[forwardedPort]
+	if (hasFor || sourceIP == "") && hasProto && hasPort {
+		return
+	}
+
+	if len(values) < forwardedHeaderCount {
+		values = make([]string, forwardedHeaderCount)
+	}
+	values[0], values[1], values[2] = sourceIP, "https", "443"
+	if !hasFor && sourceIP != "" {
+		header[forwardedFor] = values[0:1:1]
+	}
+	if !hasProto {
+		header[forwardedProto] = values[1:2:2]
+	}
+	if !hasPort {
+		header[forwardedPort] = values[2:3:3]
+	}
+}
+
+// WithForwardedHeader configures the adapter to add a Forwarded header
+// (RFC 7239) to each HTTP request, if the request event does not already
+// have one. The header has a single element describing the hop from the
+// client to API Gateway, for example:
+//
+//	Forwarded: for=203.0.113.1;proto=https;host=api.example.com;by=_abc123
+//
+// The "by" parameter is the API Gateway API ID, formatted as an
+// obfuscated identifier.
+func WithForwardedHeader() Option {
+	return func(o *options) {
+		o.forwardedHeader = true
+	}
+}
+
+// addForwardedHeader adds the Forwarded header to r. It is called after
+// the X-Forwarded headers have been added, and uses X-Forwarded-Proto
+// so that the two headers are consistent.
+func addForwardedHeader(r *http.Request, request *events.APIGatewayProxyRequest) {
+	if _, ok := r.Header["Forwarded"]; ok {
+		return
+	}
+	var b strings.Builder
+	add := func(name, value string) {
+		if value == "" {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteByte(';')
+		}
+		b.WriteString(name)
+		b.WriteByte('=')
+		writeForwardedValue(&b, value)
+	}
+	forIP := request.RequestContext.Identity.SourceIP
+	if strings.IndexByte(forIP, ':') >= 0 {
+		// IPv6 addresses are enclosed in square brackets
+		forIP = "[" + forIP + "]"
+	}
+	host := r.Host
+	if host == "" {
+		host = request.RequestContext.DomainName
+	}
+	add("for", forIP)
+	add("proto", strings.ToLower(r.Header.Get("X-Forwarded-Proto")))
+	add("host", host)
+	if id := request.RequestContext.APIID; id != "" {
+		add("by", "_"+id)
+	}
+	if b.Len() > 0 {
+		r.Header["Forwarded"] = []string{b.String()}
+	}
+}
+
+// WithURLScheme configures the adapter to populate the Scheme and Host
+// fields of each request URL, so that r.URL is the absolute URL requested by
+// the client, which some libraries use to generate links and redirects. The
+// scheme is the first value of the X-Forwarded-Proto header, or "https" if
+// the header is missing or is not "http" or "https", so the URL is correct
+// when API Gateway is behind another proxy, such as CloudFront. The host is
+// the Host header, or the domain name in the request context.
+//
+// By default r.URL only contains the path and query, as it does for a
+// request received by a conventional HTTP server.
+func WithURLScheme() Option {
+	return func(o *options) {
+		o.urlScheme = true
+	}
+}
+
+// requestScheme returns the scheme of the request from the X-Forwarded-Proto
+// header, which may contain a comma separated list of values when the
+// request has passed through several proxies. The first value is the scheme
+// used by the client.
+func requestScheme(header http.Header) string {
+	proto := header.Get("X-Forwarded-Proto")
+	if i := strings.IndexByte(proto, ','); i >= 0 {
+		proto = proto[:i]
+	}
+	if strings.EqualFold(strings.TrimSpace(proto), "http") {
+		return "http"
+	}
+	return "https"
+}
+
+// setURLScheme sets the scheme and host of the request URL. It is called
+// after the RequestURI field has been set from the path and query.
+func setURLScheme(r *http.Request, request *events.APIGatewayProxyRequest) {
+	r.URL.Scheme = requestScheme(r.Header)
+	r.URL.Host = r.Host
+	if r.URL.Host == "" {
+		r.URL.Host = request.RequestContext.DomainName
+	}
+}
+
+// writeForwardedValue writes value as a token if possible, otherwise
+// as a quoted string.
+func writeForwardedValue(b *strings.Builder, value string) {
+	token := true
+	for i := 0; i < len(value); i++ {
+		if !isTokenChar(value[i]) {
+			token = false
+			break
+		}
+	}
+	if token {
+		b.WriteString(value)
+		return
+	}
+	b.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c == '"' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(value[i])
+	}
+	b.WriteByte('"')
+}
@@ -0,0 +1,179 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestForwardedHeaders(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Join([]string{
+			r.Header.Get("X-Forwarded-For"),
+			r.Header.Get("X-Forwarded-Proto"),
+			r.Header.Get("X-Forwarded-Port"),
+		}, ",")))
+	})
+	tests := []struct {
+		request events.APIGatewayProxyRequest
+		want    string
+	}{
+		{
+			request: events.APIGatewayProxyRequest{Path: "/"},
+			want:    ",https,443",
+		},
+		{
+			request: events.APIGatewayProxyRequest{
+				Path: "/",
+				RequestContext: events.APIGatewayProxyRequestContext{
+					Identity: events.APIGatewayRequestIdentity{SourceIP: "203.0.113.1"},
+				},
+			},
+			want: "203.0.113.1,https,443",
+		},
+		{
+			request: events.APIGatewayProxyRequest{
+				Path: "/",
+				Headers: map[string]string{
+					"x-forwarded-for":   "198.51.100.1, 203.0.113.1",
+					"x-forwarded-proto": "http",
+					"x-forwarded-port":  "80",
+				},
+				RequestContext: events.APIGatewayProxyRequestContext{
+					Identity: events.APIGatewayRequestIdentity{SourceIP: "203.0.113.1"},
+				},
+			},
+			want: "198.51.100.1, 203.0.113.1,http,80",
+		},
+	}
+	for i, tt := range tests {
+		handler := apiGatewayHandler(h, newOptions(nil))
+		response, err := handler(context.Background(), tt.request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestForwardedHeader(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Forwarded")))
+	})
+	tests := []struct {
+		opts    []Option
+		request events.APIGatewayProxyRequest
+		want    string
+	}{
+		{
+			request: events.APIGatewayProxyRequest{Path: "/"},
+			want:    "",
+		},
+		{
+			opts: []Option{WithForwardedHeader()},
+			request: events.APIGatewayProxyRequest{
+				Path:    "/",
+				Headers: map[string]string{"Host": "api.example.com"},
+				RequestContext: events.APIGatewayProxyRequestContext{
+					APIID:    "abc123",
+					Identity: events.APIGatewayRequestIdentity{SourceIP: "203.0.113.1"},
+				},
+			},
+			want: "for=203.0.113.1;proto=https;host=api.example.com;by=_abc123",
+		},
+		{
+			opts: []Option{WithForwardedHeader()},
+			request: events.APIGatewayProxyRequest{
+				Path: "/",
+				RequestContext: events.APIGatewayProxyRequestContext{
+					DomainName: "localhost:3000",
+					Identity:   events.APIGatewayRequestIdentity{SourceIP: "2001:db8::1"},
+				},
+			},
+			want: `for="[2001:db8::1]";proto=https;host="localhost:3000"`,
+		},
+		{
+			opts: []Option{WithForwardedHeader()},
+			request: events.APIGatewayProxyRequest{
+				Path:    "/",
+				Headers: map[string]string{"Forwarded": "for=198.51.100.1"},
+			},
+			want: "for=198.51.100.1",
+		},
+	}
+	for i, tt := range tests {
+		handler := apiGatewayHandler(h, newOptions(tt.opts))
+		response, err := handler(context.Background(), tt.request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestWithURLScheme(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.String() + " " + r.RequestURI))
+	})
+	tests := []struct {
+		opts    []Option
+		request events.APIGatewayProxyRequest
+		want    string
+	}{
+		{
+			request: events.APIGatewayProxyRequest{
+				Path:    "/a",
+				Headers: map[string]string{"Host": "api.example.com"},
+			},
+			want: "/a /a",
+		},
+		{
+			opts: []Option{WithURLScheme()},
+			request: events.APIGatewayProxyRequest{
+				Path:                  "/a",
+				Headers:               map[string]string{"Host": "api.example.com"},
+				QueryStringParameters: map[string]string{"q": "1"},
+			},
+			want: "https://api.example.com/a?q=1 /a?q=1",
+		},
+		{
+			opts: []Option{WithURLScheme()},
+			request: events.APIGatewayProxyRequest{
+				Path: "/a",
+				Headers: map[string]string{
+					"Host":              "api.example.com",
+					"X-Forwarded-Proto": "HTTP, https",
+				},
+			},
+			want: "http://api.example.com/a /a",
+		},
+		{
+			opts: []Option{WithURLScheme()},
+			request: events.APIGatewayProxyRequest{
+				Path:    "/a",
+				Headers: map[string]string{"X-Forwarded-Proto": "wss"},
+				RequestContext: events.APIGatewayProxyRequestContext{
+					DomainName: "abc123.execute-api.us-east-1.amazonaws.com",
+				},
+			},
+			want: "https://abc123.execute-api.us-east-1.amazonaws.com/a /a",
+		},
+	}
+	for i, tt := range tests {
+		handler := apiGatewayHandler(h, newOptions(tt.opts))
+		response, err := handler(context.Background(), tt.request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
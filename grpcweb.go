@@ -0,0 +1,63 @@
+package apigatewayproxy
+
+import (
+	"encoding/binary"
+	"net/http"
+	"strings"
+)
+
+// grpcWebContentType is the media type of binary gRPC-Web messages. The
+// "application/grpc-web-text" media type is already base64 encoded, and is
+// returned as a string like other text.
+const grpcWebContentType = "application/grpc-web"
+
+// grpcWebTrailerFlag is the flag byte of a gRPC-Web trailers frame.
+const grpcWebTrailerFlag = 0x80
+
+// isGRPCWeb reports whether the content type is a binary gRPC-Web media
+// type, such as "application/grpc-web" or "application/grpc-web+proto".
+func isGRPCWeb(contentType string) bool {
+	mediaType := contentType
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	return mediaType == grpcWebContentType || strings.HasPrefix(mediaType, grpcWebContentType+"+")
+}
+
+// writeGRPCWebTrailers appends the trailers set by the handler to the body
+// as a gRPC-Web trailers frame, because API Gateway cannot return HTTP
+// trailers. Handlers that already write the trailers frame, such as gRPC
+// servers wrapped for gRPC-Web, do not set HTTP trailers, and their body is
+// returned unchanged.
+func (w *responseWriter) writeGRPCWebTrailers() {
+	names := trailerNames(w.header)
+	if len(names) == 0 {
+		return
+	}
+	var trailers strings.Builder
+	for _, name := range names {
+		for _, key := range []string{name, http.TrailerPrefix + name} {
+			for _, value := range w.header[key] {
+				trailers.WriteString(strings.ToLower(name))
+				trailers.WriteString(": ")
+				trailers.WriteString(value)
+				trailers.WriteString("\r\n")
+			}
+		}
+		w.deleteResponseHeader(http.TrailerPrefix + name)
+	}
+	w.deleteResponseHeader("Trailer")
+	if trailers.Len() == 0 {
+		return
+	}
+	var prefix [5]byte
+	prefix[0] = grpcWebTrailerFlag
+	binary.BigEndian.PutUint32(prefix[1:], uint32(trailers.Len()))
+	if w.body == nil {
+		w.body = getBuffer(w.opts.bufferPool)
+	}
+	w.body.Write(prefix[:])
+	w.body.WriteString(trailers.String())
+	w.binary = true
+}
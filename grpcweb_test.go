@@ -0,0 +1,95 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestIsGRPCWeb(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/grpc-web", true},
+		{"application/grpc-web+proto", true},
+		{"Application/GRPC-Web+json; charset=utf-8", true},
+		{"application/grpc-web-text", false},
+		{"application/grpc-web-text+proto", false},
+		{"application/grpc", false},
+		{"", false},
+	}
+	for i, tt := range tests {
+		if got := isGRPCWeb(tt.contentType); got != tt.want {
+			t.Errorf("%d: %q: got %v, want %v", i, tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestGRPCWeb(t *testing.T) {
+	// a data frame containing a message that happens to be text
+	message := "\x00\x00\x00\x00\x05hello"
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wrapped":
+			w.Header().Set("Content-Type", "application/grpc-web+proto")
+			w.Header().Set("Grpc-Status", "0")
+			w.Write([]byte("hello"))
+		case "/trailers":
+			w.Header().Set("Content-Type", "application/grpc-web+proto")
+			w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+			w.Write([]byte(message))
+			w.Header().Set("Grpc-Status", "3")
+			w.Header().Set("Grpc-Message", "invalid")
+			w.Header().Set(http.TrailerPrefix+"X-Extra", "extra")
+		case "/text":
+			w.Header().Set("Content-Type", "application/grpc-web-text+proto")
+			w.Write([]byte("AAAAAAVoZWxsbw=="))
+		}
+	})
+	tests := []struct {
+		path      string
+		want      string
+		base64    bool
+		header    string
+		hasHeader bool
+	}{
+		{path: "/wrapped", want: "hello", base64: true, header: "Grpc-Status", hasHeader: true},
+		{
+			path:   "/trailers",
+			want:   message + "\x80\x00\x00\x00\x37grpc-message: invalid\r\ngrpc-status: 3\r\nx-extra: extra\r\n",
+			base64: true,
+			header: "Trailer",
+		},
+		{path: "/text", want: "AAAAAAVoZWxsbw==", base64: false},
+	}
+	handler := apiGatewayHandler(h, newOptions([]Option{WithPlainTextStatus(2), WithStrict(UnsupportedAll)}))
+	for i, tt := range tests {
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "POST",
+			Path:       tt.path,
+		})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.IsBase64Encoded, tt.base64; got != want {
+			t.Errorf("%d: got IsBase64Encoded %v, want %v", i, got, want)
+		}
+		body := response.Body
+		if response.IsBase64Encoded {
+			b, _ := base64.StdEncoding.DecodeString(body)
+			body = string(b)
+		}
+		if got, want := body, tt.want; got != want {
+			t.Errorf("%d: got body %q, want %q", i, got, want)
+		}
+		if tt.header != "" {
+			if _, got := response.Headers[tt.header]; got != tt.hasHeader {
+				t.Errorf("%d: got %s header %v, want %v", i, tt.header, got, tt.hasHeader)
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// WithOriginalHeaderCase configures the adapter to preserve the casing of
+// header names in the response. When a response header has the same name as
+// a request header, the response header uses the name exactly as it was
+// received from API Gateway, so a request header "x-api-key" is returned as
+// "x-api-key" rather than "X-Api-Key".
+//
+// Handlers that need a specific casing for other response headers can
+// assign directly to the header map, which bypasses canonicalization:
+//
+//	w.Header()["x-correlation-id"] = []string{id}
+func WithOriginalHeaderCase() Option {
+	return func(o *options) {
+		o.originalHeaderCase = true
+	}
+}
+
+// HeaderName returns the name of the request header exactly as it was
+// received from API Gateway. The request headers are always accessed using
+// canonical names, so use this function when the original casing matters.
+// If the header was not present in the event, or the request was not
+// created by this package, the canonical form of name is returned.
+func HeaderName(r *http.Request, name string) string {
+	canonical := textproto.CanonicalMIMEHeaderKey(name)
+	inv, _ := r.Context().Value(ctxKeyInvocation).(*invocation)
+	if inv == nil {
+		return canonical
+	}
+	for k := range inv.request.Headers {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	for k := range inv.request.MultiValueHeaders {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return canonical
+}
+
+// originalHeaderNames returns a map of canonical header name to original
+// header name for each request header whose name is not in canonical form.
+func originalHeaderNames(request *events.APIGatewayProxyRequest) map[string]string {
+	var names map[string]string
+	add := func(name string) {
+		canonical := textproto.CanonicalMIMEHeaderKey(name)
+		if canonical == name {
+			return
+		}
+		if names == nil {
+			names = make(map[string]string)
+		}
+		names[canonical] = name
+	}
+	for k := range request.Headers {
+		add(k)
+	}
+	for k := range request.MultiValueHeaders {
+		add(k)
+	}
+	return names
+}
+
+// renameHeaders replaces canonical response header names with the
+// original request header names. It is called after the response is
+// complete, because the response writer looks up headers by canonical name.
+func (w *responseWriter) renameHeaders() {
+	for canonical, name := range w.headerNames {
+		if v, ok := w.response2.Headers[canonical]; ok {
+			delete(w.response2.Headers, canonical)
+			w.response2.Headers[name] = v
+		}
+		if vv, ok := w.response2.MultiValueHeaders[canonical]; ok {
+			delete(w.response2.MultiValueHeaders, canonical)
+			w.response2.MultiValueHeaders[name] = vv
+		}
+		if w.response2.MultiValueHeaders == nil {
+			// response.Headers shares the same map
+			continue
+		}
+		if v, ok := w.response.Headers[canonical]; ok {
+			delete(w.response.Headers, canonical)
+			w.response.Headers[name] = v
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestOriginalHeaderCase(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Api-Key", r.Header.Get("X-Api-Key"))
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(HeaderName(r, "X-API-KEY")))
+	})
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/",
+		Headers:    map[string]string{"x-api-key": "secret"},
+	}
+	tests := []struct {
+		opts      []Option
+		wantKey   string
+		wantOther string
+	}{
+		{
+			wantKey:   "X-Api-Key",
+			wantOther: "x-api-key",
+		},
+		{
+			opts:      []Option{WithOriginalHeaderCase()},
+			wantKey:   "x-api-key",
+			wantOther: "X-Api-Key",
+		},
+	}
+	for i, tt := range tests {
+		handler := apiGatewayHandler(h, newOptions(tt.opts))
+		response, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Headers[tt.wantKey], "secret"; got != want {
+			t.Errorf("%d: got %q=%q, want %q", i, tt.wantKey, got, want)
+		}
+		if _, ok := response.Headers[tt.wantOther]; ok {
+			t.Errorf("%d: got unexpected header %q", i, tt.wantOther)
+		}
+		if got, want := response.Headers["Content-Type"], "text/plain"; got != want {
+			t.Errorf("%d: got content type %q, want %q", i, got, want)
+		}
+		if got, want := response.Body, "x-api-key"; got != want {
+			t.Errorf("%d: got header name %q, want %q", i, got, want)
+		}
+	}
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	if got, want := HeaderName(r, "x-api-key"), "X-Api-Key"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,292 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// processStarted is the time the process started, used to report uptime
+	processStarted = time.Now()
+
+	// requestCount is the number of requests received by handlers
+	// returned by Handler, used to detect a cold start
+	requestCount int64
+)
+
+// WithHealthCheck configures the adapter to respond to requests for path
+// directly, without calling the HTTP handler. If path is empty, the
+// default path "/healthz" is used.
+//
+// The health check response is a JSON object containing the process uptime,
+// whether the request is the first request received by the process (a cold
+// start when running in Lambda), and whether the handler is ready. If the
+// handler has a method "Ready() bool", it is called to determine readiness,
+// and a status of 503 is returned if the handler is not ready. The checks
+// added using WithReadinessCheck are also run, and their results included
+// in the response.
+//
+// The health check can also keep a Lambda function warm: configure an
+// EventBridge schedule for the function and use WithScheduledPath to send
+// the scheduled events to the health check path, so that the checks keep
+// connections to dependencies open and report failures in the logs.
+func WithHealthCheck(path string) Option {
+	if path == "" {
+		path = "/healthz"
+	}
+	return func(o *options) {
+		o.healthCheckPath = path
+	}
+}
+
+// health is the body of the health check response
+type health struct {
+	Status        string  `json:"status"`
+	Uptime        string  `json:"uptime"`
+	UptimeSeconds float64 `json:"uptimeSeconds"`
+	ColdStart     bool    `json:"coldStart"`
+	Ready         bool    `json:"ready"`
+
+	Checks map[string]*checkResult `json:"checks,omitempty"`
+}
+
+// countRequests counts each request received so that a cold start can be detected.
+func countRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Default paths for the liveness and readiness probes.
+const (
+	DefaultLivenessPath  = "/livez"
+	DefaultReadinessPath = "/readyz"
+)
+
+// WithProbes configures the adapter to respond to separate liveness and
+// readiness probes, as used by Kubernetes and ECS, so that the same binary
+// behaves correctly when deployed as a container. If a path is empty, the
+// default is used. The probes are answered directly, without calling the
+// HTTP handler, and can be used alongside WithHealthCheck.
+//
+// The liveness probe reports that the process is able to respond to
+// requests, and always has a 200 status. It does not depend on the handler
+// or its dependencies, so that an unavailable database does not cause the
+// orchestrator to restart the process.
+//
+// The readiness probe reports whether the process should receive traffic.
+// It has a 503 status if the handler has a "Ready() bool" method that
+// returns false, or if any check added using WithReadinessCheck fails.
+// Because the functions added using WithPreload are called before the
+// handler is created, the readiness probe only succeeds once they have
+// completed.
+func WithProbes(livenessPath, readinessPath string) Option {
+	if livenessPath == "" {
+		livenessPath = DefaultLivenessPath
+	}
+	if readinessPath == "" {
+		readinessPath = DefaultReadinessPath
+	}
+	return func(o *options) {
+		o.livenessPath = livenessPath
+		o.readinessPath = readinessPath
+	}
+}
+
+// Checker checks a dependency of the handler, such as a database or a
+// downstream API, and returns an error if it is not available.
+type Checker func(ctx context.Context) error
+
+// DefaultCheckTimeout is the default time allowed for each Checker.
+const DefaultCheckTimeout = 2 * time.Second
+
+// WithReadinessCheck adds a named check of a dependency, such as a database
+// ping, to the readiness probe configured using WithProbes and the health
+// check configured using WithHealthCheck, in AWS Lambda and when running
+// as a conventional HTTP server. The response fails if any check returns
+// an error. The checks are called concurrently for each request, with a
+// context derived from the request context that is cancelled after the
+// timeout set by WithCheckTimeout.
+//
+// The response reports the result of each check by name, for example:
+//
+//	"checks": {
+//	  "db": {"status": "ok", "durationMs": 3},
+//	  "payments": {"status": "failed", "error": "context deadline exceeded", "durationMs": 2000}
+//	}
+func WithReadinessCheck(name string, check Checker) Option {
+	return func(o *options) {
+		o.readinessChecks = append(o.readinessChecks, readinessCheck{name: name, check: check})
+	}
+}
+
+// WithCheckTimeout sets the time allowed for each check added using
+// WithReadinessCheck. A check that takes longer fails, even if it ignores
+// the cancellation of its context. The default is DefaultCheckTimeout.
+func WithCheckTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.checkTimeout = timeout
+	}
+}
+
+// readinessCheck is a check added using WithReadinessCheck.
+type readinessCheck struct {
+	name  string
+	check Checker
+}
+
+// checkResult is the result of a readiness check in a health response.
+type checkResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"durationMs"`
+}
+
+// runChecks calls the checks concurrently, and returns the result of each
+// check and whether all of the checks succeeded.
+func runChecks(ctx context.Context, checks []readinessCheck, timeout time.Duration) (map[string]*checkResult, bool) {
+	if len(checks) == 0 {
+		return nil, true
+	}
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	results := make(map[string]*checkResult, len(checks))
+	for _, c := range checks {
+		results[c.name] = &checkResult{}
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok := true
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c readinessCheck, result *checkResult) {
+			defer wg.Done()
+			start := time.Now()
+			err := callCheck(ctx, c.check, timeout)
+			mu.Lock()
+			defer mu.Unlock()
+			result.DurationMS = time.Since(start).Milliseconds()
+			result.Status = "ok"
+			if err != nil {
+				result.Status, result.Error = "failed", err.Error()
+				ok = false
+				Logger(ctx).Warn("readiness check failed", "check", c.name, "error", err)
+			}
+		}(c, results[c.name])
+	}
+	wg.Wait()
+	return results, ok
+}
+
+// callCheck calls check, and returns context.DeadlineExceeded if it does
+// not return within the timeout.
+func callCheck(ctx context.Context, check Checker, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// probe is the body of the liveness and readiness probe responses.
+type probe struct {
+	Status        string                  `json:"status"`
+	Uptime        string                  `json:"uptime"`
+	UptimeSeconds float64                 `json:"uptimeSeconds"`
+	Checks        map[string]*checkResult `json:"checks,omitempty"`
+}
+
+// readyFunc returns the "Ready() bool" method of h, or a function that always
+// reports ready if h does not have one.
+func readyFunc(h http.Handler) func() bool {
+	if r, ok := h.(interface{ Ready() bool }); ok {
+		return r.Ready
+	}
+	return func() bool { return true }
+}
+
+// healthCheckHandler responds to requests for the health check and probe
+// paths, and passes all other requests to h. The ready function reports
+// the readiness of the HTTP handler.
+func healthCheckHandler(h http.Handler, ready func() bool, o *options) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var status int
+		var body interface{}
+		switch r.URL.Path {
+		case "":
+			// the paths that are not configured are empty
+			h.ServeHTTP(w, r)
+			return
+		case o.healthCheckPath:
+			status, body = healthCheck(r.Context(), ready, o)
+		case o.livenessPath:
+			status, body = livenessProbe()
+		case o.readinessPath:
+			status, body = readinessProbe(r.Context(), ready, o)
+		default:
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		if r.Method != http.MethodHead {
+			json.NewEncoder(w).Encode(body)
+		}
+	})
+}
+
+// healthCheck returns the status and body of the health check response.
+func healthCheck(ctx context.Context, ready func() bool, o *options) (int, *health) {
+	uptime := time.Since(processStarted)
+	body := &health{
+		Status:        "ok",
+		Uptime:        uptime.Round(time.Millisecond).String(),
+		UptimeSeconds: uptime.Seconds(),
+		ColdStart:     atomic.LoadInt64(&requestCount) <= 1,
+		Ready:         ready(),
+	}
+	checks, ok := runChecks(ctx, o.readinessChecks, o.checkTimeout)
+	body.Checks = checks
+	body.Ready = body.Ready && ok
+	if !body.Ready {
+		body.Status = "unavailable"
+		return http.StatusServiceUnavailable, body
+	}
+	return http.StatusOK, body
+}
+
+// livenessProbe returns the status and body of the liveness probe response.
+func livenessProbe() (int, *probe) {
+	uptime := time.Since(processStarted)
+	return http.StatusOK, &probe{
+		Status:        "ok",
+		Uptime:        uptime.Round(time.Millisecond).String(),
+		UptimeSeconds: uptime.Seconds(),
+	}
+}
+
+// readinessProbe returns the status and body of the readiness probe
+// response, running the readiness checks.
+func readinessProbe(ctx context.Context, ready func() bool, o *options) (int, *probe) {
+	status, body := livenessProbe()
+	checks, ok := runChecks(ctx, o.readinessChecks, o.checkTimeout)
+	body.Checks = checks
+	if !ready() || !ok {
+		status, body.Status = http.StatusServiceUnavailable, "unavailable"
+	}
+	return status, body
+}
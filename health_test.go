@@ -0,0 +1,240 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type readyHandler bool
+
+func (h readyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("handler"))
+}
+
+func (h readyHandler) Ready() bool {
+	return bool(h)
+}
+
+func TestHealthCheck(t *testing.T) {
+	tests := []struct {
+		handler http.Handler
+		opts    []Option
+		path    string
+		status  int
+		ready   bool
+		body    string
+	}{
+		{
+			handler: readyHandler(true),
+			opts:    []Option{WithHealthCheck("")},
+			path:    "/healthz",
+			status:  http.StatusOK,
+			ready:   true,
+		},
+		{
+			handler: readyHandler(false),
+			opts:    []Option{WithHealthCheck("")},
+			path:    "/healthz",
+			status:  http.StatusServiceUnavailable,
+			ready:   false,
+		},
+		{
+			// readiness of the handler wrapped by other middleware
+			handler: readyHandler(false),
+			opts:    []Option{WithHealthCheck(""), WithDebug("/debug", "secret")},
+			path:    "/healthz",
+			status:  http.StatusServiceUnavailable,
+			ready:   false,
+		},
+		{
+			handler: readyHandler(false),
+			opts:    []Option{WithHealthCheck(""), WithDebug("/debug", "secret"), WithoutContentTypeSniffing()},
+			path:    "/healthz",
+			status:  http.StatusServiceUnavailable,
+			ready:   false,
+		},
+		{
+			handler: http.NotFoundHandler(),
+			opts:    []Option{WithHealthCheck("/ping")},
+			path:    "/ping",
+			status:  http.StatusOK,
+			ready:   true,
+		},
+		{
+			handler: readyHandler(true),
+			opts:    []Option{WithHealthCheck("")},
+			path:    "/other",
+			status:  http.StatusOK,
+			body:    "handler",
+		},
+		{
+			// no health check unless configured
+			handler: readyHandler(true),
+			path:    "/healthz",
+			status:  http.StatusOK,
+			body:    "handler",
+		},
+	}
+
+	for i, tt := range tests {
+		h := Handler(tt.handler, tt.opts...)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if got, want := w.Code, tt.status; got != want {
+			t.Errorf("%d: got=%d, want=%d", i, got, want)
+		}
+		if tt.body != "" {
+			if got, want := w.Body.String(), tt.body; got != want {
+				t.Errorf("%d: got=%q, want=%q", i, got, want)
+			}
+			continue
+		}
+		var body health
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Errorf("%d: got %v, want no error", i, err)
+			continue
+		}
+		if got, want := body.Ready, tt.ready; got != want {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+		if body.Uptime == "" {
+			t.Errorf("%d: got empty uptime", i)
+		}
+	}
+}
+
+func TestProbes(t *testing.T) {
+	var dbErr error
+	opts := []Option{
+		WithProbes("", ""),
+		WithReadinessCheck("db", func(ctx context.Context) error { return dbErr }),
+	}
+	tests := []struct {
+		handler http.Handler
+		dbErr   error
+		path    string
+		status  int
+		checks  map[string]string // check name to status or error
+	}{
+		{
+			handler: readyHandler(true),
+			path:    "/livez",
+			status:  http.StatusOK,
+		},
+		{
+			handler: readyHandler(false),
+			dbErr:   errors.New("connection refused"),
+			path:    "/livez",
+			status:  http.StatusOK,
+		},
+		{
+			handler: readyHandler(true),
+			path:    "/readyz",
+			status:  http.StatusOK,
+			checks:  map[string]string{"db": "ok"},
+		},
+		{
+			handler: readyHandler(true),
+			dbErr:   errors.New("connection refused"),
+			path:    "/readyz",
+			status:  http.StatusServiceUnavailable,
+			checks:  map[string]string{"db": "connection refused"},
+		},
+		{
+			handler: readyHandler(false),
+			path:    "/readyz",
+			status:  http.StatusServiceUnavailable,
+			checks:  map[string]string{"db": "ok"},
+		},
+	}
+	for i, tt := range tests {
+		dbErr = tt.dbErr
+		w := httptest.NewRecorder()
+		Handler(tt.handler, opts...).ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if got, want := w.Code, tt.status; got != want {
+			t.Errorf("%d: got=%d, want=%d", i, got, want)
+		}
+		var body probe
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Errorf("%d: got %v, want no error", i, err)
+			continue
+		}
+		if got, want := checkStatuses(body.Checks), tt.checks; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+	}
+
+	// the handler is called for other paths, including the health check
+	w := httptest.NewRecorder()
+	Handler(readyHandler(true), opts...).ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+	if got, want := w.Body.String(), "handler"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestReadinessCheckTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	h := Handler(readyHandler(true),
+		WithHealthCheck(""),
+		WithCheckTimeout(20*time.Millisecond),
+		WithReadinessCheck("cache", func(ctx context.Context) error { return nil }),
+		WithReadinessCheck("api", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+		WithReadinessCheck("stuck", func(ctx context.Context) error {
+			// ignores the context
+			<-release
+			return nil
+		}),
+	)
+	start := time.Now()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("got %v, want checks to time out", elapsed)
+	}
+	if got, want := w.Code, http.StatusServiceUnavailable; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	var body health
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"cache": "ok",
+		"api":   context.DeadlineExceeded.Error(),
+		"stuck": context.DeadlineExceeded.Error(),
+	}
+	if got := checkStatuses(body.Checks); !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if body.Ready {
+		t.Error("got ready, want not ready")
+	}
+	if got := body.Checks["api"].DurationMS; got < 20 {
+		t.Errorf("got duration %dms, want at least 20ms", got)
+	}
+}
+
+// checkStatuses returns the status of each check, or its error.
+func checkStatuses(checks map[string]*checkResult) map[string]string {
+	if checks == nil {
+		return nil
+	}
+	statuses := make(map[string]string, len(checks))
+	for name, c := range checks {
+		statuses[name] = c.Status
+		if c.Error != "" {
+			statuses[name] = c.Error
+		}
+	}
+	return statuses
+}
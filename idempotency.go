@@ -0,0 +1,148 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyHeader is the request header that contains the key
+// identifying retries of the same request.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is the response header that is set to "true"
+// when the response is a stored response to a previous request.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// idempotencyDigestHeader is the header of a stored response that contains
+// the SHA-256 digest of the request body. It is not sent to clients.
+const idempotencyDigestHeader = "Idempotency-Request-Digest"
+
+// DefaultIdempotencyTTL is the default time that responses are kept for
+// idempotency.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// WithIdempotency configures the adapter to store the response to each
+// request that has an IdempotencyKeyHeader header, and to return the stored
+// response when a request with the same key is received again, instead of
+// calling the handler. Requests without the header are keyed on the request
+// ID assigned by API Gateway, which is the same when API Gateway retries a
+// request. Responses are kept in store for ttl. If ttl is zero or negative,
+// DefaultIdempotencyTTL is used.
+//
+// Keys are scoped to the caller: the authenticated principal, the API key,
+// or the Authorization header, so that clients cannot receive the responses
+// to each other's requests by reusing a key. A request with the same key as
+// a previous request but a different body receives a 422 (Unprocessable
+// Entity) response.
+//
+// Only requests with methods that are not safe, such as POST and PATCH, are
+// checked. Responses with a 5xx status code are not stored, so that the
+// client can retry after a server error. Requests with the same key that
+// arrive while the first request is still being handled are not detected.
+func WithIdempotency(store ResponseStore, ttl time.Duration) Option {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return func(o *options) {
+		o.idempotencyStore = store
+		o.idempotencyTTL = ttl
+	}
+}
+
+// idempotencyHandler returns stored responses for retried requests.
+func idempotencyHandler(h http.Handler, o *options) http.Handler {
+	store, ttl := o.idempotencyStore, o.idempotencyTTL
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := idempotencyKey(r)
+		if key == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			Logger(ctx).Warn("cannot read request body", "error", err)
+			WriteError(w, r, http.StatusBadRequest, "cannot read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		bodyDigest := sha256Hex(body)
+
+		stored, err := store.Get(ctx, key)
+		if err != nil {
+			// fail open: handling the request is better than failing it
+			Logger(ctx).Warn("cannot get idempotent response", "key", key, "error", err)
+		}
+		if stored != nil {
+			if stored.Header.Get(idempotencyDigestHeader) != bodyDigest {
+				WriteError(w, r, http.StatusUnprocessableEntity, "idempotency key was used for a different request body")
+				return
+			}
+			replayed := *stored
+			replayed.Header = stored.Header.Clone()
+			replayed.Header.Del(idempotencyDigestHeader)
+			w.Header().Set(IdempotentReplayedHeader, "true")
+			writeStoredResponse(w, &replayed)
+			return
+		}
+		rw := &recordingWriter{ResponseWriter: w}
+		h.ServeHTTP(rw, r)
+		if response := rw.response(); response.StatusCode < 500 {
+			response.Header.Set(idempotencyDigestHeader, bodyDigest)
+			if err := store.Put(ctx, key, response, ttl); err != nil {
+				Logger(ctx).Warn("cannot store idempotent response", "key", key, "error", err)
+			}
+		}
+	})
+}
+
+// idempotencyKey returns the key for storing the response to r, or an
+// empty string if the response should not be stored.
+func idempotencyKey(r *http.Request) string {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return ""
+	}
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		if info := Info(r.Context()); info != nil {
+			key = info.RequestID
+		}
+		if key == "" {
+			return ""
+		}
+		key = "request:" + key
+	} else {
+		key = "key:" + key
+	}
+	// the same key used for a different request or by a different caller
+	// is a different request
+	return r.Method + " " + r.URL.Path + " " + key + idempotencyScope(r)
+}
+
+// idempotencyScope returns the caller that the idempotency key of r is
+// scoped to, or an empty string for anonymous requests. Credentials are
+// hashed so that they are not kept in the store.
+func idempotencyScope(r *http.Request) string {
+	if info := Info(r.Context()); info != nil && info.Principal != "" {
+		return " principal:" + info.Principal
+	}
+	if request := Request(r.Context()); request != nil && request.RequestContext.Identity.APIKey != "" {
+		return " apikey:" + sha256Hex([]byte(request.RequestContext.Identity.APIKey))
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		return " authorization:" + sha256Hex([]byte(auth))
+	}
+	return ""
+}
+
+// sha256Hex returns the hex encoded SHA-256 digest of b.
+func sha256Hex(b []byte) string {
+	digest := sha256.Sum256(b)
+	return hex.EncodeToString(digest[:])
+}
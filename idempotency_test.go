@@ -0,0 +1,134 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "call %d", calls)
+	})
+	opts := newOptions([]Option{WithIdempotency(NewMemoryResponseStore(), 0)})
+	handler := apiGatewayHandler(opts.wrap(h), opts)
+
+	tests := []struct {
+		method       string
+		path         string
+		key          string
+		requestID    string
+		wantBody     string
+		wantReplayed string
+	}{
+		{method: "POST", path: "/orders", key: "k1", wantBody: "call 1"},
+		{method: "POST", path: "/orders", key: "k1", wantBody: "call 1", wantReplayed: "true"},
+		{method: "POST", path: "/orders", key: "k2", wantBody: "call 2"},
+		{method: "POST", path: "/other", key: "k1", wantBody: "call 3"},
+		{method: "GET", path: "/orders", key: "k1", wantBody: "call 4"},
+		{method: "POST", path: "/orders", requestID: "r1", wantBody: "call 5"},
+		{method: "POST", path: "/orders", requestID: "r1", wantBody: "call 5", wantReplayed: "true"},
+		{method: "POST", path: "/orders", wantBody: "call 6"},
+		{method: "POST", path: "/orders", wantBody: "call 7"},
+		{method: "POST", path: "/fail", key: "k1"},
+		{method: "POST", path: "/fail", key: "k1"},
+	}
+	for i, tt := range tests {
+		request := events.APIGatewayProxyRequest{
+			HTTPMethod:     tt.method,
+			Path:           tt.path,
+			RequestContext: events.APIGatewayProxyRequestContext{RequestID: tt.requestID},
+		}
+		if tt.key != "" {
+			request.Headers = map[string]string{"Idempotency-Key": tt.key}
+		}
+		response, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := response.Body, tt.wantBody; got != want {
+			t.Errorf("%d: got body %q, want %q", i, got, want)
+		}
+		if got, want := response.Headers[IdempotentReplayedHeader], tt.wantReplayed; got != want {
+			t.Errorf("%d: got replayed %q, want %q", i, got, want)
+		}
+	}
+	if got, want := calls, 9; got != want {
+		t.Errorf("got %d calls, want %d", got, want)
+	}
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	var calls int
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "call %d: %s", calls, body)
+	})
+	opts := newOptions([]Option{WithIdempotency(NewMemoryResponseStore(), 0)})
+	handler := apiGatewayHandler(opts.wrap(h), opts)
+
+	tests := []struct {
+		principal  string
+		apiKey     string
+		auth       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{body: "a", wantStatus: http.StatusOK, wantBody: "call 1: a"},
+		{body: "a", wantStatus: http.StatusOK, wantBody: "call 1: a"},
+		{body: "b", wantStatus: http.StatusUnprocessableEntity},
+		{principal: "alice", body: "a", wantStatus: http.StatusOK, wantBody: "call 2: a"},
+		{principal: "alice", body: "a", wantStatus: http.StatusOK, wantBody: "call 2: a"},
+		{principal: "bob", body: "a", wantStatus: http.StatusOK, wantBody: "call 3: a"},
+		{apiKey: "key1", body: "a", wantStatus: http.StatusOK, wantBody: "call 4: a"},
+		{apiKey: "key2", body: "a", wantStatus: http.StatusOK, wantBody: "call 5: a"},
+		{auth: "Bearer 1", body: "a", wantStatus: http.StatusOK, wantBody: "call 6: a"},
+		{auth: "Bearer 1", body: "a", wantStatus: http.StatusOK, wantBody: "call 6: a"},
+		{auth: "Bearer 2", body: "a", wantStatus: http.StatusOK, wantBody: "call 7: a"},
+		{auth: "Bearer 2", body: "c", wantStatus: http.StatusUnprocessableEntity},
+	}
+	for i, tt := range tests {
+		request := events.APIGatewayProxyRequest{
+			HTTPMethod: "POST",
+			Path:       "/orders",
+			Headers:    map[string]string{"Idempotency-Key": "k1"},
+			Body:       tt.body,
+		}
+		if tt.principal != "" {
+			request.RequestContext.Authorizer = map[string]interface{}{"principalId": tt.principal}
+		}
+		request.RequestContext.Identity.APIKey = tt.apiKey
+		if tt.auth != "" {
+			request.Headers["Authorization"] = tt.auth
+		}
+		response, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := response.StatusCode, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if tt.wantBody != "" {
+			if got, want := response.Body, tt.wantBody; got != want {
+				t.Errorf("%d: got body %q, want %q", i, got, want)
+			}
+		}
+		if _, ok := response.Headers[idempotencyDigestHeader]; ok {
+			t.Errorf("%d: got %s header, want none", i, idempotencyDigestHeader)
+		}
+	}
+}
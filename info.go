@@ -0,0 +1,168 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RequestInfo contains information about the event that caused the HTTP
+// request, independent of the type of event. It allows middleware to log
+// and authorize requests without depending on the event types.
+type RequestInfo struct {
+	Method    string // HTTP method of the request passed to the handler
+	RawPath   string // path as received in the event, before unescaping
+	Stage     string // API Gateway stage, if any
+	Route     string // resource path, route key, topic or rule that matched the event
+	RequestID string // request ID assigned by the event source
+	SourceIP  string // IP address of the client, if known
+	Principal string // authenticated principal, if any
+}
+
+// Info returns information about the event associated with ctx, or nil if
+// ctx is not associated with an event received by this package.
+func Info(ctx context.Context) *RequestInfo {
+	if request := Request(ctx); request != nil {
+		rc := &request.RequestContext
+		return &RequestInfo{
+			Method:    request.HTTPMethod,
+			RawPath:   request.Path,
+			Stage:     rc.Stage,
+			Route:     rc.ResourcePath,
+			RequestID: rc.RequestID,
+			SourceIP:  rc.Identity.SourceIP,
+			Principal: principal(rc.Authorizer, rc.Identity.UserArn),
+		}
+	}
+	if request := RequestV2(ctx); request != nil {
+		rc := &request.RequestContext
+		return &RequestInfo{
+			Method:    rc.HTTP.Method,
+			RawPath:   request.RawPath,
+			Stage:     rc.Stage,
+			Route:     rc.RouteKey,
+			RequestID: rc.RequestID,
+			SourceIP:  rc.HTTP.SourceIP,
+			Principal: principalV2(rc.Authorizer),
+		}
+	}
+	if request := ALBRequest(ctx); request != nil {
+		info := &RequestInfo{
+			Method:  request.HTTPMethod,
+			RawPath: request.Path,
+			Route:   request.RequestContext.ELB.TargetGroupArn,
+		}
+		if inv, ok := ctx.Value(ctxKeyInvocation).(*invocation); ok {
+			for k, v := range inv.request.Headers {
+				if strings.EqualFold(k, "X-Amzn-Trace-Id") {
+					info.RequestID = v
+				}
+			}
+			info.SourceIP = inv.request.RequestContext.Identity.SourceIP
+		}
+		return info
+	}
+	if request := WebSocketRequest(ctx); request != nil {
+		rc := &request.RequestContext
+		info := &RequestInfo{
+			Stage:     rc.Stage,
+			Route:     rc.RouteKey,
+			RequestID: rc.RequestID,
+			SourceIP:  rc.Identity.SourceIP,
+			Principal: principal(rc.Authorizer, rc.Identity.UserArn),
+		}
+		if inv, ok := ctx.Value(ctxKeyInvocation).(*invocation); ok {
+			// the method and path of the synthesized request
+			info.Method = inv.request.HTTPMethod
+			info.RawPath = inv.request.Path
+		}
+		return info
+	}
+	if request := AuthorizerRequest(ctx); request != nil {
+		rc := &request.RequestContext
+		return &RequestInfo{
+			Method:    request.HTTPMethod,
+			RawPath:   request.Path,
+			Stage:     rc.Stage,
+			Route:     rc.ResourcePath,
+			RequestID: rc.RequestID,
+			SourceIP:  rc.Identity.SourceIP,
+		}
+	}
+	if event := AppSyncEvent(ctx); event != nil {
+		identity := event.identity()
+		info := &RequestInfo{
+			Route:     event.Info.ParentTypeName + "." + event.Info.FieldName,
+			RequestID: invocationRequestID(ctx),
+			Principal: identity.Sub,
+		}
+		if info.Principal == "" {
+			info.Principal = identity.UserArn
+		}
+		if len(identity.SourceIP) > 0 {
+			info.SourceIP = identity.SourceIP[0]
+		}
+		if inv, ok := ctx.Value(ctxKeyInvocation).(*invocation); ok {
+			// the method and path of the synthesized request
+			info.Method = inv.request.HTTPMethod
+			info.RawPath = inv.request.Path
+		}
+		return info
+	}
+	if record := SNSRecord(ctx); record != nil {
+		return &RequestInfo{
+			Method:    http.MethodPost,
+			Route:     record.SNS.TopicArn,
+			RequestID: record.SNS.MessageID,
+		}
+	}
+	if event := ScheduledEvent(ctx); event != nil {
+		info := &RequestInfo{
+			Method:    http.MethodGet,
+			RequestID: event.ID,
+		}
+		if len(event.Resources) > 0 {
+			info.Route = event.Resources[0]
+		}
+		return info
+	}
+	return nil
+}
+
+// principal returns the principal from the authorizer context. Lambda
+// authorizers set "principalId", and Cognito authorizers set the "sub"
+// claim. If neither is present, the IAM user ARN is returned.
+func principal(authorizer interface{}, userARN string) string {
+	if m, ok := authorizer.(map[string]interface{}); ok {
+		if id, ok := m["principalId"].(string); ok && id != "" {
+			return id
+		}
+		if claims, ok := m["claims"].(map[string]interface{}); ok {
+			if sub, ok := claims["sub"].(string); ok && sub != "" {
+				return sub
+			}
+		}
+	}
+	return userARN
+}
+
+// principalV2 returns the principal from a payload format version 2.0
+// authorizer: the Lambda authorizer "principalId", the JWT "sub" claim,
+// or the IAM user ARN.
+func principalV2(authorizer *events.APIGatewayV2HTTPRequestContextAuthorizerDescription) string {
+	if authorizer == nil {
+		return ""
+	}
+	if id, ok := authorizer.Lambda["principalId"].(string); ok && id != "" {
+		return id
+	}
+	if authorizer.JWT != nil && authorizer.JWT.Claims["sub"] != "" {
+		return authorizer.JWT.Claims["sub"]
+	}
+	if authorizer.IAM != nil {
+		return authorizer.IAM.UserARN
+	}
+	return ""
+}
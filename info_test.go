@@ -0,0 +1,121 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+func TestInfo(t *testing.T) {
+	var got *RequestInfo
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = Info(r.Context())
+	})
+
+	handler := apiGatewayHandler(h, newOptions(nil))
+	if _, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/users/123",
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage:        "prod",
+			ResourcePath: "/users/{id}",
+			RequestID:    "req-1",
+			Identity:     events.APIGatewayRequestIdentity{SourceIP: "203.0.113.1"},
+			Authorizer:   map[string]interface{}{"principalId": "user-1"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want := &RequestInfo{
+		Method:    "GET",
+		RawPath:   "/users/123",
+		Stage:     "prod",
+		Route:     "/users/{id}",
+		RequestID: "req-1",
+		SourceIP:  "203.0.113.1",
+		Principal: "user-1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%+v, want=%+v", got, want)
+	}
+
+	wsHandler := webSocketHandler(h, newOptions(nil))
+	if _, err := wsHandler(context.Background(), events.APIGatewayWebsocketProxyRequest{
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+			RouteKey:   "$connect",
+			Stage:      "dev",
+			RequestID:  "req-2",
+			Authorizer: map[string]interface{}{"claims": map[string]interface{}{"sub": "abc"}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want = &RequestInfo{
+		Method:    "POST",
+		RawPath:   "/ws/connect",
+		Stage:     "dev",
+		Route:     "$connect",
+		RequestID: "req-2",
+		Principal: "abc",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%+v, want=%+v", got, want)
+	}
+
+	authHandler := authorizerHandler(h, newOptions(nil))
+	if _, err := authHandler(context.Background(), events.APIGatewayCustomAuthorizerRequestTypeRequest{
+		HTTPMethod: "DELETE",
+		Path:       "/users/123",
+		RequestContext: events.APIGatewayCustomAuthorizerRequestTypeRequestContext{
+			Stage:        "prod",
+			ResourcePath: "/users/{id}",
+			RequestID:    "req-3",
+			Identity:     events.APIGatewayCustomAuthorizerRequestTypeRequestIdentity{SourceIP: "203.0.113.2"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	want = &RequestInfo{
+		Method:    "DELETE",
+		RawPath:   "/users/123",
+		Stage:     "prod",
+		Route:     "/users/{id}",
+		RequestID: "req-3",
+		SourceIP:  "203.0.113.2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%+v, want=%+v", got, want)
+	}
+
+	appSyncHandler := appSyncHandler(h, newOptions(nil))
+	event := AppSyncResolverEvent{
+		Arguments: json.RawMessage(`{"id":"123"}`),
+		Identity:  json.RawMessage(`{"sub":"user-2","sourceIp":["203.0.113.3"]}`),
+	}
+	event.Info.ParentTypeName = "Query"
+	event.Info.FieldName = "user"
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "req-4"})
+	if _, err := appSyncHandler(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+	want = &RequestInfo{
+		Method:    "POST",
+		RawPath:   "/appsync/Query/user",
+		Route:     "Query.user",
+		RequestID: "req-4",
+		SourceIP:  "203.0.113.3",
+		Principal: "user-2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%+v, want=%+v", got, want)
+	}
+
+	if got := Info(context.Background()); got != nil {
+		t.Errorf("got=%+v, want nil", got)
+	}
+}
@@ -0,0 +1,57 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// InvokeJSON passes the raw JSON event payload to h in the same way as the
+// Lambda runtime, and returns the JSON response payload. The payload can be
+// an API Gateway proxy event (payload format version 1.0 or 2.0), or an
+// Application Load Balancer event.
+//
+// InvokeJSON is useful for testing with saved events, and as a fuzzing
+// target for the event conversion: see GenerateEvent.
+func InvokeJSON(ctx context.Context, h http.Handler, payload []byte, opts ...Option) ([]byte, error) {
+	kind, err := eventKindOf(payload)
+	if err != nil {
+		return nil, err
+	}
+	o := newOptions(opts)
+	h = o.wrap(h)
+
+	var response interface{}
+	switch kind {
+	case eventV1:
+		var request events.APIGatewayProxyRequest
+		if err := o.jsonCodec.Unmarshal(payload, &request); err != nil {
+			return nil, kv.Wrap(err, "cannot decode event")
+		}
+		response, err = withSpan(o, apiGatewayHandler(h, o))(ctx, request)
+	case eventV2:
+		var request events.APIGatewayV2HTTPRequest
+		if err := o.jsonCodec.Unmarshal(payload, &request); err != nil {
+			return nil, kv.Wrap(err, "cannot decode version 2.0 event")
+		}
+		response, err = withSpan(o, v2Handler(h, o))(ctx, request)
+	case eventALB:
+		var request events.ALBTargetGroupRequest
+		if err := o.jsonCodec.Unmarshal(payload, &request); err != nil {
+			return nil, kv.Wrap(err, "cannot decode ALB event")
+		}
+		response, err = withSpan(o, albHandler(h, o))(ctx, request)
+	default:
+		return nil, kv.NewError("payload is not a supported event")
+	}
+	if err != nil {
+		return nil, err
+	}
+	b, err := o.jsonCodec.Marshal(response)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot marshal response")
+	}
+	return b, nil
+}
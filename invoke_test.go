@@ -0,0 +1,106 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"testing"
+)
+
+func TestInvokeJSON(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path))
+	})
+	tests := []struct {
+		payload string
+		want    string
+		wantErr bool
+	}{
+		{
+			payload: `{"httpMethod":"GET","path":"/a","requestContext":{}}`,
+			want:    `{"statusCode":200,"headers":{"Content-Type":"text/plain; charset=utf-8"},"body":"GET /a"}`,
+		},
+		{
+			payload: `{"version":"2.0","rawPath":"/b","requestContext":{"http":{"method":"POST"}}}`,
+			want:    `{"statusCode":200,"headers":{"Content-Type":"text/plain; charset=utf-8"},"multiValueHeaders":null,"body":"POST /b","cookies":null}`,
+		},
+		{
+			payload: `{"httpMethod":"PUT","path":"/c","requestContext":{"elb":{"targetGroupArn":"arn"}}}`,
+			want:    `{"statusCode":200,"statusDescription":"200 OK","headers":{"Content-Type":"text/plain; charset=utf-8"},"multiValueHeaders":null,"body":"PUT /c","isBase64Encoded":false}`,
+		},
+		{payload: `{"status":"200"}`, wantErr: true},
+		{payload: `not json`, wantErr: true},
+	}
+	for i, tt := range tests {
+		got, err := InvokeJSON(context.Background(), h, []byte(tt.payload))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%d: got no error, want error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%d: got=%s\nwant=%s", i, got, tt.want)
+		}
+	}
+}
+
+func FuzzInvokeJSON(f *testing.F) {
+	for seed := int64(0); seed < 20; seed++ {
+		payload, err := json.Marshal(GenerateEvent(rand.New(rand.NewSource(seed))))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(payload)
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(body)
+	})
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		// must not panic, and the body must round trip
+		b, err := InvokeJSON(context.Background(), h, payload)
+		if err != nil {
+			return
+		}
+		var ev struct {
+			Body            string `json:"body"`
+			IsBase64Encoded bool   `json:"isBase64Encoded"`
+		}
+		var resp struct {
+			Body            string `json:"body"`
+			IsBase64Encoded bool   `json:"isBase64Encoded"`
+		}
+		if json.Unmarshal(payload, &ev) != nil || json.Unmarshal(b, &resp) != nil {
+			return
+		}
+		if kind, _ := eventKindOf(payload); kind != eventV1 {
+			return
+		}
+		if got, want := decodeTestBody(t, resp.Body, resp.IsBase64Encoded), decodeTestBody(t, ev.Body, ev.IsBase64Encoded); !bytes.Equal(got, want) {
+			t.Errorf("got body %q, want %q", got, want)
+		}
+	})
+}
+
+func decodeTestBody(t *testing.T, body string, isBase64 bool) []byte {
+	if !isBase64 {
+		return []byte(body)
+	}
+	b, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		t.Fatalf("cannot decode body: %v", err)
+	}
+	return b
+}
@@ -0,0 +1,61 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// InvocationInfo contains information about the Lambda function and the
+// current invocation. It combines the invocation context provided by the
+// Lambda runtime with the function configuration from the environment.
+type InvocationInfo struct {
+	FunctionName       string
+	FunctionVersion    string
+	MemoryLimitInMB    int
+	Region             string
+	LogGroupName       string
+	LogStreamName      string
+	InvokedFunctionARN string    // ARN used to invoke the function, including any alias
+	RequestID          string    // Lambda request ID, which differs from the API Gateway request ID
+	Deadline           time.Time // time at which the invocation times out
+}
+
+// Invocation returns information about the Lambda invocation associated with
+// ctx, or nil if ctx is not associated with a Lambda invocation. The context
+// of every HTTP request created by this package is derived from the
+// invocation context, so Invocation(r.Context()) works in HTTP handlers.
+func Invocation(ctx context.Context) *InvocationInfo {
+	lc, ok := lambdacontext.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info := &InvocationInfo{
+		FunctionName:       lambdacontext.FunctionName,
+		FunctionVersion:    lambdacontext.FunctionVersion,
+		MemoryLimitInMB:    lambdacontext.MemoryLimitInMB,
+		Region:             os.Getenv("AWS_REGION"),
+		LogGroupName:       lambdacontext.LogGroupName,
+		LogStreamName:      lambdacontext.LogStreamName,
+		InvokedFunctionARN: lc.InvokedFunctionArn,
+		RequestID:          lc.AwsRequestID,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		info.Deadline = deadline
+	}
+	return info
+}
+
+// Remaining returns the time remaining before the invocation times out,
+// or zero if the deadline is unknown or has passed.
+func (info *InvocationInfo) Remaining() time.Duration {
+	if info.Deadline.IsZero() {
+		return 0
+	}
+	if d := time.Until(info.Deadline); d > 0 {
+		return d
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+func TestInvocation(t *testing.T) {
+	var info *InvocationInfo
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info = Invocation(r.Context())
+	})
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	ctx = lambdacontext.NewContext(ctx, &lambdacontext.LambdaContext{
+		AwsRequestID:       "lambda-req-1",
+		InvokedFunctionArn: "arn:aws:lambda:us-east-1:123456789012:function:test:live",
+	})
+
+	handler := apiGatewayHandler(h, newOptions(nil))
+	if _, err := handler(ctx, events.APIGatewayProxyRequest{Path: "/"}); err != nil {
+		t.Fatal(err)
+	}
+	if info == nil {
+		t.Fatal("got nil invocation info")
+	}
+	if got, want := info.RequestID, "lambda-req-1"; got != want {
+		t.Errorf("got request ID %q, want %q", got, want)
+	}
+	if got, want := info.InvokedFunctionARN, "arn:aws:lambda:us-east-1:123456789012:function:test:live"; got != want {
+		t.Errorf("got ARN %q, want %q", got, want)
+	}
+	if !info.Deadline.Equal(deadline) {
+		t.Errorf("got deadline %v, want %v", info.Deadline, deadline)
+	}
+	if remaining := info.Remaining(); remaining <= 0 || remaining > time.Minute {
+		t.Errorf("got remaining %v, want between 0 and 1m", remaining)
+	}
+
+	if got := Invocation(context.Background()); got != nil {
+		t.Errorf("got %+v, want nil", got)
+	}
+}
@@ -0,0 +1,123 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLeakGracePeriod is the default time that goroutines started by a
+// request have to finish before they are reported as leaked.
+const DefaultLeakGracePeriod = time.Second
+
+// leakLabel is the profiler label that identifies the goroutines started
+// while handling a request.
+const leakLabel = "apigatewayproxy.request"
+
+// GoroutineLeak describes goroutines started while handling a request that
+// were still running after the grace period.
+type GoroutineLeak struct {
+	Method string
+	URL    string
+	Count  int    // number of leaked goroutines
+	Stacks string // goroutine profile records for the leaked goroutines
+}
+
+// WithLeakDetection configures the adapter to detect goroutines that are
+// started while handling a request, and are still running grace after
+// the handler returns. Each leak is passed to onLeak. If grace is zero or
+// negative, DefaultLeakGracePeriod is used.
+//
+// The goroutines are identified using a profiler label, which goroutines
+// inherit from the goroutine that starts them, so leaks are attributed to
+// the correct request even when requests are handled concurrently.
+// Handlers that replace the profiler labels of the request context are
+// not tracked.
+//
+// Leak detection is intended for local development, and is disabled when
+// running in AWS Lambda.
+func WithLeakDetection(grace time.Duration, onLeak func(leak *GoroutineLeak)) Option {
+	if grace <= 0 {
+		grace = DefaultLeakGracePeriod
+	}
+	return func(o *options) {
+		o.leakGrace = grace
+		o.onLeak = onLeak
+	}
+}
+
+// leakID identifies each request checked for leaks.
+var leakID int64
+
+// leakHandler labels the goroutines started by h, and reports any that are
+// still running after the grace period.
+func leakHandler(h http.Handler, o *options) http.Handler {
+	if IsLambda() {
+		return h
+	}
+	grace, onLeak := o.leakGrace, o.onLeak
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strconv.FormatInt(atomic.AddInt64(&leakID, 1), 10)
+		pprof.Do(r.Context(), pprof.Labels(leakLabel, id), func(ctx context.Context) {
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+		method, url := r.Method, r.URL.String()
+
+		// check on a separate goroutine, which does not have the label,
+		// so that the response is not delayed
+		go func() {
+			deadline := time.Now().Add(grace)
+			for {
+				count, stacks := labelledGoroutines(id)
+				if count == 0 {
+					return
+				}
+				if time.Now().After(deadline) {
+					onLeak(&GoroutineLeak{
+						Method: method,
+						URL:    url,
+						Count:  count,
+						Stacks: stacks,
+					})
+					return
+				}
+				time.Sleep(grace / 10)
+			}
+		}()
+	})
+}
+
+// labelledGoroutines returns the number of goroutines with the leak label
+// for id, and their records from the goroutine profile.
+func labelledGoroutines(id string) (int, string) {
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 1)
+	label := strconv.Quote(leakLabel) + ":" + strconv.Quote(id)
+
+	// after the first line, the records are separated by blank lines, and
+	// each record starts with the number of goroutines with the same stack
+	// and labels
+	profile := buf.String()
+	if i := strings.IndexByte(profile, '\n'); i >= 0 {
+		profile = profile[i+1:]
+	}
+	var count int
+	var stacks strings.Builder
+	for _, record := range strings.Split(profile, "\n\n") {
+		if !strings.Contains(record, "# labels: ") || !strings.Contains(record, label) {
+			continue
+		}
+		if i := strings.IndexByte(record, ' '); i > 0 {
+			n, _ := strconv.Atoi(record[:i])
+			count += n
+		}
+		stacks.WriteString(record)
+		stacks.WriteString("\n\n")
+	}
+	return count, stacks.String()
+}
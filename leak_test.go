@@ -0,0 +1,50 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeakDetection(t *testing.T) {
+	leaks := make(chan *GoroutineLeak, 2)
+	release := make(chan struct{})
+	defer close(release)
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/leak":
+			go func() { <-release }()
+		case "/ok":
+			done := make(chan struct{})
+			go func() { close(done) }()
+			<-done
+		}
+	}), WithLeakDetection(50*time.Millisecond, func(leak *GoroutineLeak) {
+		leaks <- leak
+	}))
+
+	for _, path := range []string{"/ok", "/leak"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	select {
+	case leak := <-leaks:
+		if got, want := leak.URL, "/leak"; got != want {
+			t.Errorf("got url %q, want %q", got, want)
+		}
+		if got, want := leak.Count, 1; got != want {
+			t.Errorf("got count %d, want %d", got, want)
+		}
+		if !strings.Contains(leak.Stacks, "TestLeakDetection") {
+			t.Errorf("stacks do not contain the leaking function:\n%s", leak.Stacks)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("got no leak, want leak")
+	}
+	select {
+	case leak := <-leaks:
+		t.Errorf("got unexpected leak for %q", leak.URL)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,162 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// GatewayTimeout is the maximum integration timeout of an API Gateway
+// REST API.
+const GatewayTimeout = 29 * time.Second
+
+// LocalFaults configures the local server to simulate the failures that
+// occur in AWS Lambda and API Gateway, so that error handling can be tested
+// before the function is deployed. The faults are only simulated for requests
+// that are not received from Lambda.
+//
+// The response is buffered until the handler returns, so responses are not
+// streamed to the client.
+type LocalFaults struct {
+	// Timeout is the time after which the request receives the 504 (Gateway
+	// Timeout) response sent by API Gateway, for example GatewayTimeout. The
+	// handler continues to run, as it does in Lambda. If Timeout is zero,
+	// requests do not time out.
+	Timeout time.Duration
+
+	// ResponseLimit causes responses larger than MaxResponseSize to be
+	// replaced by the 502 (Bad Gateway) response sent by API Gateway.
+	ResponseLimit bool
+
+	// ColdStart is the maximum latency of a simulated cold start. The first
+	// request, and a proportion ColdStartRate of later requests, are delayed
+	// by a random time up to ColdStart before calling the handler.
+	ColdStart     time.Duration
+	ColdStartRate float64
+}
+
+// The response bodies sent by API Gateway.
+const (
+	gatewayTimeoutBody = `{"message": "Endpoint request timed out"}`
+	badGatewayBody     = `{"message": "Internal server error"}`
+)
+
+// WithLocalFaults configures the adapter to simulate Lambda and API Gateway
+// failures when running as a local server.
+func WithLocalFaults(faults LocalFaults) Option {
+	return func(o *options) {
+		o.localFaults = &faults
+	}
+}
+
+// localFaultsHandler simulates the failures configured by faults.
+func localFaultsHandler(h http.Handler, faults *LocalFaults) http.Handler {
+	var (
+		mutex sync.Mutex
+		warm  bool
+		rnd   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	)
+	coldStart := func() time.Duration {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if faults.ColdStart <= 0 || (warm && rnd.Float64() >= faults.ColdStartRate) {
+			warm = true
+			return 0
+		}
+		warm = true
+		return time.Duration(rnd.Int63n(int64(faults.ColdStart) + 1))
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(ctxKeyInvocation) != nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+		var timeout <-chan time.Time
+		if faults.Timeout > 0 {
+			timer := time.NewTimer(faults.Timeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		bw := &bufferedWriter{header: make(http.Header)}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if delay := coldStart(); delay > 0 {
+				time.Sleep(delay)
+			}
+			h.ServeHTTP(bw, r)
+		}()
+		select {
+		case <-done:
+		case <-timeout:
+			writeGatewayError(w, http.StatusGatewayTimeout, gatewayTimeoutBody)
+			return
+		}
+		if faults.ResponseLimit && bw.payloadSize() > MaxResponseSize {
+			writeGatewayError(w, http.StatusBadGateway, badGatewayBody)
+			return
+		}
+		bw.writeTo(w)
+	})
+}
+
+// writeGatewayError writes an error response in the format used by
+// API Gateway.
+func writeGatewayError(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	io.WriteString(w, body)
+}
+
+// bufferedWriter keeps the response in memory until the handler returns.
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// payloadSize returns the approximate size of the response payload that
+// Lambda would return for the response.
+func (w *bufferedWriter) payloadSize() int {
+	response := apiGatewayProxyResponse{MultiValueHeaders: w.header}
+	n := w.body.Len()
+	if !utf8.Valid(w.body.Bytes()) {
+		// binary bodies are base64 encoded
+		n = base64.StdEncoding.EncodedLen(n)
+	}
+	return payloadSize(&response) + n
+}
+
+// writeTo writes the buffered response to w.
+func (w *bufferedWriter) writeTo(rw http.ResponseWriter) {
+	header := rw.Header()
+	for k, v := range w.header {
+		header[k] = v
+	}
+	if w.status != 0 {
+		rw.WriteHeader(w.status)
+	}
+	rw.Write(w.body.Bytes())
+}
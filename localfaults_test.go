@@ -0,0 +1,58 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalFaults(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", MaxResponseSize)))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("ok"))
+	})
+	tests := []struct {
+		faults     LocalFaults
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{faults: LocalFaults{Timeout: time.Second, ResponseLimit: true}, path: "/", wantStatus: http.StatusCreated, wantBody: "ok"},
+		{faults: LocalFaults{Timeout: 20 * time.Millisecond}, path: "/slow", wantStatus: http.StatusGatewayTimeout, wantBody: gatewayTimeoutBody},
+		{faults: LocalFaults{ResponseLimit: true}, path: "/large", wantStatus: http.StatusBadGateway, wantBody: badGatewayBody},
+		{faults: LocalFaults{}, path: "/large", wantStatus: http.StatusOK, wantBody: strings.Repeat("x", MaxResponseSize)},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		Handler(h, WithLocalFaults(tt.faults)).ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if got, want := w.Code, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if got, want := w.Body.String(), tt.wantBody; got != want {
+			t.Errorf("%d: got body %.40q, want %.40q", i, got, want)
+		}
+	}
+}
+
+func TestLocalFaultsColdStart(t *testing.T) {
+	var calls int
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}), WithLocalFaults(LocalFaults{ColdStart: time.Millisecond}))
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+	if calls != 3 {
+		t.Errorf("got %d calls, want 3", calls)
+	}
+}
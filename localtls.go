@@ -0,0 +1,135 @@
+package apigatewayproxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jjeffery/kv"
+)
+
+// LocalTLSConfig returns a TLS configuration for running the handler as a
+// local HTTPS server, so that Secure cookies, HSTS and other code that
+// depends on the scheme behave as they do behind API Gateway. For example:
+//
+//	config, err := apigatewayproxy.LocalTLSConfig("localhost.pem", "localhost-key.pem")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	server := &http.Server{Addr: ":8443", Handler: h, TLSConfig: config}
+//	log.Fatal(server.ListenAndServeTLS("", ""))
+//
+// If certFile and keyFile exist, the certificate is loaded from them. These
+// are the names of the files created by "mkcert localhost", so a certificate
+// trusted by the local browser can be used. Otherwise a self-signed
+// certificate for hosts is generated, and saved to certFile and keyFile if
+// they are not empty, so that it is reused the next time the server starts.
+// The default hosts are "localhost", "127.0.0.1" and "::1".
+//
+// Requests received over TLS by a handler created using Handler have the
+// X-Forwarded-Proto and X-Forwarded-Port headers added if they are not
+// present, in the same way as requests received from API Gateway.
+func LocalTLSConfig(certFile, keyFile string, hosts ...string) (*tls.Config, error) {
+	if certFile != "" && keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err == nil {
+			return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, kv.Wrap(err, "cannot load certificate").With("certFile", certFile, "keyFile", keyFile)
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = []string{"localhost", "127.0.0.1", "::1"}
+	}
+	certPEM, keyPEM, err := generateCertificate(hosts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if certFile != "" && keyFile != "" {
+		if err := os.WriteFile(certFile, certPEM, 0o644); err != nil {
+			return nil, kv.Wrap(err, "cannot save certificate").With("certFile", certFile)
+		}
+		if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+			return nil, kv.Wrap(err, "cannot save key").With("keyFile", keyFile)
+		}
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot use generated certificate")
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+}
+
+// generateCertificate returns a PEM encoded self-signed certificate for
+// hosts, and its private key.
+func generateCertificate(hosts []string, now time.Time) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, kv.Wrap(err, "cannot generate key")
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, kv.Wrap(err, "cannot generate serial number")
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"apigatewayproxy local development"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, kv.Wrap(err, "cannot create certificate")
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, kv.Wrap(err, "cannot marshal key")
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// localTLSHandler adds the X-Forwarded-Proto and X-Forwarded-Port headers
+// to requests received directly over TLS, so that they are the same as
+// requests received from API Gateway.
+func localTLSHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && r.Context().Value(ctxKeyInvocation) == nil {
+			if r.Header.Get("X-Forwarded-Proto") == "" {
+				r.Header.Set("X-Forwarded-Proto", "https")
+			}
+			if r.Header.Get("X-Forwarded-Port") == "" {
+				port := "443"
+				if _, p, err := net.SplitHostPort(r.Host); err == nil && p != "" {
+					port = p
+				}
+				r.Header.Set("X-Forwarded-Port", port)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
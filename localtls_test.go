@@ -0,0 +1,57 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "localhost.pem"), filepath.Join(dir, "localhost-key.pem")
+	config, err := LocalTLSConfig(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatalf("certificate not saved: %v", err)
+	}
+
+	// the saved certificate is reused
+	config2, err := LocalTLSConfig(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(config.Certificates[0].Certificate[0], config2.Certificates[0].Certificate[0]) {
+		t.Error("got a new certificate, want the saved certificate")
+	}
+
+	server := httptest.NewUnstartedServer(Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("X-Forwarded-Proto")+" "+r.Header.Get("X-Forwarded-Port"))
+	})))
+	server.TLS = config
+	server.StartTLS()
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	if got, want := string(body), "https "+port; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
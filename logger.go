@@ -0,0 +1,102 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// WithLogger configures the adapter to install a logger in the context
+// of each HTTP request, which handlers retrieve using Logger. The newLogger
+// function is called for each request with information about the request,
+// and returns the logger to use. The adapter adds the request ID, the route
+// and whether the request is a cold start as attributes. If newLogger is
+// nil, slog.Default is used.
+func WithLogger(newLogger func(info RequestInfo) *slog.Logger) Option {
+	if newLogger == nil {
+		newLogger = func(RequestInfo) *slog.Logger { return slog.Default() }
+	}
+	return func(o *options) {
+		o.newLogger = newLogger
+	}
+}
+
+// WithLogCorrelation configures the adapter to add the X-Ray trace ID to
+// the logger installed by WithLogger, as the attribute "xrayTraceId". With
+// a JSON handler, every log line then has the "requestId", "xrayTraceId"
+// and "route" keys, which CloudWatch Logs Insights discovers automatically:
+//
+//	fields @timestamp, msg | filter xrayTraceId = "1-5759e988-bd862e3fe1be46a994272793"
+//
+// If WithLogger is not specified, the logger is based on slog.Default.
+func WithLogCorrelation() Option {
+	return func(o *options) {
+		o.logCorrelation = true
+		if o.newLogger == nil {
+			WithLogger(nil)(o)
+		}
+	}
+}
+
+// Logger returns the logger installed in ctx by WithLogger. If there is
+// no logger in ctx, it returns slog.Default.
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(ctxKeyLogger).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// loggerHandler installs the request logger in the request context.
+// It must be wrapped by countRequests so that cold starts are detected.
+func loggerHandler(h http.Handler, o *options) http.Handler {
+	newLogger, correlation := o.newLogger, o.logCorrelation
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := requestInfo(r)
+		logger := newLogger(info).With(
+			slog.String("requestId", info.RequestID),
+			slog.String("route", info.Route),
+			slog.Bool("coldStart", atomic.LoadInt64(&requestCount) <= 1),
+		)
+		if correlation {
+			if traceID := xrayTraceID(r); traceID != "" {
+				logger = logger.With(slog.String("xrayTraceId", traceID))
+			}
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyLogger, logger)))
+	})
+}
+
+// requestInfo returns the information about the request. For requests
+// that were not created from an event, such as when running in a local
+// HTTP server, the information comes from the HTTP request.
+func requestInfo(r *http.Request) RequestInfo {
+	if info := Info(r.Context()); info != nil {
+		return *info
+	}
+	return RequestInfo{
+		Method:   r.Method,
+		RawPath:  r.URL.EscapedPath(),
+		Route:    r.URL.Path,
+		SourceIP: ClientIP(r),
+	}
+}
+
+// xrayTraceID returns the X-Ray trace ID for the request. The Lambda runtime
+// passes the trace header in the context, and API Gateway passes it in the
+// X-Amzn-Trace-Id request header. The trace ID is the Root field of the
+// trace header, or the whole header if it has no Root field.
+func xrayTraceID(r *http.Request) string {
+	header, _ := r.Context().Value("x-amzn-trace-id").(string)
+	if header == "" {
+		header = r.Header.Get("X-Amzn-Trace-Id")
+	}
+	for _, field := range strings.Split(header, ";") {
+		if strings.HasPrefix(field, "Root=") {
+			return strings.TrimPrefix(field, "Root=")
+		}
+	}
+	return header
+}
@@ -0,0 +1,135 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	var gotInfo RequestInfo
+	opts := newOptions([]Option{
+		WithLogger(func(info RequestInfo) *slog.Logger {
+			gotInfo = info
+			return slog.New(slog.NewJSONHandler(&buf, nil)).With("service", "test")
+		}),
+	})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Logger(r.Context()).Info("hello")
+	})
+	handler := apiGatewayHandler(opts.wrap(h), opts)
+	if _, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/users/1",
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID:    "req-1",
+			ResourcePath: "/users/{id}",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gotInfo.RequestID, "req-1"; got != want {
+		t.Errorf("got info request ID %q, want %q", got, want)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("cannot decode log record %q: %v", buf.String(), err)
+	}
+	for k, want := range map[string]interface{}{
+		"msg":       "hello",
+		"service":   "test",
+		"requestId": "req-1",
+		"route":     "/users/{id}",
+	} {
+		if got := record[k]; got != want {
+			t.Errorf("got %s=%v, want %v", k, got, want)
+		}
+	}
+	if _, ok := record["coldStart"].(bool); !ok {
+		t.Errorf("got coldStart=%v, want bool", record["coldStart"])
+	}
+}
+
+func TestLoggerDefault(t *testing.T) {
+	if got, want := Logger(context.Background()), slog.Default(); got != want {
+		t.Errorf("got %v, want default logger", got)
+	}
+
+	var got *slog.Logger
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = Logger(r.Context())
+	}), WithLogger(nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if got == nil || got == slog.Default() {
+		t.Error("got default logger, want request logger")
+	}
+}
+
+func TestLogCorrelation(t *testing.T) {
+	var buf bytes.Buffer
+	opts := newOptions([]Option{
+		WithLogCorrelation(),
+		WithLogger(func(info RequestInfo) *slog.Logger {
+			return slog.New(slog.NewJSONHandler(&buf, nil))
+		}),
+	})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Logger(r.Context()).Info("hello")
+	})
+	handler := apiGatewayHandler(opts.wrap(h), opts)
+
+	tests := []struct {
+		ctx    context.Context
+		header string
+		want   interface{}
+	}{
+		{
+			ctx:    context.Background(),
+			header: "Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1",
+			want:   "1-5759e988-bd862e3fe1be46a994272793",
+		},
+		{
+			ctx:    context.WithValue(context.Background(), "x-amzn-trace-id", "Root=1-abc;Parent=def;Sampled=0"),
+			header: "Root=1-5759e988-bd862e3fe1be46a994272793",
+			want:   "1-abc",
+		},
+		{
+			ctx:  context.Background(),
+			want: nil,
+		},
+	}
+	for i, tt := range tests {
+		buf.Reset()
+		request := events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Path:       "/",
+			RequestContext: events.APIGatewayProxyRequestContext{
+				RequestID:    "req-1",
+				ResourcePath: "/",
+			},
+		}
+		if tt.header != "" {
+			request.Headers = map[string]string{"X-Amzn-Trace-Id": tt.header}
+		}
+		if _, err := handler(tt.ctx, request); err != nil {
+			t.Fatal(err)
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("%d: cannot decode log record %q: %v", i, buf.String(), err)
+		}
+		if got := record["xrayTraceId"]; got != tt.want {
+			t.Errorf("%d: got xrayTraceId=%v, want %v", i, got, tt.want)
+		}
+		if got, want := record["requestId"], "req-1"; got != want {
+			t.Errorf("%d: got requestId=%v, want %v", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package apigatewayproxy
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Maintenance configures maintenance mode. While maintenance mode is
+// enabled, requests receive a 503 (Service Unavailable) response without
+// calling the handler.
+type Maintenance struct {
+	// Enabled is called for each request, and reports whether maintenance
+	// mode is enabled. See MaintenanceEnv and MaintenanceFile.
+	Enabled func(r *http.Request) bool
+
+	// RetryAfter is the value of the Retry-After response header. If it is
+	// zero, the header is not set.
+	RetryAfter time.Duration
+
+	// ContentType and Body are the response in maintenance mode. If Body
+	// is empty, the response is written using WriteError.
+	ContentType string
+	Body        string
+}
+
+// MaintenanceEnv returns a function for Maintenance.Enabled that enables
+// maintenance mode when the environment variable is set to a true value,
+// such as "1" or "true".
+func MaintenanceEnv(name string) func(r *http.Request) bool {
+	return func(*http.Request) bool {
+		enabled, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv(name)))
+		return enabled
+	}
+}
+
+// MaintenanceFile returns a function for Maintenance.Enabled that enables
+// maintenance mode while the file exists. This is useful for local servers,
+// and for Lambda functions with a mounted EFS file system.
+func MaintenanceFile(path string) func(r *http.Request) bool {
+	return func(*http.Request) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+}
+
+// WithMaintenance configures the adapter to check whether maintenance mode
+// is enabled for each request, so that operators can drain traffic from one
+// place. Health check requests are not affected by maintenance mode.
+func WithMaintenance(m Maintenance) Option {
+	return func(o *options) {
+		if m.Enabled == nil {
+			o.maintenance = nil
+			return
+		}
+		o.maintenance = &m
+	}
+}
+
+// maintenanceHandler responds to requests without calling h while
+// maintenance mode is enabled.
+func maintenanceHandler(h http.Handler, m *Maintenance) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.Enabled(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if m.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int((m.RetryAfter+time.Second-1)/time.Second)))
+		}
+		writeUnavailable(w, r, m.ContentType, m.Body, "maintenance in progress")
+	})
+}
+
+// writeUnavailable writes a 503 (Service Unavailable) response with the body
+// and content type, or an error response with the message if body is empty.
+func writeUnavailable(w http.ResponseWriter, r *http.Request, contentType, body, message string) {
+	if body == "" {
+		WriteError(w, r, http.StatusServiceUnavailable, message)
+		return
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	io.WriteString(w, body)
+}
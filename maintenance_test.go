@@ -0,0 +1,76 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMaintenance(t *testing.T) {
+	var enabled bool
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		WithHealthCheck("/health"),
+		WithMaintenance(Maintenance{
+			Enabled:     func(r *http.Request) bool { return enabled },
+			RetryAfter:  90 * time.Second,
+			ContentType: "text/html",
+			Body:        "<h1>Back soon</h1>",
+		}),
+	)
+
+	tests := []struct {
+		path       string
+		enabled    bool
+		wantStatus int
+		wantRetry  string
+	}{
+		{path: "/", wantStatus: 200},
+		{path: "/", enabled: true, wantStatus: 503, wantRetry: "90"},
+		{path: "/health", enabled: true, wantStatus: 200},
+	}
+	for i, tt := range tests {
+		enabled = tt.enabled
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if got, want := w.Code, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if got, want := w.Header().Get("Retry-After"), tt.wantRetry; got != want {
+			t.Errorf("%d: got Retry-After=%q, want %q", i, got, want)
+		}
+		if w.Code == http.StatusServiceUnavailable {
+			if got, want := w.Body.String(), "<h1>Back soon</h1>"; got != want {
+				t.Errorf("%d: got body %q, want %q", i, got, want)
+			}
+		}
+	}
+}
+
+func TestMaintenanceSwitches(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+
+	const name = "APIGATEWAYPROXY_TEST_MAINTENANCE"
+	enabled := MaintenanceEnv(name)
+	for value, want := range map[string]bool{"": false, "true": true, "1": true, "false": false, "x": false} {
+		os.Setenv(name, value)
+		if got := enabled(r); got != want {
+			t.Errorf("%q: got %v, want %v", value, got, want)
+		}
+	}
+	os.Unsetenv(name)
+
+	path := filepath.Join(t.TempDir(), "maintenance")
+	enabled = MaintenanceFile(path)
+	if enabled(r) {
+		t.Error("got enabled, want disabled without file")
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !enabled(r) {
+		t.Error("got disabled, want enabled with file")
+	}
+}
@@ -0,0 +1,108 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WithMountPath configures the adapter to serve the handler under prefix,
+// so that a handler written to serve at "/" can be mounted at a path such as
+// "/api/v1". As for http.StripPrefix, the prefix is removed from the request
+// path, and requests for paths outside prefix receive a 404 (Not Found)
+// response. The prefix is also removed from RequestURI, and is added to
+// the Location header of responses that redirect to an absolute path, so
+// that redirects work for clients.
+//
+// Because the Start functions and Handler apply the same options, the
+// handler is mounted in the same way in AWS Lambda and when running as a
+// conventional HTTP server.
+func WithMountPath(prefix string) Option {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return func(o *options) {
+		o.mountPath = prefix
+	}
+}
+
+// mountHandler removes prefix from the request path before calling h.
+func mountHandler(h http.Handler, prefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, ok := stripMountPath(r.URL.Path, prefix)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		ctx := r.Context()
+		if ctx.Value(ctxKeyInvocation) == nil {
+			// AbsoluteURL obtains the mount path from the invocation for
+			// requests received from Lambda
+			ctx = context.WithValue(ctx, ctxKeyMountPath, prefix)
+		}
+		r2 := r.WithContext(ctx)
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = path
+		if r.URL.RawPath != "" {
+			if r2.URL.RawPath, ok = stripMountPath(r.URL.RawPath, prefix); !ok {
+				r2.URL.RawPath = ""
+			}
+		}
+		if uri, ok := stripMountPath(r.RequestURI, prefix); ok {
+			r2.RequestURI = uri
+		}
+		h.ServeHTTP(&mountWriter{ResponseWriter: w, prefix: prefix}, r2)
+	})
+}
+
+// stripMountPath removes prefix from path, and reports whether path is
+// within prefix. The result always starts with "/".
+func stripMountPath(path, prefix string) (string, bool) {
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+	rest := path[len(prefix):]
+	switch {
+	case rest == "":
+		return "/", true
+	case rest[0] == '/':
+		return rest, true
+	case rest[0] == '?':
+		return "/" + rest, true
+	}
+	// for example, "/api/v10" is not within "/api/v1"
+	return "", false
+}
+
+// mountWriter adds the mount path to redirects to absolute paths.
+type mountWriter struct {
+	http.ResponseWriter
+	prefix      string
+	wroteHeader bool
+}
+
+func (w *mountWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.wroteHeader = true
+	header := w.Header()
+	if location := header.Get("Location"); strings.HasPrefix(location, "/") && !strings.HasPrefix(location, "//") {
+		header.Set("Location", w.prefix+location)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *mountWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (w *mountWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,50 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMountPath(t *testing.T) {
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusFound)
+			return
+		}
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Request-Uri", r.RequestURI)
+	}), WithMountPath("/api/v1/"))
+
+	tests := []struct {
+		target       string
+		wantStatus   int
+		wantPath     string
+		wantURI      string
+		wantLocation string
+	}{
+		{target: "/api/v1/users?id=1", wantStatus: 200, wantPath: "/users", wantURI: "/users?id=1"},
+		{target: "/api/v1", wantStatus: 200, wantPath: "/", wantURI: "/"},
+		{target: "/api/v1?x=1", wantStatus: 200, wantPath: "/", wantURI: "/?x=1"},
+		{target: "/api/v1/old", wantStatus: 302, wantLocation: "/api/v1/new"},
+		{target: "/api/v10/users", wantStatus: 404},
+		{target: "/users", wantStatus: 404},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tt.target, nil))
+		if got, want := w.Code, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+			continue
+		}
+		for name, want := range map[string]string{
+			"X-Path":        tt.wantPath,
+			"X-Request-Uri": tt.wantURI,
+			"Location":      tt.wantLocation,
+		} {
+			if got := w.Header().Get(name); got != want {
+				t.Errorf("%d: got %s=%q, want %q", i, name, got, want)
+			}
+		}
+	}
+}
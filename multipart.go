@@ -0,0 +1,106 @@
+package apigatewayproxy
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/jjeffery/kv"
+)
+
+// WithStreamingMultipart configures the adapter to decode base64 encoded
+// multipart/form-data request bodies as they are read, instead of decoding
+// the whole body into a buffer before calling the handler. Together with
+// ForEachPart or r.MultipartReader, this means that a large upload is only
+// held in memory once, as the Lambda event, and file parts can be streamed
+// to storage such as S3 with bounded memory. Calling r.ParseMultipartForm
+// still copies the parts into memory or temporary files.
+//
+// Because the body is decoded as it is read, invalid base64 bodies are not
+// rejected before the handler is called. Instead, reading the body returns
+// an error that wraps ErrInvalidBase64Body.
+func WithStreamingMultipart() Option {
+	return func(o *options) {
+		o.streamingMultipart = true
+	}
+}
+
+// ForEachPart calls fn for each part of a multipart/form-data or
+// multipart/mixed request body, in order. The part is only valid until fn
+// returns, and any of the part that fn does not read is discarded. If fn
+// returns an error, ForEachPart stops and returns the error.
+//
+// Unlike r.ParseMultipartForm, ForEachPart does not buffer the parts, so a
+// file part can be copied to its destination while it is being received.
+// Form values must be read from their parts by fn.
+func ForEachPart(r *http.Request, fn func(part *multipart.Part) error) error {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return kv.Wrap(err, "cannot read multipart body")
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return kv.Wrap(err, "cannot read multipart body")
+		}
+		err = fn(part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// isMultipart reports whether the content type is a multipart media type.
+func isMultipart(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && strings.HasPrefix(mediaType, "multipart/")
+}
+
+// streamMultipartBody reports whether the body of the request event is
+// decoded as it is read.
+func (inv *invocation) streamMultipartBody() bool {
+	request := &inv.request
+	if !inv.opts.streamingMultipart || !request.IsBase64Encoded {
+		return false
+	}
+	for k, v := range request.Headers {
+		if strings.EqualFold(k, "Content-Type") {
+			return isMultipart(v)
+		}
+	}
+	return false
+}
+
+// decodedLen returns the length of the base64 encoded string s when it is
+// decoded using enc.
+func decodedLen(enc *base64.Encoding, s string) int64 {
+	n := enc.DecodedLen(len(s))
+	for i := len(s) - 1; i >= 0 && s[i] == '='; i-- {
+		// padding is included in the length returned by DecodedLen
+		n--
+	}
+	if n < 0 {
+		n = 0
+	}
+	return int64(n)
+}
+
+// base64BodyReader decodes a base64 encoded request body as it is read.
+type base64BodyReader struct {
+	r io.Reader
+}
+
+func (b base64BodyReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = kv.Wrap(newConversionError(ErrInvalidBase64Body, err), "cannot decode request body")
+	}
+	return n, err
+}
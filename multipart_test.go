@@ -0,0 +1,95 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestStreamingMultipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("name", "report")
+	fw, _ := mw.CreateFormFile("file", "report.bin")
+	file := bytes.Repeat([]byte{0, 1, 2, 0xff}, 10000)
+	fw.Write(file)
+	mw.Close()
+
+	var parts []string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Body.(*requestBody).r.(base64BodyReader); !ok {
+			t.Errorf("got %T, want body decoded as it is read", r.Body.(*requestBody).r)
+		}
+		if got, want := r.ContentLength, int64(body.Len()); got != want {
+			t.Errorf("got ContentLength %d, want %d", got, want)
+		}
+		err := ForEachPart(r, func(part *multipart.Part) error {
+			b, err := io.ReadAll(part)
+			if err != nil {
+				return err
+			}
+			if part.FileName() != "" && !bytes.Equal(b, file) {
+				t.Errorf("got file of %d bytes, want %d bytes", len(b), len(file))
+			}
+			parts = append(parts, part.FormName())
+			return nil
+		})
+		if err != nil {
+			t.Errorf("got %v, want no error", err)
+		}
+	})
+	handler := apiGatewayHandler(h, newOptions([]Option{WithStreamingMultipart()}))
+	_, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:      "POST",
+		Path:            "/upload",
+		Headers:         map[string]string{"content-type": mw.FormDataContentType()},
+		Body:            base64.StdEncoding.EncodeToString(body.Bytes()),
+		IsBase64Encoded: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(parts, ","), "name,file"; got != want {
+		t.Errorf("got parts %q, want %q", got, want)
+	}
+}
+
+func TestStreamingMultipartInvalidBase64(t *testing.T) {
+	var readErr error
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	})
+	handler := apiGatewayHandler(h, newOptions([]Option{WithStreamingMultipart()}))
+	_, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:      "POST",
+		Path:            "/upload",
+		Headers:         map[string]string{"Content-Type": "multipart/form-data; boundary=x"},
+		Body:            "not*base64",
+		IsBase64Encoded: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(readErr, ErrInvalidBase64Body) {
+		t.Errorf("got %v, want ErrInvalidBase64Body", readErr)
+	}
+}
+
+func TestDecodedLen(t *testing.T) {
+	for i, s := range []string{"", "a", "ab", "abc", "abcd", strings.Repeat("x", 1000)} {
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawURLEncoding} {
+			encoded := enc.EncodeToString([]byte(s))
+			if got, want := decodedLen(enc, encoded), int64(len(s)); got != want {
+				t.Errorf("%d: got %d, want %d", i, got, want)
+			}
+		}
+	}
+}
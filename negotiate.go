@@ -0,0 +1,129 @@
+package apigatewayproxy
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// acceptSpec is one element of an Accept or Accept-Encoding header.
+type acceptSpec struct {
+	value string
+	q     float64
+}
+
+// parseAccept parses the comma separated elements of an Accept,
+// Accept-Encoding or similar header, including the quality values
+// described in RFC 7231 section 5.3.1. Parameters other than
+// the quality value are ignored.
+func parseAccept(header string) []acceptSpec {
+	var specs []acceptSpec
+	for _, part := range strings.Split(header, ",") {
+		value, params := part, ""
+		if i := strings.IndexByte(part, ';'); i >= 0 {
+			value, params = part[:i], part[i+1:]
+		}
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		spec := acceptSpec{value: value, q: 1}
+		for _, param := range strings.Split(params, ";") {
+			param = strings.TrimSpace(param)
+			if len(param) > 2 && (param[0] == 'q' || param[0] == 'Q') && param[1] == '=' {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					spec.q = q
+				}
+			}
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// NegotiateContentType returns the media type in offers that best matches
+// the Accept header, taking into account quality values and wildcards
+// ("*/*" and "type/*"). When more than one offer matches equally well,
+// the first is chosen. If the Accept header is empty, the first offer is
+// returned. If no offer is acceptable, an empty string is returned.
+func NegotiateContentType(accept string, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+	specs := parseAccept(accept)
+	var best string
+	var bestQ float64
+	for _, offer := range offers {
+		// a more specific media range takes precedence
+		q, specificity := 0.0, -1
+		for _, spec := range specs {
+			var s int
+			switch {
+			case spec.value == strings.ToLower(offer):
+				s = 2
+			case spec.value == "*/*" || spec.value == "*":
+				s = 0
+			case strings.HasSuffix(spec.value, "/*") && strings.HasPrefix(strings.ToLower(offer), strings.TrimSuffix(spec.value, "*")):
+				s = 1
+			default:
+				continue
+			}
+			if s > specificity {
+				q, specificity = spec.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// errorBody is the body of an error response in JSON and XML format.
+type errorBody struct {
+	XMLName xml.Name `json:"-" xml:"error"`
+	Status  int      `json:"status" xml:"status"`
+	Title   string   `json:"error" xml:"title"`
+	Message string   `json:"message,omitempty" xml:"message,omitempty"`
+}
+
+// WriteError writes an error response with the status code and message. The
+// response is formatted as JSON, XML or plain text, depending on the request
+// Accept header, so that both machine clients and browsers get a
+// sensible error. If the message is empty, the status text is used.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	body := errorBody{
+		Status:  status,
+		Title:   http.StatusText(status),
+		Message: message,
+	}
+	var accept string
+	if r != nil {
+		accept = r.Header.Get("Accept")
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	switch NegotiateContentType(accept, "text/plain", "application/json", "application/xml", "text/xml") {
+	case "application/json":
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	case "application/xml", "text/xml":
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write([]byte(xml.Header))
+		xml.NewEncoder(w).Encode(body)
+	default:
+		if message == "" {
+			message = body.Title
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write([]byte(message + "\n"))
+	}
+}
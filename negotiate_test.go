@@ -0,0 +1,78 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateContentType(t *testing.T) {
+	offers := []string{"text/plain", "application/json", "application/xml"}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "text/plain"},
+		{"application/json", "application/json"},
+		{"application/xml;q=0.9, application/json;q=0.8", "application/xml"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "application/xml"},
+		{"text/html, */*;q=0.1", "text/plain"},
+		{"application/*", "application/json"},
+		{"application/*;q=0.5, application/json;q=0", "application/xml"},
+		{"image/png", ""},
+	}
+	for _, tt := range tests {
+		if got := NegotiateContentType(tt.accept, offers...); got != tt.want {
+			t.Errorf("%q: got=%q, want=%q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		accept      string
+		message     string
+		contentType string
+		body        string
+	}{
+		{
+			contentType: "text/plain; charset=utf-8",
+			body:        "Not Found\n",
+		},
+		{
+			message:     "no such widget",
+			contentType: "text/plain; charset=utf-8",
+			body:        "no such widget\n",
+		},
+		{
+			accept:      "application/json",
+			message:     "no such widget",
+			contentType: "application/json",
+			body:        `{"status":404,"error":"Not Found","message":"no such widget"}` + "\n",
+		},
+		{
+			accept:      "application/xml",
+			message:     "no such widget",
+			contentType: "application/xml; charset=utf-8",
+			body:        `<error><status>404</status><title>Not Found</title><message>no such widget</message></error>`,
+		},
+	}
+	for i, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		WriteError(w, r, http.StatusNotFound, tt.message)
+		if got, want := w.Code, http.StatusNotFound; got != want {
+			t.Errorf("%d: got=%d, want=%d", i, got, want)
+		}
+		if got, want := w.Header().Get("Content-Type"), tt.contentType; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if got, want := strings.TrimPrefix(w.Body.String(), "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"), tt.body; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// WithStrictEvents configures the adapter to reject request events that
+// are missing the HTTP method or path, rather than filling in defaults.
+// The error is returned to Lambda, and the HTTP handler is not called.
+//
+// By default, events created by test consoles and other tools are
+// normalized before the HTTP request is created: an empty method is
+// treated as GET, an empty path as "/", and a nil header map is replaced
+// with an empty map. A standard method in lower or mixed case, such as
+// "get", is converted to upper case. If the event has multi value headers
+// but no single value headers, as some test tools send, the headers are
+// taken from the multi value headers. For events sent by the API Gateway
+// console's Test feature, see IsTestInvoke.
+func WithStrictEvents() Option {
+	return func(o *options) {
+		o.strictEvents = true
+	}
+}
+
+// normalizeRequest fills in defaults for fields that are missing from
+// hand-crafted request events, or returns an error in strict mode.
+func normalizeRequest(request *events.APIGatewayProxyRequest, o *options) error {
+	if o.strictEvents {
+		if request.HTTPMethod == "" {
+			return kv.Wrap(ErrInvalidMethod, "missing HTTP method in request event")
+		}
+		if request.Path == "" {
+			return kv.Wrap(ErrInvalidPath, "missing path in request event")
+		}
+	}
+	if request.HTTPMethod == "" {
+		request.HTTPMethod = http.MethodGet
+	} else if !o.strictEvents {
+		request.HTTPMethod = standardMethod(request.HTTPMethod)
+	}
+	if request.Path == "" {
+		request.Path = "/"
+	}
+	if len(request.Headers) == 0 && len(request.MultiValueHeaders) > 0 {
+		request.Headers = lastHeaderValues(request.MultiValueHeaders)
+	}
+	if request.Headers == nil {
+		request.Headers = make(map[string]string)
+	}
+	rc := &request.RequestContext
+	if rc.HTTPMethod == "" {
+		rc.HTTPMethod = request.HTTPMethod
+	}
+	if isTestInvoke(request) {
+		normalizeTestInvoke(request)
+	}
+	return nil
+}
+
+// standardMethods are the methods that are converted to upper case.
+var standardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// standardMethod returns the standard method that is equal to method
+// ignoring case, or method if there is none.
+func standardMethod(method string) string {
+	for _, m := range standardMethods {
+		if method == m {
+			return method
+		}
+	}
+	for _, m := range standardMethods {
+		if strings.EqualFold(method, m) {
+			return m
+		}
+	}
+	return method
+}
+
+// lastHeaderValues returns the single value headers for the multi value
+// headers. As for API Gateway, the value of each header is its last value.
+func lastHeaderValues(multiValueHeaders map[string][]string) map[string]string {
+	headers := make(map[string]string, len(multiValueHeaders))
+	for k, vv := range multiValueHeaders {
+		if len(vv) > 0 {
+			headers[k] = vv[len(vv)-1]
+		}
+	}
+	return headers
+}
+
+// Values in the request context of events sent by the API Gateway
+// console's Test feature.
+const (
+	testInvokeStage    = "test-invoke-stage"
+	testInvokeSourceIP = "test-invoke-source-ip"
+)
+
+// IsTestInvoke reports whether the request associated with ctx was sent
+// by the Test feature of the API Gateway console. Test invocations do not
+// pass through a deployed stage, so the stage is "test-invoke-stage", and
+// the source IP address is not an IP address. The adapter removes the
+// source IP address from test invocations, so that ClientIP returns an
+// empty string and the X-Forwarded-For header is not added.
+func IsTestInvoke(ctx context.Context) bool {
+	if request := Request(ctx); request != nil {
+		return isTestInvoke(request)
+	}
+	return false
+}
+
+// isTestInvoke reports whether the request event was sent by the Test
+// feature of the API Gateway console.
+func isTestInvoke(request *events.APIGatewayProxyRequest) bool {
+	rc := &request.RequestContext
+	return rc.Stage == testInvokeStage || rc.Identity.SourceIP == testInvokeSourceIP
+}
+
+// normalizeTestInvoke removes the values in a test invocation event that
+// would otherwise cause parse failures.
+func normalizeTestInvoke(request *events.APIGatewayProxyRequest) {
+	identity := &request.RequestContext.Identity
+	if net.ParseIP(identity.SourceIP) == nil {
+		identity.SourceIP = ""
+	}
+}
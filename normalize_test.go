@@ -0,0 +1,121 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestNormalizeRequest(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := Request(r.Context())
+		request.Headers["X-Test"] = "ok" // must not panic
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + request.RequestContext.HTTPMethod))
+	})
+	tests := []struct {
+		opts    []Option
+		request events.APIGatewayProxyRequest
+		want    string
+		wantErr bool
+	}{
+		{
+			request: events.APIGatewayProxyRequest{},
+			want:    "GET / GET",
+		},
+		{
+			request: events.APIGatewayProxyRequest{HTTPMethod: "POST", Path: "/users"},
+			want:    "POST /users POST",
+		},
+		{
+			request: events.APIGatewayProxyRequest{HTTPMethod: "post", Path: "/users"},
+			want:    "POST /users POST",
+		},
+		{
+			request: events.APIGatewayProxyRequest{HTTPMethod: "PROPFIND", Path: "/dav"},
+			want:    "PROPFIND /dav PROPFIND",
+		},
+		{
+			opts:    []Option{WithStrictEvents()},
+			request: events.APIGatewayProxyRequest{Path: "/"},
+			wantErr: true,
+		},
+		{
+			opts:    []Option{WithStrictEvents()},
+			request: events.APIGatewayProxyRequest{HTTPMethod: "GET"},
+			wantErr: true,
+		},
+		{
+			opts:    []Option{WithStrictEvents()},
+			request: events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/users"},
+			want:    "GET /users GET",
+		},
+	}
+	for i, tt := range tests {
+		handler := apiGatewayHandler(h, newOptions(tt.opts))
+		response, err := handler(context.Background(), tt.request)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%d: got no error, want error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestTestInvoke(t *testing.T) {
+	var got struct {
+		testInvoke  bool
+		clientIP    string
+		contentType string
+		forwarded   []string
+	}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.testInvoke = IsTestInvoke(r.Context())
+		got.clientIP = ClientIP(r)
+		got.contentType = r.Header.Get("Content-Type")
+		got.forwarded = r.Header.Values("X-Forwarded-For")
+	})
+	handler := apiGatewayHandler(h, newOptions(nil))
+
+	// as sent by the Test feature of the API Gateway console, with a
+	// header typed in lower case and no single value headers
+	var request events.APIGatewayProxyRequest
+	request.HTTPMethod = "GET"
+	request.Path = "/users"
+	request.MultiValueHeaders = map[string][]string{"content-type": {"application/json"}}
+	request.RequestContext.Stage = "test-invoke-stage"
+	request.RequestContext.Identity.SourceIP = "test-invoke-source-ip"
+	response, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want %d", response.StatusCode, http.StatusOK)
+	}
+	if !got.testInvoke {
+		t.Error("got IsTestInvoke false, want true")
+	}
+	if got.clientIP != "" || len(got.forwarded) != 0 {
+		t.Errorf("got client IP %q and X-Forwarded-For %q, want none", got.clientIP, got.forwarded)
+	}
+	if got, want := got.contentType, "application/json"; got != want {
+		t.Errorf("got Content-Type %q, want %q", got, want)
+	}
+
+	request.RequestContext.Stage = "prod"
+	request.RequestContext.Identity.SourceIP = "203.0.113.1"
+	if _, err := handler(context.Background(), request); err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got.testInvoke || got.clientIP != "203.0.113.1" {
+		t.Errorf("got IsTestInvoke %v and client IP %q, want false and 203.0.113.1", got.testInvoke, got.clientIP)
+	}
+}
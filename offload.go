@@ -0,0 +1,148 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// MaxResponseSize is the maximum size of a response payload returned
+// by a synchronously invoked AWS Lambda function.
+const MaxResponseSize = 6 * 1024 * 1024
+
+// ResponseUploader uploads a response body that is too large to return
+// through API Gateway. A typical implementation puts the object in an S3
+// bucket and returns a presigned URL for downloading it. The interface is
+// defined here so that this package does not depend on the AWS SDK.
+type ResponseUploader interface {
+	// Upload stores the body using key, and returns the URL that clients
+	// can use to download it. If contentEncoding is not empty, the body has
+	// been compressed, for example by WithCompression, and the encoding must
+	// be stored with the object, such as in the S3 Content-Encoding
+	// metadata, so that clients can decode it.
+	Upload(ctx context.Context, key string, contentType string, contentEncoding string, body []byte) (url string, err error)
+}
+
+// OffloadMode determines the response returned to the client after
+// an oversize response body has been uploaded.
+type OffloadMode int
+
+// Offload modes
+const (
+	// OffloadRedirect returns a 303 (See Other) response with a
+	// Location header containing the download URL.
+	OffloadRedirect OffloadMode = iota
+
+	// OffloadEnvelope returns a 200 (OK) response containing a JSON object
+	// with the download URL, the content type, content encoding and content
+	// length of the uploaded body, and the status code returned by the handler.
+	OffloadEnvelope
+)
+
+// WithResponseOffload configures the adapter to upload response bodies
+// that would exceed MaxResponseSize, so that endpoints that serve large
+// files continue to work behind API Gateway. Without this option,
+// oversize responses fail. Responses to WebSocket events are not
+// offloaded, because they are sent to the client as messages.
+func WithResponseOffload(uploader ResponseUploader, mode OffloadMode) Option {
+	return func(o *options) {
+		o.offloadUploader = uploader
+		o.offloadMode = mode
+	}
+}
+
+// offloadEnvelope is the body of the response for OffloadEnvelope
+type offloadEnvelope struct {
+	Location        string `json:"location"`
+	ContentType     string `json:"contentType,omitempty"`
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	ContentLength   int    `json:"contentLength"`
+	Status          int    `json:"status"`
+}
+
+// payloadSize estimates the size of the JSON payload for the response.
+func payloadSize(response *apiGatewayProxyResponse) int {
+	// allow for the JSON field names and punctuation
+	size := 128 + len(response.Body)
+	for k, v := range response.Headers {
+		size += len(k) + len(v) + 6
+	}
+	for k, vv := range response.MultiValueHeaders {
+		size += len(k) + 6
+		for _, v := range vv {
+			size += len(v) + 3
+		}
+	}
+	return size
+}
+
+// offload uploads the response body if the response is too large, and
+// replaces the response with a response that refers to the uploaded body.
+func (w *responseWriter) offload(ctx context.Context, o *options, request *events.APIGatewayProxyRequest) error {
+	if o.offloadUploader == nil || payloadSize(&w.response2) <= MaxResponseSize {
+		return nil
+	}
+	key := request.RequestContext.RequestID
+	if key == "" {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return kv.Wrap(err, "cannot generate key")
+		}
+		key = hex.EncodeToString(b[:])
+	}
+	// the content type may have been sniffed, and the body compressed by
+	// WithCompression, after the handler returned
+	contentType := w.response.Headers["Content-Type"]
+	contentEncoding := w.response.Headers["Content-Encoding"]
+	var body []byte
+	if w.body != nil {
+		body = w.body.Bytes()
+	}
+	location, err := o.offloadUploader.Upload(ctx, key, contentType, contentEncoding, body)
+	if err != nil {
+		return kv.Wrap(err, "cannot upload oversize response").With("key", key)
+	}
+
+	switch o.offloadMode {
+	case OffloadEnvelope:
+		envelope, err := json.Marshal(offloadEnvelope{
+			Location:        location,
+			ContentType:     contentType,
+			ContentEncoding: contentEncoding,
+			ContentLength:   len(body),
+			Status:          w.response2.StatusCode,
+		})
+		if err != nil {
+			return kv.Wrap(err, "cannot marshal offload envelope")
+		}
+		w.replace(http.StatusOK, map[string]string{
+			"Content-Type":  "application/json",
+			"Cache-Control": "no-store",
+		}, string(envelope))
+	default:
+		w.replace(http.StatusSeeOther, map[string]string{
+			"Location":      location,
+			"Cache-Control": "no-store",
+		}, "")
+	}
+	return nil
+}
+
+// replace replaces the response after it has been finished.
+func (w *responseWriter) replace(status int, headers map[string]string, body string) {
+	w.response2 = apiGatewayProxyResponse{
+		StatusCode: status,
+		Headers:    headers,
+		Body:       body,
+	}
+	w.response = events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Headers:    headers,
+		Body:       body,
+	}
+}
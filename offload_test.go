@@ -0,0 +1,183 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type fakeUploader struct {
+	key             string
+	contentType     string
+	contentEncoding string
+	size            int
+	body            []byte
+}
+
+func (u *fakeUploader) Upload(ctx context.Context, key string, contentType string, contentEncoding string, body []byte) (string, error) {
+	u.key = key
+	u.contentType = contentType
+	u.contentEncoding = contentEncoding
+	u.size = len(body)
+	u.body = append(u.body[:0], body...)
+	return "https://bucket.s3.amazonaws.com/" + key + "?signature", nil
+}
+
+func TestResponseOffload(t *testing.T) {
+	large := bytes.Repeat([]byte("x"), MaxResponseSize+1)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		if r.URL.Path == "/large" {
+			w.Write(large)
+		} else {
+			w.Write([]byte("small"))
+		}
+	})
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/large",
+	}
+	request.RequestContext.RequestID = "request-id"
+
+	uploader := &fakeUploader{}
+	handler := apiGatewayHandler(h, newOptions([]Option{WithResponseOffload(uploader, OffloadRedirect)}))
+	response, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got, want := response.StatusCode, http.StatusSeeOther; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if got, want := response.Headers["Location"], "https://bucket.s3.amazonaws.com/request-id?signature"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	if got, want := uploader.size, len(large); got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if got, want := uploader.contentType, "text/plain"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+
+	handler = apiGatewayHandler(h, newOptions([]Option{WithResponseOffload(uploader, OffloadEnvelope)}))
+	response, err = handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	var envelope offloadEnvelope
+	if err := json.Unmarshal([]byte(response.Body), &envelope); err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	want := offloadEnvelope{
+		Location:      "https://bucket.s3.amazonaws.com/request-id?signature",
+		ContentType:   "text/plain",
+		ContentLength: len(large),
+		Status:        http.StatusOK,
+	}
+	if envelope != want {
+		t.Errorf("got=%+v, want=%+v", envelope, want)
+	}
+
+	// small responses are not uploaded
+	*uploader = fakeUploader{}
+	request.Path = "/small"
+	response, err = handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got, want := response.Body, "small"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	if uploader.key != "" {
+		t.Errorf("got key=%q, want no upload", uploader.key)
+	}
+}
+
+func TestResponseOffloadCompressed(t *testing.T) {
+	// hex text compresses to about half its size, so the compressed
+	// response is still too large to return
+	random := make([]byte, MaxResponseSize)
+	rand.New(rand.NewSource(1)).Read(random)
+	large := []byte(hex.EncodeToString(random))
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(large)
+	})
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/large",
+		Headers:    map[string]string{"Accept-Encoding": "gzip"},
+	}
+	request.RequestContext.RequestID = "request-id"
+
+	uploader := &fakeUploader{}
+	handler := apiGatewayHandler(h, newOptions([]Option{
+		WithCompression(0),
+		WithResponseOffload(uploader, OffloadEnvelope),
+	}))
+	response, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got, want := uploader.contentEncoding, "gzip"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	var envelope offloadEnvelope
+	if err := json.Unmarshal([]byte(response.Body), &envelope); err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	want := offloadEnvelope{
+		Location:        "https://bucket.s3.amazonaws.com/request-id?signature",
+		ContentType:     "text/plain",
+		ContentEncoding: "gzip",
+		ContentLength:   uploader.size,
+		Status:          http.StatusOK,
+	}
+	if envelope != want {
+		t.Errorf("got=%+v, want=%+v", envelope, want)
+	}
+	if uploader.size >= len(large) {
+		t.Errorf("got size=%d, want compressed body", uploader.size)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(uploader.body))
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if !bytes.Equal(body, large) {
+		t.Errorf("got %d bytes, want the uncompressed body", len(body))
+	}
+}
+
+func TestResponseOffloadSniffed(t *testing.T) {
+	large := append([]byte("<html>"), bytes.Repeat([]byte("x"), MaxResponseSize)...)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(large)
+	})
+	uploader := &fakeUploader{}
+	handler := apiGatewayHandler(h, newOptions([]Option{WithResponseOffload(uploader, OffloadEnvelope)}))
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/"})
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got, want := uploader.contentType, "text/html; charset=utf-8"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+	var envelope offloadEnvelope
+	if err := json.Unmarshal([]byte(response.Body), &envelope); err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got, want := envelope.ContentType, "text/html; charset=utf-8"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
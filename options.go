@@ -0,0 +1,285 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"net"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// An Option configures how Lambda events are converted into HTTP
+// requests, and how the HTTP responses are converted back.
+type Option func(*options)
+
+type options struct {
+	snsPath            string
+	scheduledPath      string
+	healthCheckPath    string
+	livenessPath       string
+	readinessPath      string
+	readinessChecks    []readinessCheck
+	checkTimeout       time.Duration
+	flushes            []telemetryFlush
+	telemetryAPI       bool
+	telemetry          *telemetryCollector // set when the extension has started
+	debugPath          string
+	debugSecret        string
+	webSocketRoutes    map[string]webSocketRoute
+	appSyncRoutes      map[string]appSyncRoute
+	connectionStore    ConnectionStore
+	bufferPool         BufferPool
+	offloadUploader    ResponseUploader
+	offloadMode        OffloadMode
+	decompression      *DecompressionLimits
+	compressionMinSize int
+	originalRequestURI bool
+	resourcePath       bool
+	queryPassthrough   bool
+	queryArrays        bool
+	headerMode         HeaderMode
+	onHeaderViolation  func(HeaderViolation)
+	headerValueLimit   int
+	headerCountLimit   int
+	strict             Unsupported
+	noSniff            bool
+	originalHeaderCase bool
+	hostHeader         bool
+	contentLength      bool
+	forwardedHeader    bool
+	urlScheme          bool
+	trustedProxies     []*net.IPNet
+	clientIPHeaders    []string
+	strictEvents       bool
+	errorTemplate      *template.Template
+	errorContentType   string
+	onPanic            func(context.Context, *PanicRecord)
+	onError            func(error, RequestSnapshot)
+	newLogger          func(RequestInfo) *slog.Logger
+	logCorrelation     bool
+	bodyLogging        *BodyLogging
+	base64Encoding     *base64.Encoding
+	plainTextStatus    uint16
+	spanHooks          *SpanHooks
+	deadlineThreshold  time.Duration
+	onDeadline         func(context.Context, time.Duration)
+	usageReporter      UsageReporter
+	leakGrace          time.Duration
+	onLeak             func(*GoroutineLeak)
+	unreadBodyMinSize  int64
+	onUnreadBody       func(*http.Request, int64)
+	mountPath          string
+	pathNormalization  bool
+	trailingSlash      TrailingSlash
+	basePathMapping    string
+	stageHandlers      map[string]http.Handler
+	idempotencyStore   ResponseStore
+	idempotencyTTL     time.Duration
+	cacheStore         ResponseStore
+	cacheTTL           time.Duration
+	cacheVary          []string
+	rateLimit          *RateLimit
+	circuitBreaker     *CircuitBreaker
+	maintenance        *Maintenance
+	configSource       ConfigSource
+	configTTL          time.Duration
+	preloads           []func(context.Context) error
+	preloadAttempts    int
+	preloadBackoff     time.Duration
+	jsonCodec          JSONCodec
+	localFaults        *LocalFaults
+	sseKeepAlive       time.Duration
+	streamWrites       bool
+	streamingMultipart bool
+	checksums          bool
+	recorder           *recorder
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		snsPath:        "/sns/{topic}",
+		scheduledPath:  "/internal/cron/{rule}",
+		bufferPool:     defaultBufferPool,
+		base64Encoding: base64.StdEncoding,
+		jsonCodec:      stdJSONCodec{},
+		sseKeepAlive:   DefaultSSEKeepAlive,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// wrap returns a handler that applies the features configured in o
+// before calling h.
+func (o *options) wrap(h http.Handler) http.Handler {
+	// readiness is determined by the handler passed in, not the middleware
+	// that wraps it
+	ready := readyFunc(h)
+	if len(o.preloads) > 0 {
+		o.preload()
+	}
+	if len(o.stageHandlers) > 0 {
+		h = stageHandler(h, o.stageHandlers)
+	}
+	if o.noSniff {
+		h = noSniffHandler(h)
+	}
+	if o.strict != 0 {
+		h = strictHandler(h, o.strict)
+	}
+	if o.onLeak != nil {
+		h = leakHandler(h, o)
+	}
+	if o.circuitBreaker != nil {
+		h = breakerHandler(h, newBreaker(*o.circuitBreaker))
+	}
+	if o.maintenance != nil {
+		h = maintenanceHandler(h, o.maintenance)
+	}
+	if o.debugPath != "" {
+		h = debugHandler(h, o.debugPath, o.debugSecret)
+	}
+	if o.healthCheckPath != "" || o.livenessPath != "" {
+		h = healthCheckHandler(h, ready, o)
+	}
+	if o.cacheStore != nil {
+		h = cacheHandler(h, o)
+	}
+	if o.idempotencyStore != nil {
+		h = idempotencyHandler(h, o)
+	}
+	if o.rateLimit != nil {
+		h = rateLimitHandler(h, newRateLimiter(*o.rateLimit))
+	}
+	if o.onDeadline != nil {
+		h = deadlineHandler(h, o)
+	}
+	if o.bodyLogging != nil {
+		h = bodyLogHandler(h, o.bodyLogging)
+	}
+	if o.configSource != nil {
+		h = configHandler(h, newConfigLoader(o.configSource, o.configTTL))
+	}
+	if o.newLogger != nil {
+		h = loggerHandler(h, o)
+	}
+	if o.mountPath != "" {
+		h = mountHandler(h, o.mountPath)
+	}
+	if o.queryArrays {
+		h = queryArraysHandler(h)
+	}
+	if o.pathNormalization || o.trailingSlash != TrailingSlashPreserve {
+		h = normalizePathHandler(h, o)
+	}
+	if len(o.trustedProxies) > 0 {
+		h = clientIPHandler(h, o)
+	}
+	if o.recorder != nil {
+		h = recordHandler(h, o.recorder)
+	}
+	if o.localFaults != nil {
+		h = localFaultsHandler(h, o.localFaults)
+	}
+	if o.decompression != nil {
+		h = decompressHandler(h, o.decompression)
+	}
+	if o.checksums {
+		h = checksumHandler(h)
+	}
+	h = sseHandler(h, o.sseKeepAlive)
+	h = localTLSHandler(h)
+	return countRequests(traceContextHandler(baggageHandler(h)))
+}
+
+// Handler returns a HTTP handler that applies the features configured
+// by opts before calling h. The Start functions use this handler
+// when running in AWS Lambda. Use it when running as a conventional
+// HTTP server so that the handler behaves the same way in both modes.
+func Handler(h http.Handler, opts ...Option) http.Handler {
+	return newOptions(opts).wrap(h)
+}
+
+// WithSNSPath sets the path of the HTTP request created for each SNS
+// notification. Any occurrence of "{topic}" in path is replaced with the
+// name of the SNS topic. The default path is "/sns/{topic}".
+func WithSNSPath(path string) Option {
+	return func(o *options) {
+		o.snsPath = path
+	}
+}
+
+// WithScheduledPath sets the path of the HTTP GET request created for each
+// scheduled EventBridge event. Any occurrence of "{rule}" in path is replaced
+// with the name of the EventBridge rule that triggered the event.
+// The default path is "/internal/cron/{rule}".
+func WithScheduledPath(path string) Option {
+	return func(o *options) {
+		o.scheduledPath = path
+	}
+}
+
+// WithWebSocketRoute maps the WebSocket route key to the HTTP method
+// and path of the request passed to the HTTP handler. This allows an
+// existing HTTP router to dispatch WebSocket messages. Route keys that
+// are not mapped are sent as POST requests to "/ws/{route}", where
+// {route} is the route key without any leading "$", so the "$connect"
+// route is sent as "POST /ws/connect".
+func WithWebSocketRoute(routeKey, method, path string) Option {
+	return func(o *options) {
+		if o.webSocketRoutes == nil {
+			o.webSocketRoutes = make(map[string]webSocketRoute)
+		}
+		o.webSocketRoutes[routeKey] = webSocketRoute{
+			method: method,
+			path:   path,
+		}
+	}
+}
+
+// WithOriginalRequestURI configures the adapter to set the RequestURI field
+// of the HTTP request to the path exactly as it appears in the request event,
+// including its original escaping, rather than the path as re-encoded
+// by net/url. The path is not changed by WithBasePathMapping, WithResourcePath
+// or WithTrailingSlash, which only change the URL of the request. This is
+// useful for handlers that use RequestURI for signature verification or
+// audit logging.
+//
+// API Gateway REST API (payload version 1.0) events do not include the
+// original query string, so the query string part of RequestURI is
+// reconstructed from the query string parameters.
+func WithOriginalRequestURI() Option {
+	return func(o *options) {
+		o.originalRequestURI = true
+	}
+}
+
+// WithHostHeader configures the adapter to include the Host header in the
+// HTTP request header map, as well as setting the request's Host field.
+// This was the behavior of earlier versions of this package. By default
+// the Host header is removed from the header map, which is consistent
+// with the requests received by a net/http server.
+func WithHostHeader() Option {
+	return func(o *options) {
+		o.hostHeader = true
+	}
+}
+
+// WithBase64Encoding sets the encoding used to decode base64 encoded request
+// bodies, and to encode binary response bodies. The default is
+// base64.StdEncoding, which is what API Gateway uses. Other encodings, such
+// as base64.RawURLEncoding, are useful when events are produced or consumed
+// by tools other than API Gateway. If enc is nil, the default is used.
+func WithBase64Encoding(enc *base64.Encoding) Option {
+	if enc == nil {
+		enc = base64.StdEncoding
+	}
+	return func(o *options) {
+		o.base64Encoding = enc
+	}
+}
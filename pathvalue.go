@@ -0,0 +1,16 @@
+//go:build go1.22
+
+package apigatewayproxy
+
+import "net/http"
+
+// setPathValues sets the path values of r to the path parameters of the
+// request event, so that r.PathValue returns the value of each parameter in
+// the API Gateway resource path. If the request is routed by a ServeMux, the
+// values of the wildcards in the matching pattern replace the path values with
+// the same name, and the other path values are unchanged.
+func setPathValues(r *http.Request, params map[string]string) {
+	for name, value := range params {
+		r.SetPathValue(name, value)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !go1.22
+
+package apigatewayproxy
+
+import "net/http"
+
+// setPathValues does nothing, because path values were added in Go 1.22.
+func setPathValues(r *http.Request, params map[string]string) {}
@@ -0,0 +1,57 @@
+//go:build go1.22
+
+// ServeMux patterns are disabled for modules that declare an earlier Go version
+//go:debug httpmuxgo121=0
+
+package apigatewayproxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestPathValues(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}/orders/{order}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.PathValue("id")+" "+r.PathValue("order")+" "+r.PathValue("tenant"))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.PathValue("proxy"))
+	})
+	handler := apiGatewayHandler(mux, newOptions(nil))
+
+	tests := []struct {
+		path   string
+		params map[string]string
+		want   string
+	}{
+		{
+			// the ServeMux wildcards take precedence
+			path:   "/users/123/orders/456",
+			params: map[string]string{"id": "ignored", "tenant": "acme"},
+			want:   "123 456 acme",
+		},
+		{
+			path:   "/files/a/b.txt",
+			params: map[string]string{"proxy": "files/a/b.txt"},
+			want:   "files/a/b.txt",
+		},
+	}
+	for i, tt := range tests {
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod:     "GET",
+			Path:           tt.path,
+			PathParameters: tt.params,
+		})
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package apigatewayproxy
+
+// WithPlainTextStatus configures the adapter to never base64 encode the
+// response body when the response status code is in one of the status
+// classes, where a class is the first digit of the status code. For example,
+// WithPlainTextStatus(4, 5) returns all 4xx and 5xx response bodies as plain
+// text, which makes error payloads much easier to read in API Gateway and
+// CloudWatch logs.
+//
+// Responses with a status code in one of the classes are not compressed,
+// and the body is returned as a string regardless of its content or of
+// ShouldEncodeBody. Any bytes in the body that are not valid UTF-8 are
+// replaced with the Unicode replacement character when the response is
+// serialized, so this option is not suitable for binary error payloads.
+func WithPlainTextStatus(classes ...int) Option {
+	return func(o *options) {
+		for _, class := range classes {
+			if class >= 1 && class <= 9 {
+				o.plainTextStatus |= 1 << uint(class)
+			}
+		}
+	}
+}
+
+// plainText reports whether the response body for the status code must
+// not be base64 encoded.
+func (o *options) plainText(status int) bool {
+	class := status / 100
+	return o.plainTextStatus != 0 && class >= 1 && class <= 9 && o.plainTextStatus&(1<<uint(class)) != 0
+}
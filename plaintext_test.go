@@ -0,0 +1,56 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestPlainTextStatus(t *testing.T) {
+	large := strings.Repeat("not found ", 200)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/binary":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("bad\x01request"))
+		case "/binary-ok":
+			w.Write([]byte("ok\x01"))
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(large))
+		}
+	})
+	handler := apiGatewayHandler(h, newOptions([]Option{WithPlainTextStatus(4, 5), WithCompression(0)}))
+
+	tests := []struct {
+		path        string
+		wantBody    string
+		wantEncoded bool
+	}{
+		{path: "/binary", wantBody: "bad\x01request"},
+		{path: "/large", wantBody: large},
+		{path: "/binary-ok", wantBody: "b2sB", wantEncoded: true},
+	}
+	for i, tt := range tests {
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			Path:    tt.path,
+			Headers: map[string]string{"Accept-Encoding": "gzip"},
+		})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, tt.wantBody; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if got, want := response.IsBase64Encoded, tt.wantEncoded; got != want {
+			t.Errorf("%d: got=%v, want=%v", i, got, want)
+		}
+		if got := response.Headers["Content-Encoding"]; got != "" {
+			t.Errorf("%d: got=%q, want no content encoding", i, got)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jjeffery/kv"
+)
+
+// Default preload retry settings.
+const (
+	DefaultPreloadAttempts = 3
+	DefaultPreloadBackoff  = 200 * time.Millisecond
+)
+
+// WithPreload configures the adapter to call fn when the handler is created,
+// before any request is received. For Lambda functions this is during init,
+// so the time taken counts against the init duration rather than the latency
+// of the first request. Use it to fetch secrets and certificates. If the
+// option is specified more than once, the functions are called in order.
+//
+// If fn returns an error, it is called again after a backoff that doubles
+// after each attempt (see WithPreloadRetry). If fn still fails, the error is
+// logged and the process exits, so that Lambda reports an init error and
+// starts a new container instead of handling requests without the secrets.
+func WithPreload(fn func(ctx context.Context) error) Option {
+	return func(o *options) {
+		o.preloads = append(o.preloads, fn)
+	}
+}
+
+// WithPreloadRetry sets the number of attempts made to call each preload
+// function, and the backoff before the second attempt. The defaults are
+// DefaultPreloadAttempts and DefaultPreloadBackoff.
+func WithPreloadRetry(attempts int, backoff time.Duration) Option {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return func(o *options) {
+		o.preloadAttempts = attempts
+		o.preloadBackoff = backoff
+	}
+}
+
+// preloadFailed is called when preloading cannot complete. It is a variable
+// so that it can be replaced in tests.
+var preloadFailed = func(err error) {
+	slog.Error("preload failed", "error", err)
+	os.Exit(1)
+}
+
+// preload calls the preload functions, retrying each until it succeeds
+// or the attempts are exhausted.
+func (o *options) preload() {
+	attempts, backoff := o.preloadAttempts, o.preloadBackoff
+	if attempts == 0 {
+		attempts, backoff = DefaultPreloadAttempts, DefaultPreloadBackoff
+	}
+	ctx := context.Background()
+	for i, fn := range o.preloads {
+		if err := retry(ctx, attempts, backoff, fn); err != nil {
+			preloadFailed(kv.Wrap(err, "cannot preload").With("preload", i, "attempts", attempts))
+			return
+		}
+	}
+}
+
+// retry calls fn up to attempts times, doubling the backoff between calls,
+// and returns the last error.
+func retry(ctx context.Context, attempts int, backoff time.Duration, fn func(context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil || attempt >= attempts {
+			return err
+		}
+		Logger(ctx).Warn("preload attempt failed", "attempt", attempt, "error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
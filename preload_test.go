@@ -0,0 +1,50 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPreload(t *testing.T) {
+	var failed error
+	defer func(f func(error)) { preloadFailed = f }(preloadFailed)
+	preloadFailed = func(err error) { failed = err }
+
+	tests := []struct {
+		failures  int // number of calls that fail
+		wantCalls int
+		wantErr   bool
+	}{
+		{failures: 0, wantCalls: 1},
+		{failures: 2, wantCalls: 3},
+		{failures: 5, wantCalls: 3, wantErr: true},
+	}
+	for i, tt := range tests {
+		failed = nil
+		var calls int
+		var secret string
+		Handler(http.NotFoundHandler(),
+			WithPreloadRetry(3, time.Millisecond),
+			WithPreload(func(ctx context.Context) error {
+				calls++
+				if calls <= tt.failures {
+					return errors.New("unavailable")
+				}
+				secret = "s3cret"
+				return nil
+			}),
+		)
+		if got, want := calls, tt.wantCalls; got != want {
+			t.Errorf("%d: got %d calls, want %d", i, got, want)
+		}
+		if got, want := failed != nil, tt.wantErr; got != want {
+			t.Errorf("%d: got failed=%v, want %v", i, failed, want)
+		}
+		if !tt.wantErr && secret == "" {
+			t.Errorf("%d: preload did not complete", i)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package apigatewayproxy
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// WithResourcePath configures the adapter to reconstruct the path of the HTTP
+// request from the API Gateway resource template and the path parameters,
+// instead of using the path in the request event.
+//
+// For example, if the resource is "/api/{proxy+}" and the "proxy" path parameter
+// is "users/123", the request path is "/api/users/123". This gives consistent
+// routing across REST and HTTP API configurations, where the path in the event
+// can include the stage name or be normalized differently from the path
+// parameters. If the event does not have a resource template, or a path
+// parameter is missing, the path in the event is used.
+func WithResourcePath() Option {
+	return func(o *options) {
+		o.resourcePath = true
+	}
+}
+
+// requestPath returns the escaped path for the HTTP request.
+func requestPath(request *events.APIGatewayProxyRequest, o *options) string {
+	path, ok := "", false
+	if o.resourcePath {
+		path, ok = resourcePath(request.Resource, request.PathParameters)
+	}
+	if !ok {
+		path = stripBasePath(request.Path, o)
+	}
+	if o.trailingSlash == TrailingSlashStrip {
+		path = stripTrailingSlash(path)
+	}
+	return path
+}
+
+// resourcePath substitutes the path parameters into the resource template
+// and returns the escaped path. It returns false if the template cannot
+// be expanded.
+func resourcePath(resource string, params map[string]string) (string, bool) {
+	if resource == "" || !strings.Contains(resource, "{") {
+		return "", false
+	}
+	var sb strings.Builder
+	for resource != "" {
+		i := strings.IndexByte(resource, '{')
+		if i < 0 {
+			sb.WriteString(resource)
+			break
+		}
+		j := strings.IndexByte(resource[i:], '}')
+		if j < 0 {
+			return "", false
+		}
+		sb.WriteString(resource[:i])
+		name := resource[i+1 : i+j]
+		greedy := strings.HasSuffix(name, "+")
+		name = strings.TrimSuffix(name, "+")
+		value, ok := params[name]
+		if !ok {
+			return "", false
+		}
+		if greedy {
+			// a greedy parameter spans path segments, so escape each segment
+			segments := strings.Split(value, "/")
+			for k, segment := range segments {
+				segments[k] = url.PathEscape(segment)
+			}
+			sb.WriteString(strings.Join(segments, "/"))
+		} else {
+			sb.WriteString(url.PathEscape(value))
+		}
+		resource = resource[i+j+1:]
+	}
+	return sb.String(), true
+}
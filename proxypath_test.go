@@ -0,0 +1,75 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestResourcePath(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.RequestURI))
+	})
+	tests := []struct {
+		opts    []Option
+		request events.APIGatewayProxyRequest
+		want    string
+	}{
+		{
+			// path from the event by default
+			request: events.APIGatewayProxyRequest{
+				Path:           "/prod/api/users/123",
+				Resource:       "/api/{proxy+}",
+				PathParameters: map[string]string{"proxy": "users/123"},
+			},
+			want: "/prod/api/users/123 /prod/api/users/123",
+		},
+		{
+			opts: []Option{WithResourcePath()},
+			request: events.APIGatewayProxyRequest{
+				Path:           "/prod/api/users/123",
+				Resource:       "/api/{proxy+}",
+				PathParameters: map[string]string{"proxy": "users/123"},
+			},
+			want: "/api/users/123 /api/users/123",
+		},
+		{
+			opts: []Option{WithResourcePath()},
+			request: events.APIGatewayProxyRequest{
+				Path:           "/prod/orgs/acme/users/a b",
+				Resource:       "/orgs/{org}/users/{id}",
+				PathParameters: map[string]string{"org": "acme", "id": "a b"},
+			},
+			want: "/orgs/acme/users/a b /orgs/acme/users/a%20b",
+		},
+		{
+			// missing parameter uses the event path
+			opts: []Option{WithResourcePath()},
+			request: events.APIGatewayProxyRequest{
+				Path:     "/users/123",
+				Resource: "/users/{id}",
+			},
+			want: "/users/123 /users/123",
+		},
+		{
+			opts: []Option{WithResourcePath()},
+			request: events.APIGatewayProxyRequest{
+				Path:     "/users",
+				Resource: "/users",
+			},
+			want: "/users /users",
+		},
+	}
+	for i, tt := range tests {
+		handler := apiGatewayHandler(h, newOptions(tt.opts))
+		response, err := handler(context.Background(), tt.request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
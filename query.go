@@ -0,0 +1,141 @@
+package apigatewayproxy
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// WithQueryPassthrough configures the adapter to build the query string of
+// the HTTP request without the normalization performed by url.Values.
+//
+// By default each query parameter is decoded and re-encoded in the same way
+// as url.Values, which drops parameters containing semicolons and escapes
+// reserved characters. In passthrough mode, any query string in the event
+// path is preserved exactly, and only the characters that cannot appear in
+// a query parameter (such as '&', '=', '#', '+', '%' and space) are escaped.
+// This suits APIs that use ';' as a sub-delimiter.
+//
+// Note that API Gateway REST API (payload version 1.0) events provide the query
+// parameters as maps, so the original order of the parameter names cannot be
+// recovered. Parameter names are emitted in sorted order.
+func WithQueryPassthrough() Option {
+	return func(o *options) {
+		o.queryPassthrough = true
+	}
+}
+
+// requestQuery returns the encoded query string for the HTTP request, given
+// the raw query that was present in the event path (if any).
+//
+// The order of the parameters is deterministic: parameters in the raw query
+// keep their original order, followed by the query string parameters from
+// the event sorted by name. Multiple values for the same name keep the order
+// in which they appear in the event.
+func requestQuery(rawQuery string, request *events.APIGatewayProxyRequest, o *options) string {
+	params := eventQueryParams(request)
+	if len(params) == 0 && (rawQuery == "" || o.queryPassthrough) {
+		return rawQuery
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	escape := url.QueryEscape
+	var sb strings.Builder
+	if o.queryPassthrough {
+		escape = escapeQueryComponent
+		sb.WriteString(rawQuery)
+	} else {
+		// keep the original order of the raw query, except for parameters
+		// that are replaced by the event parameters
+		for _, part := range strings.Split(rawQuery, "&") {
+			if part == "" || strings.Contains(part, ";") {
+				// semicolons are not permitted, as for url.ParseQuery
+				continue
+			}
+			k, v := part, ""
+			if i := strings.IndexByte(part, '='); i >= 0 {
+				k, v = part[:i], part[i+1:]
+			}
+			k, err1 := url.QueryUnescape(k)
+			v, err2 := url.QueryUnescape(v)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			if _, ok := params[k]; ok {
+				continue
+			}
+			writeQueryParam(&sb, escape(k), escape(v))
+		}
+	}
+	for _, k := range keys {
+		for _, v := range params[k] {
+			writeQueryParam(&sb, escape(k), escape(v))
+		}
+	}
+	return sb.String()
+}
+
+func writeQueryParam(sb *strings.Builder, k, v string) {
+	if sb.Len() > 0 {
+		sb.WriteByte('&')
+	}
+	sb.WriteString(k)
+	sb.WriteByte('=')
+	sb.WriteString(v)
+}
+
+// eventQueryParams returns the query string parameters in the request event.
+// The multi-value parameters are used if present, so that all values are
+// retained; otherwise the single value parameters are used.
+func eventQueryParams(request *events.APIGatewayProxyRequest) map[string][]string {
+	if len(request.MultiValueQueryStringParameters) > 0 {
+		return request.MultiValueQueryStringParameters
+	}
+	if len(request.QueryStringParameters) == 0 {
+		return nil
+	}
+	params := make(map[string][]string, len(request.QueryStringParameters))
+	for k, v := range request.QueryStringParameters {
+		params[k] = []string{v}
+	}
+	return params
+}
+
+// escapeQueryComponent escapes s for use as a query parameter name or value,
+// escaping only those characters that would change the meaning of the
+// query string, or that are not permitted in a URL.
+func escapeQueryComponent(s string) string {
+	const hex = "0123456789ABCDEF"
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if shouldEscapeQuery(s[i]) {
+			n++
+		}
+	}
+	if n == 0 {
+		return s
+	}
+	b := make([]byte, 0, len(s)+2*n)
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; shouldEscapeQuery(c) {
+			b = append(b, '%', hex[c>>4], hex[c&15])
+		} else {
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
+
+func shouldEscapeQuery(c byte) bool {
+	switch c {
+	case '&', '=', '#', '+', '%', ' ', '"', '<', '>', '\\', '^', '`', '{', '|', '}':
+		return true
+	}
+	return c < 0x20 || c >= 0x7f
+}
@@ -0,0 +1,114 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestQueryPassthrough(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RawQuery))
+	})
+	tests := []struct {
+		opts    []Option
+		request events.APIGatewayProxyRequest
+		want    string
+	}{
+		{
+			request: events.APIGatewayProxyRequest{
+				Path:                  "/",
+				QueryStringParameters: map[string]string{"b": "1;2", "a": "x y"},
+			},
+			want: "a=x+y&b=1%3B2",
+		},
+		{
+			opts: []Option{WithQueryPassthrough()},
+			request: events.APIGatewayProxyRequest{
+				Path:                  "/",
+				QueryStringParameters: map[string]string{"b": "1;2", "a": "x y"},
+			},
+			want: "a=x%20y&b=1;2",
+		},
+		{
+			opts: []Option{WithQueryPassthrough()},
+			request: events.APIGatewayProxyRequest{
+				Path:                            "/",
+				QueryStringParameters:           map[string]string{"id": "2"},
+				MultiValueQueryStringParameters: map[string][]string{"id": {"3", "1", "2"}, "q": {"a&b=c"}},
+			},
+			want: "id=3&id=1&id=2&q=a%26b%3Dc",
+		},
+		{
+			opts:    []Option{WithQueryPassthrough()},
+			request: events.APIGatewayProxyRequest{Path: "/path?z=1;a=2&y"},
+			want:    "z=1;a=2&y",
+		},
+		{
+			// semicolons are dropped by url.Values
+			request: events.APIGatewayProxyRequest{Path: "/path?z=1;a=2&y"},
+			want:    "y=",
+		},
+	}
+	for i, tt := range tests {
+		handler := apiGatewayHandler(h, newOptions(tt.opts))
+		response, err := handler(context.Background(), tt.request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestQueryOrder(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RawQuery))
+	})
+	tests := []struct {
+		request events.APIGatewayProxyRequest
+		want    string
+	}{
+		{
+			request: events.APIGatewayProxyRequest{
+				Path:                  "/",
+				QueryStringParameters: map[string]string{"c": "3", "a": "1", "b": "2"},
+			},
+			want: "a=1&b=2&c=3",
+		},
+		{
+			// multi-values retained in their original order
+			request: events.APIGatewayProxyRequest{
+				Path:                            "/",
+				QueryStringParameters:           map[string]string{"id": "2", "b": "x"},
+				MultiValueQueryStringParameters: map[string][]string{"id": {"3", "1", "2"}, "b": {"x"}},
+			},
+			want: "b=x&id=3&id=1&id=2",
+		},
+		{
+			// raw query keeps the original order, event parameters replace
+			// raw query parameters and follow in sorted order
+			request: events.APIGatewayProxyRequest{
+				Path:                  "/path?z=1&a=2&m=x+y",
+				QueryStringParameters: map[string]string{"m": "3", "b": "4"},
+			},
+			want: "z=1&a=2&b=4&m=3",
+		},
+	}
+	for i, tt := range tests {
+		handler := apiGatewayHandler(h, newOptions(nil))
+		for n := 0; n < 10; n++ {
+			response, err := handler(context.Background(), tt.request)
+			if err != nil {
+				t.Fatalf("%d: got %v, want no error", i, err)
+			}
+			if got, want := response.Body, tt.want; got != want {
+				t.Errorf("%d: got=%q, want=%q", i, got, want)
+				break
+			}
+		}
+	}
+}
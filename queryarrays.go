@@ -0,0 +1,117 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WithQueryArrays configures the adapter to decode the bracketed array
+// parameters in the query string of each request before calling the
+// handler, in AWS Lambda and when running as a conventional HTTP server.
+// Use QueryArrays to obtain the decoded parameters. See DecodeQueryArrays
+// for the conventions supported.
+//
+// The query string of the request is not changed, so r.URL.Query and
+// r.FormValue still return the parameters with their original names.
+func WithQueryArrays() Option {
+	return func(o *options) {
+		o.queryArrays = true
+	}
+}
+
+// QueryArrays returns the query parameters of the request associated with
+// ctx, decoded by DecodeQueryArrays. It returns nil unless the adapter is
+// configured using WithQueryArrays.
+func QueryArrays(ctx context.Context) url.Values {
+	values, _ := ctx.Value(ctxKeyQueryArrays).(url.Values)
+	return values
+}
+
+// DecodeQueryArrays returns the query parameters with the array conventions
+// used by JavaScript clients, such as jQuery and the qs package, decoded.
+// Parameters named "key[]", as in "key[]=a&key[]=b", and indexed parameters
+// such as "key[0]=a&key[1]=b" are returned as the values of "key". Indexed
+// values are ordered by index, whatever their order in the query string.
+// Other parameters, including object notation such as "key[name]=a", are
+// returned unchanged.
+//
+// If a query string mixes conventions for the same name, the values of
+// "key" come first, followed by the values of "key[]" and then the indexed
+// values.
+func DecodeQueryArrays(query url.Values) url.Values {
+	type element struct {
+		index int // -1 for "key[]"
+		key   string
+		value string
+	}
+	var arrays map[string][]element
+	result := make(url.Values, len(query))
+	for k, vv := range query {
+		name, index, ok := parseArrayKey(k)
+		if !ok {
+			result[k] = append(result[k], vv...)
+			continue
+		}
+		if arrays == nil {
+			arrays = make(map[string][]element)
+		}
+		for _, v := range vv {
+			arrays[name] = append(arrays[name], element{index: index, key: k, value: v})
+		}
+	}
+	for name, elements := range arrays {
+		sort.SliceStable(elements, func(i, j int) bool {
+			a, b := &elements[i], &elements[j]
+			if a.index != b.index {
+				return a.index < b.index
+			}
+			// "key[0]" and "key[00]" have the same index
+			return a.key < b.key
+		})
+		values := result[name]
+		for i := range elements {
+			values = append(values, elements[i].value)
+		}
+		result[name] = values
+	}
+	return result
+}
+
+// parseArrayKey returns the name of an array parameter, such as "key" for
+// "key[]" or "key[1]", and its index, which is -1 for "key[]". It returns
+// false if the parameter is not an array parameter.
+func parseArrayKey(k string) (name string, index int, ok bool) {
+	if !strings.HasSuffix(k, "]") {
+		return "", 0, false
+	}
+	i := strings.LastIndexByte(k, '[')
+	if i <= 0 {
+		return "", 0, false
+	}
+	inner := k[i+1 : len(k)-1]
+	if inner == "" {
+		return k[:i], -1, true
+	}
+	for j := 0; j < len(inner); j++ {
+		if inner[j] < '0' || inner[j] > '9' {
+			return "", 0, false
+		}
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return "", 0, false
+	}
+	return k[:i], index, true
+}
+
+// queryArraysHandler decodes the query arrays before calling h.
+func queryArraysHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := DecodeQueryArrays(r.URL.Query())
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyQueryArrays, values)))
+	})
+}
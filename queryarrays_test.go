@@ -0,0 +1,78 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestDecodeQueryArrays(t *testing.T) {
+	tests := []struct {
+		query string
+		want  url.Values
+	}{
+		{query: "", want: url.Values{}},
+		{query: "a=1&a=2", want: url.Values{"a": {"1", "2"}}},
+		{query: "ids[]=3&ids[]=1&ids[]=2", want: url.Values{"ids": {"3", "1", "2"}}},
+		{query: "ids%5B%5D=1&ids%5B%5D=2", want: url.Values{"ids": {"1", "2"}}},
+		{query: "ids[2]=c&ids[0]=a&ids[10]=d&ids[1]=b", want: url.Values{"ids": {"a", "b", "c", "d"}}},
+		{query: "ids[1]=b&ids[]=x&ids=y&ids[0]=a", want: url.Values{"ids": {"y", "x", "a", "b"}}},
+		{query: "user[name]=bob&user[roles][]=admin", want: url.Values{"user[name]": {"bob"}, "user[roles]": {"admin"}}},
+		{query: "[]=1&a[-1]=2", want: url.Values{"[]": {"1"}, "a[-1]": {"2"}}},
+	}
+	for i, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := DecodeQueryArrays(query), tt.want; !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestWithQueryArrays(t *testing.T) {
+	var got url.Values
+	var rawQuery string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = QueryArrays(r.Context())
+		rawQuery = r.URL.RawQuery
+	})
+	want := url.Values{"tag": {"a", "b"}, "q": {"x"}}
+
+	o := newOptions([]Option{WithQueryArrays()})
+	if _, err := apiGatewayHandler(o.wrap(h), o)(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/items",
+		MultiValueQueryStringParameters: map[string][]string{
+			"tag[1]": {"b"},
+			"tag[0]": {"a"},
+			"q":      {"x"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if wantQuery := "q=x&tag%5B0%5D=a&tag%5B1%5D=b"; rawQuery != wantQuery {
+		t.Errorf("got query %q, want %q", rawQuery, wantQuery)
+	}
+
+	got = nil
+	Handler(h, WithQueryArrays()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items?tag[]=a&tag[]=b&q=x", nil))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got = nil
+	Handler(h).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items?tag[]=a", nil))
+	if got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
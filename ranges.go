@@ -0,0 +1,31 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// partialContent reports whether the response body is part of the resource,
+// because the handler answered a Range request. This is a 206 (Partial
+// Content) response, or any response with a Content-Range header, such as
+// a 416 (Range Not Satisfiable) response.
+//
+// The byte ranges refer to the body as written by the handler, so a partial
+// body is never compressed, its content type is not sniffed, and a binary
+// body is always base64 encoded, even if the status is configured using
+// WithPlainTextStatus or ShouldEncodeBody returns false.
+func (w *responseWriter) partialContent() bool {
+	if w.response.StatusCode == http.StatusPartialContent {
+		return true
+	}
+	_, ok := w.response.Headers["Content-Range"]
+	return ok
+}
+
+// acceptsRanges reports whether the handler accepts Range requests for the
+// resource. The response is not compressed, so that the byte ranges of
+// later requests refer to the body that the client received.
+func (w *responseWriter) acceptsRanges() bool {
+	v := w.response.Headers["Accept-Ranges"]
+	return v != "" && !strings.EqualFold(v, "none")
+}
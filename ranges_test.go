@@ -0,0 +1,132 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestRangeRequests(t *testing.T) {
+	// binary content, except for a text section at offset 256
+	content := make([]byte, 1024)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	copy(content[256:], strings.Repeat("text ", 20))
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "video.mp4", time.Time{}, bytes.NewReader(content))
+	})
+	tests := []struct {
+		rangeHeader  string
+		status       int
+		contentRange string
+		body         []byte
+		base64       bool
+	}{
+		{
+			rangeHeader:  "bytes=0-99",
+			status:       http.StatusPartialContent,
+			contentRange: "bytes 0-99/1024",
+			body:         content[:100],
+			base64:       true,
+		},
+		{
+			// a partial body that happens to be text is returned unchanged
+			rangeHeader:  "bytes=256-355",
+			status:       http.StatusPartialContent,
+			contentRange: "bytes 256-355/1024",
+			body:         content[256:356],
+			base64:       false,
+		},
+		{
+			rangeHeader:  "bytes=1000-",
+			status:       http.StatusPartialContent,
+			contentRange: "bytes 1000-1023/1024",
+			body:         content[1000:],
+			base64:       true,
+		},
+		{
+			rangeHeader:  "bytes=2000-",
+			status:       http.StatusRequestedRangeNotSatisfiable,
+			contentRange: "bytes */1024",
+		},
+	}
+	// partial binary bodies are base64 encoded, even though 2xx bodies are
+	// configured as plain text, and they are not compressed
+	opts := []Option{WithCompression(1), WithPlainTextStatus(2, 4), WithContentLength()}
+	handler := apiGatewayHandler(h, newOptions(opts))
+	for i, tt := range tests {
+		headers := map[string]string{"Accept-Encoding": "gzip"}
+		if tt.rangeHeader != "" {
+			headers["Range"] = tt.rangeHeader
+		}
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Path:       "/video.mp4",
+			Headers:    headers,
+		})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.StatusCode, tt.status; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if got, want := response.Headers["Content-Range"], tt.contentRange; got != want {
+			t.Errorf("%d: got Content-Range %q, want %q", i, got, want)
+		}
+		if got, want := response.Headers["Content-Encoding"], ""; got != want {
+			t.Errorf("%d: got Content-Encoding %q, want %q", i, got, want)
+		}
+		if tt.body == nil {
+			continue
+		}
+		if got, want := response.Headers["Accept-Ranges"], "bytes"; got != want {
+			t.Errorf("%d: got Accept-Ranges %q, want %q", i, got, want)
+		}
+		if got, want := response.Headers["Content-Type"], "video/mp4"; got != want {
+			t.Errorf("%d: got Content-Type %q, want %q", i, got, want)
+		}
+		if got, want := response.IsBase64Encoded, tt.base64; got != want {
+			t.Errorf("%d: got IsBase64Encoded %v, want %v", i, got, want)
+		}
+		body := []byte(response.Body)
+		if response.IsBase64Encoded {
+			body, _ = newOptions(nil).base64Encoding.DecodeString(response.Body)
+		}
+		if !bytes.Equal(body, tt.body) {
+			t.Errorf("%d: got body %q, want %q", i, body, tt.body)
+		}
+		if got, want := response.Headers["Content-Length"], strconv.Itoa(len(tt.body)); got != want {
+			t.Errorf("%d: got Content-Length %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestPartialContentNotSniffed(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "bytes 100-104/1000")
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte("hello"))
+	})
+	handler := apiGatewayHandler(h, newOptions([]Option{WithPlainTextStatus(2)}))
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/",
+		Headers:    map[string]string{"Range": "bytes=100-104"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := response.Headers["Content-Type"]; ok {
+		t.Errorf("got Content-Type %q, want none", got)
+	}
+	if got, want := response.Body, "hello"; got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+}
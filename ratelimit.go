@@ -0,0 +1,149 @@
+package apigatewayproxy
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit configures per-key rate limiting using a token bucket. Each
+// key has a bucket holding up to Burst tokens, which refills at Rate tokens
+// per second. Each request takes one token, and requests that arrive when
+// the bucket is empty receive a 429 (Too Many Requests) response.
+type RateLimit struct {
+	Rate  float64 // tokens added per second
+	Burst int     // maximum number of tokens, which must be at least one
+
+	// Key returns the key that the request is limited by. Requests with
+	// an empty key are not limited. If Key is nil, RateLimitKey is used.
+	Key func(r *http.Request) string
+}
+
+// RateLimitKey is the default key for rate limiting. It is the API key of
+// the request, if any, otherwise the IP address of the client.
+func RateLimitKey(r *http.Request) string {
+	if request := Request(r.Context()); request != nil {
+		if key := request.RequestContext.Identity.APIKey; key != "" {
+			return "apikey:" + key
+		}
+	}
+	if key := r.Header.Get("X-Api-Key"); key != "" {
+		return "apikey:" + key
+	}
+	if ip := ClientIP(r); ip != "" {
+		return "ip:" + ip
+	}
+	return ""
+}
+
+// WithRateLimit configures the adapter to limit the rate at which requests
+// are passed to the handler. The limit is enforced in the memory of each
+// Lambda container, so it complements, rather than replaces, API Gateway
+// usage plans.
+//
+// Responses include X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset headers, where the reset time is the number of seconds
+// until the bucket is full. Responses to requests that exceed the limit
+// also include a Retry-After header.
+func WithRateLimit(limit RateLimit) Option {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	if limit.Key == nil {
+		limit.Key = RateLimitKey
+	}
+	return func(o *options) {
+		o.rateLimit = &limit
+	}
+}
+
+// maxRateLimitBuckets is the number of buckets at which full buckets are
+// removed, to limit memory use.
+const maxRateLimitBuckets = 10000
+
+// rateLimiter holds the token buckets.
+type rateLimiter struct {
+	limit   RateLimit
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// take takes a token from the bucket for key. It reports whether a token
+// was available, and returns the number of tokens remaining.
+func (l *rateLimiter) take(key string) (bool, float64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.now()
+	burst := float64(l.limit.Burst)
+	b, found := l.buckets[key]
+	if !found {
+		if len(l.buckets) >= maxRateLimitBuckets {
+			l.prune(now)
+		}
+		b = &tokenBucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, b.tokens
+	}
+	b.tokens--
+	return true, b.tokens
+}
+
+// seconds returns the number of whole seconds taken to add tokens to
+// a bucket, rounded up.
+func (l *rateLimiter) seconds(tokens float64) string {
+	if l.limit.Rate <= 0 || tokens <= 0 {
+		return "0"
+	}
+	return strconv.Itoa(int(math.Ceil(tokens / l.limit.Rate)))
+}
+
+// prune removes the buckets that are full.
+func (l *rateLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.limit.Rate >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimitHandler rejects requests that exceed the rate limit.
+func rateLimitHandler(h http.Handler, l *rateLimiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := l.limit.Key(r)
+		if key == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		ok, tokens := l.take(key)
+		header := w.Header()
+		header.Set("X-RateLimit-Limit", strconv.Itoa(l.limit.Burst))
+		header.Set("X-RateLimit-Remaining", strconv.Itoa(int(tokens)))
+		header.Set("X-RateLimit-Reset", l.seconds(float64(l.limit.Burst)-tokens))
+		if !ok {
+			header.Set("Retry-After", l.seconds(1-tokens))
+			WriteError(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
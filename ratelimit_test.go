@@ -0,0 +1,53 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(RateLimit{Rate: 0.5, Burst: 2, Key: RateLimitKey})
+	l.now = func() time.Time { return now }
+	h := rateLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), l)
+
+	tests := []struct {
+		advance       time.Duration
+		apiKey        string
+		wantStatus    int
+		wantRemaining string
+		wantReset     string
+		wantRetry     string
+	}{
+		{wantStatus: 200, wantRemaining: "1", wantReset: "2"},
+		{wantStatus: 200, wantRemaining: "0", wantReset: "4"},
+		{wantStatus: 429, wantRemaining: "0", wantReset: "4", wantRetry: "2"},
+		{apiKey: "tenant-a", wantStatus: 200, wantRemaining: "1", wantReset: "2"},
+		{advance: time.Second, wantStatus: 429, wantRemaining: "0", wantReset: "3", wantRetry: "1"},
+		{advance: time.Second, wantStatus: 200, wantRemaining: "0", wantReset: "4"},
+	}
+	for i, tt := range tests {
+		now = now.Add(tt.advance)
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.apiKey != "" {
+			r.Header.Set("X-Api-Key", tt.apiKey)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if got, want := w.Code, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		for name, want := range map[string]string{
+			"X-RateLimit-Limit":     "2",
+			"X-RateLimit-Remaining": tt.wantRemaining,
+			"X-RateLimit-Reset":     tt.wantReset,
+			"Retry-After":           tt.wantRetry,
+		} {
+			if got := w.Header().Get(name); got != want {
+				t.Errorf("%d: got %s=%q, want %q", i, name, got, want)
+			}
+		}
+	}
+}
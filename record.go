@@ -0,0 +1,184 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Recording is an event received by the adapter and the response returned,
+// as written by WithRecording. Replay accepts recordings as input, and
+// compares the replayed status with the recorded status.
+type Recording struct {
+	Time     time.Time       `json:"time"`
+	Event    json.RawMessage `json:"event"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// WithRecording configures the adapter to write a Recording of each request
+// to w, as JSON lines, to build a corpus of events for Replay and for golden
+// file tests. Requests received from Lambda are recorded as the Lambda event
+// and response payloads. Requests received by a local server are recorded as
+// the equivalent API Gateway proxy event (payload format version 1.0).
+//
+// Headers and cookies that contain credentials are redacted from the
+// recording, but bodies are recorded as is. Take care when recording in
+// Lambda: the recording may contain personal information, and writing each
+// recording adds to the latency of the request. Errors writing to w are
+// logged, and do not affect the response.
+func WithRecording(w io.Writer) Option {
+	return func(o *options) {
+		o.recorder = &recorder{w: w}
+	}
+}
+
+// recorder writes recordings.
+type recorder struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+// record writes the recording of an event and response in JSON format.
+func (rec *recorder) record(ctx context.Context, event, response []byte, err error) {
+	recording := Recording{
+		Time:     time.Now().UTC(),
+		Event:    redactJSON(event),
+		Response: redactJSON(response),
+	}
+	if err != nil {
+		recording.Error = err.Error()
+	}
+	b, merr := json.Marshal(&recording)
+	if merr != nil {
+		Logger(ctx).Warn("cannot marshal recording", "error", merr)
+		return
+	}
+	b = append(b, '\n')
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	if _, err := rec.w.Write(b); err != nil {
+		Logger(ctx).Warn("cannot write recording", "error", err)
+	}
+}
+
+// recordInvoke returns an invokeFunc that records each invocation, or
+// invoke itself if recording is not configured.
+func (o *options) recordInvoke(invoke invokeFunc) invokeFunc {
+	rec := o.recorder
+	if rec == nil {
+		return invoke
+	}
+	return func(ctx context.Context, payload []byte) ([]byte, error) {
+		response, err := invoke(ctx, payload)
+		rec.record(ctx, payload, response, err)
+		return response, err
+	}
+}
+
+// recordHandler records the requests received by a local server.
+func recordHandler(h http.Handler, rec *recorder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if ctx.Value(ctxKeyInvocation) != nil {
+			// recorded by recordInvoke
+			h.ServeHTTP(w, r)
+			return
+		}
+		ev, err := newProxyEvent(r)
+		if err != nil {
+			WriteError(w, r, http.StatusBadRequest, "cannot read request body")
+			return
+		}
+		if ev.IsBase64Encoded {
+			b, _ := base64.StdEncoding.DecodeString(ev.Body)
+			r.Body = io.NopCloser(bytes.NewReader(b))
+		} else if ev.Body != "" {
+			r.Body = io.NopCloser(strings.NewReader(ev.Body))
+		}
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ev.RequestContext.Identity.SourceIP = host
+		}
+		rw := &recordingWriter{ResponseWriter: w}
+		h.ServeHTTP(rw, r)
+
+		event, _ := json.Marshal(ev)
+		rec.record(ctx, event, recordedResponse(rw.response()), nil)
+	})
+}
+
+// recordedResponse returns the API Gateway proxy response payload
+// equivalent to the response.
+func recordedResponse(response *StoredResponse) []byte {
+	resp := events.APIGatewayProxyResponse{
+		StatusCode:        response.StatusCode,
+		MultiValueHeaders: response.Header,
+	}
+	if utf8.Valid(response.Body) {
+		resp.Body = string(response.Body)
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(response.Body)
+		resp.IsBase64Encoded = true
+	}
+	b, _ := json.Marshal(&resp)
+	return b
+}
+
+// redactJSON returns the JSON payload with the values of headers that
+// contain credentials redacted. It works for all of the event and response
+// types, which have "headers" and "multiValueHeaders" fields, and "cookies"
+// fields in payload format version 2.0.
+func redactJSON(payload []byte) json.RawMessage {
+	if len(payload) == 0 {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(payload, &m); err != nil {
+		// not an object, such as the null response for SNS events
+		return payload
+	}
+	redact := func(key string) bool {
+		for _, name := range redactedHeaders {
+			if strings.EqualFold(key, name) {
+				return true
+			}
+		}
+		return false
+	}
+	changed := false
+	if headers, ok := m["headers"].(map[string]interface{}); ok {
+		for k := range headers {
+			if redact(k) {
+				headers[k], changed = Redacted, true
+			}
+		}
+	}
+	if headers, ok := m["multiValueHeaders"].(map[string]interface{}); ok {
+		for k := range headers {
+			if redact(k) {
+				headers[k], changed = []string{Redacted}, true
+			}
+		}
+	}
+	if cookies, ok := m["cookies"].([]interface{}); ok && len(cookies) > 0 {
+		m["cookies"], changed = []string{Redacted}, true
+	}
+	if !changed {
+		return payload
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return payload
+	}
+	return b
+}
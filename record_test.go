@@ -0,0 +1,88 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestRecordingLocal(t *testing.T) {
+	var buf bytes.Buffer
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}), WithRecording(&buf))
+
+	r := httptest.NewRequest("POST", "/items?a=1", strings.NewReader("hello"))
+	r.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if got, want := w.Body.String(), "hello"; got != want {
+		t.Fatalf("got body %q, want %q", got, want)
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("recording contains credentials: %s", buf.String())
+	}
+
+	var recording Recording
+	if err := json.Unmarshal(buf.Bytes(), &recording); err != nil {
+		t.Fatal(err)
+	}
+	var ev events.APIGatewayProxyRequest
+	if err := json.Unmarshal(recording.Event, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.HTTPMethod != "POST" || ev.Path != "/items" || ev.Body != "hello" || ev.QueryStringParameters["a"] != "1" {
+		t.Errorf("got event %+v", ev)
+	}
+	var resp events.APIGatewayProxyResponse
+	if err := json.Unmarshal(recording.Response, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusCreated || resp.Body != "hello" {
+		t.Errorf("got response %+v", resp)
+	}
+
+	// the recording can be replayed
+	results, err := Replay(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if res := results[0]; res.Err != nil || res.WantStatus != http.StatusCreated || res.GotStatus != http.StatusOK || !res.StatusChanged() {
+		t.Errorf("got %+v", res)
+	}
+}
+
+func TestRecordInvoke(t *testing.T) {
+	var buf bytes.Buffer
+	o := newOptions([]Option{WithRecording(&buf)})
+	invoke := o.recordInvoke(jsonInvoke(o.jsonCodec, apiGatewayHandler(http.NotFoundHandler(), o)))
+	payload := `{"httpMethod":"GET","path":"/","headers":{"x-api-key":"secret","accept":"*/*"}}`
+	if _, err := invoke(context.Background(), []byte(payload)); err != nil {
+		t.Fatal(err)
+	}
+	var recording Recording
+	if err := json.Unmarshal(buf.Bytes(), &recording); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(recording.Event); strings.Contains(got, "secret") || !strings.Contains(got, `"accept":"*/*"`) {
+		t.Errorf("got event %s", got)
+	}
+	if got := string(recording.Response); !strings.Contains(got, `"statusCode":404`) {
+		t.Errorf("got response %s", got)
+	}
+}
@@ -0,0 +1,74 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+)
+
+// redactedHeaders are the headers whose values are replaced in the
+// request snapshot passed to the panic hook, and in body logging.
+var redactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"X-Amz-Security-Token",
+	"Set-Cookie",
+	DebugSecretHeader,
+}
+
+// Redacted is the value of redacted headers and fields.
+const Redacted = "[REDACTED]"
+
+// PanicRecord describes a panic recovered from the HTTP handler.
+type PanicRecord struct {
+	Value   interface{}     // value passed to panic
+	Stack   []byte          // stack trace of the goroutine that panicked
+	Request RequestSnapshot // the request being handled
+}
+
+// RequestSnapshot is a copy of the request being handled when the handler
+// panicked or the invocation failed. Headers that contain credentials are redacted, so the
+// snapshot is safe to send to an error reporting service.
+type RequestSnapshot struct {
+	Method     string
+	URL        string
+	Header     http.Header
+	RemoteAddr string
+	Info       *RequestInfo // information about the event, if any
+}
+
+// WithPanicHook configures the adapter to recover panics in the HTTP handler,
+// and to call hook with a record of the panic. This makes it simple to report
+// panics to an error reporting service. After the hook returns, the adapter
+// returns a 500 (Internal Server Error) response, using the error response
+// configured by WithErrorResponse if present.
+func WithPanicHook(hook func(ctx context.Context, record *PanicRecord)) Option {
+	return func(o *options) {
+		o.onPanic = hook
+	}
+}
+
+// newPanicRecord returns the record of a panic while handling r. It must
+// be called from the deferred function so that the stack includes the
+// panicking function.
+func newPanicRecord(value interface{}, r *http.Request) *PanicRecord {
+	return &PanicRecord{
+		Value:   value,
+		Stack:   debug.Stack(),
+		Request: newRequestSnapshot(r),
+	}
+}
+
+// redactHeader returns a copy of header with the values of the named
+// headers redacted. The names must be in canonical form.
+func redactHeader(header http.Header, names []string) http.Header {
+	header = header.Clone()
+	for _, name := range names {
+		if _, ok := header[name]; ok {
+			header[name] = []string{Redacted}
+		}
+	}
+	return header
+}
@@ -0,0 +1,65 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestPanicHook(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	var record *PanicRecord
+	handler := apiGatewayHandler(h, newOptions([]Option{
+		WithPanicHook(func(ctx context.Context, r *PanicRecord) {
+			if Request(ctx) == nil {
+				t.Error("got nil request in context")
+			}
+			record = r
+		}),
+	}))
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Path:       "/users",
+		Headers: map[string]string{
+			"authorization": "Bearer secret",
+			"cookie":        "session=secret",
+			"accept":        "application/json",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{RequestID: "req-1"},
+	})
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got, want := response.StatusCode, http.StatusInternalServerError; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+	if record == nil {
+		t.Fatal("hook not called")
+	}
+	if got, want := record.Value, "boom"; got != want {
+		t.Errorf("got value %v, want %v", got, want)
+	}
+	if !strings.Contains(string(record.Stack), "TestPanicHook") {
+		t.Errorf("stack does not contain the panicking function:\n%s", record.Stack)
+	}
+	rs := record.Request
+	if rs.Method != "POST" || rs.URL != "/users" {
+		t.Errorf("got %s %s, want POST /users", rs.Method, rs.URL)
+	}
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if got, want := rs.Header.Get(name), Redacted; got != want {
+			t.Errorf("got %s=%q, want %q", name, got, want)
+		}
+	}
+	if got, want := rs.Header.Get("Accept"), "application/json"; got != want {
+		t.Errorf("got Accept=%q, want %q", got, want)
+	}
+	if rs.Info == nil || rs.Info.RequestID != "req-1" {
+		t.Errorf("got info %+v, want request ID req-1", rs.Info)
+	}
+}
@@ -0,0 +1,90 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/jjeffery/kv"
+)
+
+// Reloader is a HTTP handler for the local development server that applies
+// options loaded by a function, and can load them again without restarting
+// the process. This makes it quicker to change the settings that mimic the
+// API Gateway configuration, such as the base path mapping. Use
+// NewReloader to create a Reloader.
+//
+// Reloading creates the handler again, so state kept by features such as
+// WithRateLimit and WithCircuitBreaker is reset, and WithPreload functions
+// are called again. Requests in progress complete using the previous
+// options.
+type Reloader struct {
+	h       http.Handler
+	load    func() ([]Option, error)
+	handler atomic.Pointer[http.Handler]
+}
+
+// NewReloader returns a Reloader that applies the options returned by load
+// before calling h. The load function typically reads a configuration file.
+// It returns an error if the options cannot be loaded.
+func NewReloader(h http.Handler, load func() ([]Option, error)) (*Reloader, error) {
+	r := &Reloader{
+		h:    h,
+		load: load,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the options and applies them to subsequent requests. If the
+// options cannot be loaded, the previous options continue to be used.
+func (r *Reloader) Reload() error {
+	opts, err := r.load()
+	if err != nil {
+		return kv.Wrap(err, "cannot load options")
+	}
+	h := Handler(r.h, opts...)
+	r.handler.Store(&h)
+	return nil
+}
+
+// ReloadOnSignal reloads the options each time the process receives one of
+// the signals, until ctx is done. If no signals are specified, the options
+// are reloaded on SIGHUP. Errors are logged, and the previous options continue
+// to be used. ReloadOnSignal blocks, so it is usually called in a goroutine.
+func (r *Reloader) ReloadOnSignal(ctx context.Context, sig ...os.Signal) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sig...)
+	defer signal.Stop(c)
+	r.reloadOn(ctx, c)
+}
+
+// reloadOn reloads the options each time a signal is received from c,
+// until ctx is done.
+func (r *Reloader) reloadOn(ctx context.Context, c <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case s := <-c:
+			if err := r.Reload(); err != nil {
+				Logger(ctx).Error("cannot reload options", "signal", s.String(), "error", err)
+				continue
+			}
+			Logger(ctx).Info("reloaded options", "signal", s.String())
+		}
+	}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (r *Reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	(*r.handler.Load()).ServeHTTP(w, req)
+}
@@ -0,0 +1,74 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestReloader(t *testing.T) {
+	mountPath := "/v1"
+	var loadErr error
+	r, err := NewReloader(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}), func() ([]Option, error) {
+		return []Option{WithMountPath(mountPath)}, loadErr
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Code
+	}
+	if got := get("/v1/x"); got != http.StatusOK {
+		t.Errorf("got %d, want %d", got, http.StatusOK)
+	}
+
+	mountPath = "/v2"
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := get("/v2/x"); got != http.StatusOK {
+		t.Errorf("got %d, want %d after reload", got, http.StatusOK)
+	}
+
+	// a failed reload keeps the previous options
+	mountPath, loadErr = "/v3", errors.New("bad config")
+	if err := r.Reload(); err == nil {
+		t.Error("got nil, want error")
+	}
+	if got := get("/v2/x"); got != http.StatusOK {
+		t.Errorf("got %d, want %d after failed reload", got, http.StatusOK)
+	}
+}
+
+func TestReloadOn(t *testing.T) {
+	loads := make(chan struct{}, 2)
+	r, err := NewReloader(http.NotFoundHandler(), func() ([]Option, error) {
+		loads <- struct{}{}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-loads
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan os.Signal)
+	done := make(chan struct{})
+	go func() {
+		r.reloadOn(ctx, c)
+		close(done)
+	}()
+	c <- syscall.SIGHUP
+	<-loads
+	cancel()
+	<-done
+}
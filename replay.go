@@ -0,0 +1,232 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// ReplayResult is the result of replaying one entry through a handler.
+type ReplayResult struct {
+	Index       int           // zero-based position of the entry in the input
+	RequestID   string        // request ID from the entry, if any
+	Method      string        // HTTP method
+	Path        string        // request path
+	WantStatus  int           // status recorded in the access log, or zero for events
+	GotStatus   int           // status returned by the handler
+	WantLatency time.Duration // latency recorded in the access log, or zero
+	GotLatency  time.Duration // time taken to handle the replayed request
+	Err         error         // error converting or handling the entry
+}
+
+// StatusChanged reports whether the replayed status differs from the
+// status recorded in the access log.
+func (r *ReplayResult) StatusChanged() bool {
+	return r.WantStatus != 0 && r.WantStatus != r.GotStatus
+}
+
+// Replay reads API Gateway access log entries or saved Lambda event payloads
+// from input, replays each one through h, and returns the result of each.
+// The input is a sequence of JSON objects, such as JSON lines. This is
+// useful when migrating a service onto this package, or between payload
+// versions, to check that the handler responds in the same way.
+//
+// Each object can be any of:
+//   - an API Gateway proxy event (payload format version 1.0)
+//   - an API Gateway HTTP API event (payload format version 2.0)
+//   - an Application Load Balancer event
+//   - an access log entry in JSON format
+//   - a Recording written by WithRecording
+//
+// Access log entries are converted to requests using the "httpMethod" and
+// "path" fields (or "routeKey" for HTTP APIs). The recorded status is read
+// from "status", and the recorded latency in milliseconds from
+// "responseLatency", "integrationLatency" or "latency". Access logs do not
+// contain headers or bodies, so the replayed requests have neither. The
+// recorded status of a Recording is the status of the recorded response.
+//
+// Replay returns an error only if the input cannot be read. Errors for
+// individual entries are reported in the results.
+func Replay(ctx context.Context, h http.Handler, input io.Reader, opts ...Option) ([]ReplayResult, error) {
+	o := newOptions(opts)
+	h = o.wrap(h)
+	v1 := apiGatewayHandler(h, o)
+	v2 := v2Handler(h, o)
+	alb := albHandler(h, o)
+
+	var results []ReplayResult
+	dec := json.NewDecoder(input)
+	for index := 0; ; index++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return results, nil
+			}
+			return results, kv.Wrap(err, "cannot read replay input").With("index", index)
+		}
+		result := ReplayResult{Index: index}
+		result.Err = replayEntry(ctx, raw, &result, v1, v2, alb)
+		results = append(results, result)
+	}
+}
+
+// replayEntry converts the entry, replays it and records the result.
+func replayEntry(
+	ctx context.Context,
+	raw json.RawMessage,
+	result *ReplayResult,
+	v1 func(context.Context, events.APIGatewayProxyRequest) (apiGatewayProxyResponse, error),
+	v2 func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error),
+	alb func(context.Context, events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error),
+) error {
+	var recording struct {
+		Event    json.RawMessage `json:"event"`
+		Response *struct {
+			StatusCode int `json:"statusCode"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(raw, &recording); err == nil && len(recording.Event) > 0 {
+		raw = recording.Event
+		if recording.Response != nil {
+			result.WantStatus = recording.Response.StatusCode
+		}
+	}
+	kind, err := eventKindOf(raw)
+	if err != nil {
+		return err
+	}
+
+	// invoke calls the handler for the converted entry
+	var invoke func() (int, error)
+	switch {
+	case kind == eventALB:
+		var request events.ALBTargetGroupRequest
+		if err := json.Unmarshal(raw, &request); err != nil {
+			return kv.Wrap(err, "cannot decode ALB event")
+		}
+		result.Method, result.Path = request.HTTPMethod, request.Path
+		invoke = func() (int, error) {
+			response, err := alb(ctx, request)
+			return response.StatusCode, err
+		}
+	case kind == eventV2:
+		var request events.APIGatewayV2HTTPRequest
+		if err := json.Unmarshal(raw, &request); err != nil {
+			return kv.Wrap(err, "cannot decode version 2.0 event")
+		}
+		result.RequestID = request.RequestContext.RequestID
+		result.Method, result.Path = request.RequestContext.HTTP.Method, request.RawPath
+		invoke = func() (int, error) {
+			response, err := v2(ctx, request)
+			return response.StatusCode, err
+		}
+	default:
+		var request events.APIGatewayProxyRequest
+		if kind == eventV1 {
+			if err := json.Unmarshal(raw, &request); err != nil {
+				return kv.Wrap(err, "cannot decode event")
+			}
+			result.RequestID = request.RequestContext.RequestID
+			result.Method, result.Path = request.HTTPMethod, request.Path
+		} else {
+			entry, err := accessLogRequest(raw, result)
+			if err != nil {
+				return err
+			}
+			request = *entry
+		}
+		invoke = func() (int, error) {
+			response, err := v1(ctx, request)
+			return response.StatusCode, err
+		}
+	}
+
+	start := time.Now()
+	status, err := invoke()
+	result.GotLatency = time.Since(start)
+	result.GotStatus = status
+	return err
+}
+
+// event kinds recognized in JSON payloads
+const (
+	eventUnknown = iota // not an event, such as an access log entry
+	eventV1             // API Gateway proxy event, payload format version 1.0
+	eventV2             // API Gateway HTTP API event, payload format version 2.0
+	eventALB            // Application Load Balancer event
+)
+
+// eventKindOf returns the kind of event in the JSON payload.
+func eventKindOf(raw []byte) (int, error) {
+	var probe struct {
+		Version        string `json:"version"`
+		RequestContext *struct {
+			ELB *json.RawMessage `json:"elb"`
+		} `json:"requestContext"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return eventUnknown, kv.Wrap(err, "cannot decode event")
+	}
+	switch {
+	case probe.RequestContext == nil:
+		return eventUnknown, nil
+	case probe.RequestContext.ELB != nil:
+		return eventALB, nil
+	case probe.Version == "2.0":
+		return eventV2, nil
+	}
+	return eventV1, nil
+}
+
+// accessLogRequest converts an access log entry into a request event,
+// and records the logged values in result.
+func accessLogRequest(raw json.RawMessage, result *ReplayResult) (*events.APIGatewayProxyRequest, error) {
+	var entry map[string]interface{}
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, kv.Wrap(err, "cannot decode access log entry")
+	}
+	str := func(keys ...string) string {
+		for _, key := range keys {
+			switch v := entry[key].(type) {
+			case string:
+				if v != "" && v != "-" {
+					return v
+				}
+			case float64:
+				return strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		}
+		return ""
+	}
+	method, path := str("httpMethod"), str("path")
+	if routeKey := str("routeKey"); routeKey != "" && (method == "" || path == "") {
+		// HTTP API route key is "METHOD /path"
+		if i := strings.IndexByte(routeKey, ' '); i > 0 {
+			method, path = routeKey[:i], routeKey[i+1:]
+		}
+	}
+	if method == "" || path == "" {
+		return nil, kv.NewError("access log entry has no method or path")
+	}
+	result.RequestID = str("requestId")
+	result.Method, result.Path = method, path
+	result.WantStatus, _ = strconv.Atoi(str("status"))
+	if ms, err := strconv.ParseFloat(str("responseLatency", "integrationLatency", "latency"), 64); err == nil {
+		result.WantLatency = time.Duration(ms * float64(time.Millisecond))
+	}
+	return &events.APIGatewayProxyRequest{
+		HTTPMethod: method,
+		Path:       path,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID: result.RequestID,
+			Identity:  events.APIGatewayRequestIdentity{SourceIP: str("ip", "sourceIp")},
+		},
+	}, nil
+}
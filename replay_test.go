@@ -0,0 +1,65 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			w.Write([]byte("ok"))
+		}
+	})
+	input := `
+{"requestId":"a1","ip":"203.0.113.1","httpMethod":"GET","path":"/users","status":"200","responseLatency":"12"}
+{"requestId":"a2","routeKey":"GET /missing","status":200,"integrationLatency":3.5}
+{"requestId":"a3","status":"200"}
+{
+  "httpMethod": "POST",
+  "path": "/users",
+  "requestContext": {"requestId": "e1"}
+}
+{"version":"2.0","rawPath":"/missing","requestContext":{"requestId":"e2","http":{"method":"GET"}}}
+{"httpMethod":"GET","path":"/","requestContext":{"elb":{"targetGroupArn":"arn"}}}
+`
+	results, err := Replay(context.Background(), h, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ReplayResult{
+		{Index: 0, RequestID: "a1", Method: "GET", Path: "/users", WantStatus: 200, GotStatus: 200, WantLatency: 12 * time.Millisecond},
+		{Index: 1, RequestID: "a2", Method: "GET", Path: "/missing", WantStatus: 200, GotStatus: 404, WantLatency: 3500 * time.Microsecond},
+		{Index: 2},
+		{Index: 3, RequestID: "e1", Method: "POST", Path: "/users", GotStatus: 200},
+		{Index: 4, RequestID: "e2", Method: "GET", Path: "/missing", GotStatus: 404},
+		{Index: 5, Method: "GET", Path: "/", GotStatus: 200},
+	}
+	if got, want := len(results), len(want); got != want {
+		t.Fatalf("got %d results, want %d", got, want)
+	}
+	for i := range want {
+		got := results[i]
+		if (got.Err != nil) != (i == 2) {
+			t.Errorf("%d: got error %v", i, got.Err)
+		}
+		got.Err = nil
+		got.GotLatency = 0
+		if got != want[i] {
+			t.Errorf("%d: got=%+v\nwant=%+v", i, got, want[i])
+		}
+	}
+	if !results[1].StatusChanged() || results[0].StatusChanged() || results[4].StatusChanged() {
+		t.Error("unexpected StatusChanged result")
+	}
+
+	if _, err := Replay(context.Background(), h, strings.NewReader("{")); err == nil {
+		t.Error("got no error, want error")
+	}
+}
@@ -0,0 +1,39 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"time"
+)
+
+// RequestTime returns the time at which API Gateway received the request
+// associated with ctx, as recorded in the request context of the event.
+// Measuring latency from this time includes the time spent in API Gateway
+// and starting the Lambda invocation, as well as the handler time. For SNS
+// notifications it returns the time the notification was published, and for
+// scheduled events the time of the event; if the event does not record a
+// time, it returns the time the invocation started. It returns the zero time
+// if ctx is not associated with an event, or a proxy request does not record
+// the time.
+func RequestTime(ctx context.Context) time.Time {
+	if start, ok := ctx.Value(ctxKeyStartTime).(time.Time); ok {
+		return start
+	}
+	var epoch int64
+	if request := Request(ctx); request != nil {
+		epoch = request.RequestContext.RequestTimeEpoch
+	} else if request := RequestV2(ctx); request != nil {
+		epoch = request.RequestContext.TimeEpoch
+	} else if request := WebSocketRequest(ctx); request != nil {
+		epoch = request.RequestContext.RequestTimeEpoch
+	}
+	if epoch == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, epoch*int64(time.Millisecond))
+}
+
+// withStartTime returns a context that records t as the start of the
+// request, for events that do not record a request time epoch.
+func withStartTime(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, ctxKeyStartTime, t)
+}
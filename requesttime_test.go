@@ -0,0 +1,94 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestRequestTime(t *testing.T) {
+	var got time.Time
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = RequestTime(r.Context())
+	})
+	want := time.Date(2021, 3, 4, 5, 6, 7, 890*int(time.Millisecond), time.UTC)
+	epoch := want.UnixNano() / int64(time.Millisecond)
+
+	if _, err := apiGatewayHandler(h, newOptions(nil))(context.Background(), events.APIGatewayProxyRequest{
+		Path:           "/",
+		RequestContext: events.APIGatewayProxyRequestContext{RequestTimeEpoch: epoch},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	if _, err := v2Handler(h, newOptions(nil))(context.Background(), events.APIGatewayV2HTTPRequest{
+		RawPath:        "/",
+		RequestContext: events.APIGatewayV2HTTPRequestContext{TimeEpoch: epoch},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	if _, err := apiGatewayHandler(h, newOptions(nil))(context.Background(), events.APIGatewayProxyRequest{Path: "/"}); err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsZero() {
+		t.Errorf("got=%v, want zero time", got)
+	}
+
+	before := time.Now()
+	var times []time.Time
+	h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, RequestTime(r.Context()))
+		time.Sleep(time.Millisecond)
+	})
+	if err := snsHandler(h, newOptions(nil))(context.Background(), events.SNSEvent{
+		Records: []events.SNSEventRecord{{}, {}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduledHandler(h, newOptions(nil))(context.Background(), events.CloudWatchEvent{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 3 {
+		t.Fatalf("got %d requests, want 3", len(times))
+	}
+	if times[0].Before(before) || !times[1].Equal(times[0]) {
+		t.Errorf("got SNS times %v, want the same time after %v", times[:2], before)
+	}
+	if !times[2].After(times[1]) {
+		t.Errorf("got scheduled time %v, want after %v", times[2], times[1])
+	}
+
+	// events that record a time use it as the request time
+	times = nil
+	published := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	if err := snsHandler(h, newOptions(nil))(context.Background(), events.SNSEvent{
+		Records: []events.SNSEventRecord{
+			{SNS: events.SNSEntity{Timestamp: published}},
+			{SNS: events.SNSEntity{Timestamp: published.Add(time.Second)}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduledHandler(h, newOptions(nil))(context.Background(), events.CloudWatchEvent{Time: want}); err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 3 {
+		t.Fatalf("got %d requests, want 3", len(times))
+	}
+	if !times[0].Equal(published) || !times[1].Equal(published.Add(time.Second)) {
+		t.Errorf("got SNS times %v, want %v", times[:2], []time.Time{published, published.Add(time.Second)})
+	}
+	if !times[2].Equal(want) {
+		t.Errorf("got scheduled time %v, want %v", times[2], want)
+	}
+}
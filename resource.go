@@ -0,0 +1,37 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"strings"
+)
+
+// Resource returns the template of the API Gateway resource that matched
+// the request associated with ctx, such as "/users/{id}" or "/{proxy+}".
+// Unlike the request path, the number of distinct values is small, so it is
+// suitable as a metric dimension or for aggregating logs by route. For HTTP
+// APIs (payload format version 2.0), it is the path of the route key without
+// the method. It returns an empty string for the $default route of an HTTP
+// API, and if ctx is not associated with an API Gateway event.
+func Resource(ctx context.Context) string {
+	if request := Request(ctx); request != nil {
+		if request.Resource != "" {
+			return request.Resource
+		}
+		return request.RequestContext.ResourcePath
+	}
+	if request := RequestV2(ctx); request != nil {
+		route := request.RouteKey
+		if route == "" {
+			route = request.RequestContext.RouteKey
+		}
+		if i := strings.IndexByte(route, ' '); i >= 0 {
+			// route keys are "METHOD /path" or "ANY /path"
+			return route[i+1:]
+		}
+		if strings.HasPrefix(route, "$") {
+			return ""
+		}
+		return route
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestResource(t *testing.T) {
+	var got string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = Resource(r.Context())
+	})
+
+	v1 := []struct {
+		request events.APIGatewayProxyRequest
+		want    string
+	}{
+		{
+			request: events.APIGatewayProxyRequest{Path: "/users/123", Resource: "/users/{id}"},
+			want:    "/users/{id}",
+		},
+		{
+			request: events.APIGatewayProxyRequest{
+				Path:           "/files/a.txt",
+				RequestContext: events.APIGatewayProxyRequestContext{ResourcePath: "/{proxy+}"},
+			},
+			want: "/{proxy+}",
+		},
+	}
+	for i, tt := range v1 {
+		if _, err := apiGatewayHandler(h, newOptions(nil))(context.Background(), tt.request); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%d: got %q, want %q", i, got, tt.want)
+		}
+	}
+
+	v2 := []struct {
+		routeKey string
+		want     string
+	}{
+		{routeKey: "GET /users/{id}", want: "/users/{id}"},
+		{routeKey: "ANY /{proxy+}", want: "/{proxy+}"},
+		{routeKey: "$default", want: ""},
+	}
+	for i, tt := range v2 {
+		if _, err := v2Handler(h, newOptions(nil))(context.Background(), events.APIGatewayV2HTTPRequest{
+			RawPath:  "/users/123",
+			RouteKey: tt.routeKey,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("v2 %d: got %q, want %q", i, got, tt.want)
+		}
+	}
+
+	if got := Resource(context.Background()); got != "" {
+		t.Errorf("got %q, want empty", got)
+	}
+}
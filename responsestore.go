@@ -0,0 +1,230 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jjeffery/kv"
+)
+
+// StoredResponse is an HTTP response kept in a ResponseStore.
+type StoredResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// ResponseStore stores HTTP responses by key, for middleware that returns
+// a previous response instead of calling the handler. A typical
+// implementation for AWS Lambda keeps the responses in DynamoDB or
+// ElastiCache, so that they are shared by all Lambda containers. The
+// interface is defined here so that this package does not depend on
+// the AWS SDK.
+type ResponseStore interface {
+	// Get returns the response stored with key, or nil if there is no
+	// such response or it has expired.
+	Get(ctx context.Context, key string) (*StoredResponse, error)
+
+	// Put stores the response with key, replacing any existing response.
+	// The response expires after ttl.
+	Put(ctx context.Context, key string, response *StoredResponse, ttl time.Duration) error
+}
+
+// MemoryResponseStore is a ResponseStore that keeps responses in memory.
+// It is intended for local development and testing, because each Lambda
+// container has its own memory. Use NewMemoryResponseStore to create a
+// MemoryResponseStore.
+type MemoryResponseStore struct {
+	mutex     sync.Mutex
+	responses map[string]memoryResponse
+	now       func() time.Time
+}
+
+type memoryResponse struct {
+	response *StoredResponse
+	expires  time.Time
+}
+
+// NewMemoryResponseStore returns an empty in-memory response store.
+func NewMemoryResponseStore() *MemoryResponseStore {
+	return &MemoryResponseStore{
+		responses: make(map[string]memoryResponse),
+		now:       time.Now,
+	}
+}
+
+// Get implements the ResponseStore interface.
+func (s *MemoryResponseStore) Get(ctx context.Context, key string) (*StoredResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	mr, ok := s.responses[key]
+	if !ok {
+		return nil, nil
+	}
+	if !s.now().Before(mr.expires) {
+		delete(s.responses, key)
+		return nil, nil
+	}
+	return mr.response, nil
+}
+
+// Put implements the ResponseStore interface.
+func (s *MemoryResponseStore) Put(ctx context.Context, key string, response *StoredResponse, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.responses[key] = memoryResponse{
+		response: response,
+		expires:  s.now().Add(ttl),
+	}
+	return nil
+}
+
+// MarshalBinary encodes the response as JSON, for stores such as
+// ElastiCache that keep values as bytes. The Redis clients for Go accept
+// values that implement encoding.BinaryMarshaler.
+func (r *StoredResponse) MarshalBinary() ([]byte, error) {
+	b, err := json.Marshal(storedResponseJSON{
+		StatusCode: r.StatusCode,
+		Header:     r.Header,
+		Body:       r.Body,
+	})
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot marshal response")
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a response encoded by MarshalBinary.
+func (r *StoredResponse) UnmarshalBinary(data []byte) error {
+	var v storedResponseJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return kv.Wrap(err, "cannot unmarshal response")
+	}
+	*r = StoredResponse(v)
+	return nil
+}
+
+// storedResponseJSON is the JSON encoding of a stored response.
+// The body is base64 encoded.
+type storedResponseJSON struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// DynamoDBResponseAPI is the subset of the DynamoDB API used by
+// DynamoDBResponseStore. As for DynamoDBItemAPI, items are represented as
+// maps of string attributes, and the interface is defined here so that
+// this package does not depend on the AWS SDK.
+type DynamoDBResponseAPI interface {
+	// GetItem returns the item with the given key from the table, or nil
+	// if there is no such item.
+	GetItem(ctx context.Context, table string, key map[string]string) (map[string]string, error)
+
+	// PutItem creates or replaces the item in the table.
+	PutItem(ctx context.Context, table string, item map[string]string) error
+}
+
+// DynamoDBResponseStore is a reference implementation of a ResponseStore
+// that stores responses in a DynamoDB table. The table must have a partition
+// key named "key" of type string. The "expiresAt" attribute contains the
+// expiry time in Unix seconds, so it can be configured as the TTL attribute
+// of the table. DynamoDB deletes expired items some time after they expire,
+// so Get also checks the expiry time.
+type DynamoDBResponseStore struct {
+	API       DynamoDBResponseAPI
+	TableName string
+}
+
+// Get implements the ResponseStore interface.
+func (s *DynamoDBResponseStore) Get(ctx context.Context, key string) (*StoredResponse, error) {
+	item, err := s.API.GetItem(ctx, s.TableName, map[string]string{"key": key})
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot get response").With("key", key)
+	}
+	if item == nil {
+		return nil, nil
+	}
+	expiresAt, _ := strconv.ParseInt(item["expiresAt"], 10, 64)
+	if time.Now().Unix() >= expiresAt {
+		return nil, nil
+	}
+	var response StoredResponse
+	if err := response.UnmarshalBinary([]byte(item["response"])); err != nil {
+		return nil, kv.Wrap(err, "cannot decode stored response").With("key", key)
+	}
+	return &response, nil
+}
+
+// Put implements the ResponseStore interface.
+func (s *DynamoDBResponseStore) Put(ctx context.Context, key string, response *StoredResponse, ttl time.Duration) error {
+	b, err := response.MarshalBinary()
+	if err != nil {
+		return kv.Wrap(err, "cannot encode response").With("key", key)
+	}
+	item := map[string]string{
+		"key":       key,
+		"response":  string(b),
+		"expiresAt": strconv.FormatInt(time.Now().Add(ttl).Unix(), 10),
+	}
+	if err := s.API.PutItem(ctx, s.TableName, item); err != nil {
+		return kv.Wrap(err, "cannot put response").With("key", key)
+	}
+	return nil
+}
+
+// recordingWriter passes the response to the underlying response writer,
+// and keeps a copy of the status and body.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// response returns the recorded response.
+func (w *recordingWriter) response() *StoredResponse {
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &StoredResponse{
+		StatusCode: status,
+		Header:     w.Header().Clone(),
+		Body:       w.body.Bytes(),
+	}
+}
+
+// writeStoredResponse writes the stored response to w.
+func writeStoredResponse(w http.ResponseWriter, response *StoredResponse) {
+	header := w.Header()
+	for k, v := range response.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	w.WriteHeader(response.StatusCode)
+	w.Write(response.Body)
+}
@@ -0,0 +1,32 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryResponseStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryResponseStore()
+	store.now = func() time.Time { return now }
+
+	if err := store.Put(ctx, "k", &StoredResponse{StatusCode: 201}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	response, err := store.Get(ctx, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response == nil || response.StatusCode != 201 {
+		t.Errorf("got %+v, want status 201", response)
+	}
+	if response, _ := store.Get(ctx, "other"); response != nil {
+		t.Errorf("got %+v, want nil", response)
+	}
+	now = now.Add(time.Minute)
+	if response, _ := store.Get(ctx, "k"); response != nil {
+		t.Errorf("got %+v, want nil after expiry", response)
+	}
+}
@@ -0,0 +1,220 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/jjeffery/kv"
+)
+
+// StartWithContext is like Start, except that it returns when ctx is done,
+// so that the full Lambda invocation loop can be run in integration tests,
+// or embedded in a larger process. Contexts passed to the handler are derived
+// from ctx, so they are cancelled when ctx is cancelled.
+//
+// StartWithContext returns nil when ctx is done. It returns an error if
+// the Lambda runtime API cannot be reached, or if the handler panics, in
+// which case the process should exit so that Lambda starts a new container.
+// It requires a runtime that provides the Lambda runtime API, such as
+// provided.al2, which is identified by the AWS_LAMBDA_RUNTIME_API
+// environment variable.
+func StartWithContext(ctx context.Context, h http.Handler, opts ...Option) error {
+	o := newOptions(opts)
+	o.startTelemetry()
+	invoke := o.flushInvoke(o.recordInvoke(jsonInvoke(o.jsonCodec, withSpan(o, apiGatewayHandler(o.wrap(h), o)))))
+	return runtimeLoop(ctx, os.Getenv("AWS_LAMBDA_RUNTIME_API"), invoke)
+}
+
+// invokeFunc handles a Lambda invocation with a JSON payload, and returns
+// the JSON response payload.
+type invokeFunc func(ctx context.Context, payload []byte) ([]byte, error)
+
+// Invoke implements the lambda.Handler interface.
+func (fn invokeFunc) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	return fn(ctx, payload)
+}
+
+// jsonInvoke returns an invokeFunc that decodes the payload, calls fn and
+// encodes the response using codec. Unlike the aws-lambda-go package, it
+// does not use reflection to call fn.
+func jsonInvoke[E, R any](codec JSONCodec, fn func(context.Context, E) (R, error)) invokeFunc {
+	return func(ctx context.Context, payload []byte) ([]byte, error) {
+		var event E
+		if err := codec.Unmarshal(payload, &event); err != nil {
+			return nil, kv.Wrap(err, "cannot decode event")
+		}
+		response, err := fn(ctx, event)
+		if err != nil {
+			return nil, err
+		}
+		b, err := codec.Marshal(response)
+		if err != nil {
+			return nil, kv.Wrap(err, "cannot marshal response")
+		}
+		return b, nil
+	}
+}
+
+// jsonInvokeNoResponse is like jsonInvoke, for handlers that do not return
+// a response.
+func jsonInvokeNoResponse[E any](codec JSONCodec, fn func(context.Context, E) error) invokeFunc {
+	return jsonInvoke(codec, func(ctx context.Context, event E) (interface{}, error) {
+		return nil, fn(ctx, event)
+	})
+}
+
+// Lambda runtime API headers.
+const (
+	runtimeRequestIDHeader   = "Lambda-Runtime-Aws-Request-Id"
+	runtimeDeadlineHeader    = "Lambda-Runtime-Deadline-Ms"
+	runtimeTraceIDHeader     = "Lambda-Runtime-Trace-Id"
+	runtimeFunctionARNHeader = "Lambda-Runtime-Invoked-Function-Arn"
+)
+
+// runtimeError is the error payload sent to the Lambda runtime API. It has
+// the same format as the errors reported by the aws-lambda-go package.
+type runtimeError struct {
+	Message string `json:"errorMessage"`
+	Type    string `json:"errorType"`
+}
+
+// runtimeLoop receives invocations from the Lambda runtime API at address
+// and passes them to invoke, until ctx is done.
+func runtimeLoop(ctx context.Context, address string, invoke invokeFunc) error {
+	return runtimeNextLoop(ctx, address, func(ctx context.Context, client *http.Client, url string, header http.Header, payload []byte) error {
+		id := header.Get(runtimeRequestIDHeader)
+		response, panicked, invokeErr := runtimeInvoke(ctx, invoke, header, payload)
+
+		// the response is sent even if ctx is cancelled while the handler runs
+		postCtx := context.WithoutCancel(ctx)
+		var err error
+		if invokeErr != nil {
+			err = runtimePost(postCtx, client, url+"/error", runtimeErrorBody(invokeErr))
+		} else {
+			err = runtimePost(postCtx, client, url+"/response", response)
+		}
+		if err != nil {
+			return kv.Wrap(err, "cannot send invocation result").With("requestId", id)
+		}
+		if panicked {
+			return kv.Wrap(invokeErr, "handler panicked").With("requestId", id)
+		}
+		return nil
+	})
+}
+
+// runtimeNextLoop receives invocations from the Lambda runtime API at
+// address, and calls respond with the URL of each invocation, until ctx is
+// done or respond returns an error.
+func runtimeNextLoop(ctx context.Context, address string, respond func(ctx context.Context, client *http.Client, url string, header http.Header, payload []byte) error) error {
+	if address == "" {
+		return kv.NewError("AWS_LAMBDA_RUNTIME_API is not set")
+	}
+	baseURL := "http://" + address + "/2018-06-01/runtime/invocation/"
+	client := &http.Client{}
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"next", nil)
+		if err != nil {
+			return kv.Wrap(err, "cannot create next invocation request")
+		}
+		resp, err := client.Do(req)
+		if ctx.Err() != nil {
+			if err == nil {
+				resp.Body.Close()
+			}
+			return nil
+		}
+		if err != nil {
+			return kv.Wrap(err, "cannot get next invocation")
+		}
+		payload, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return kv.Wrap(err, "cannot read invocation")
+		}
+		if resp.StatusCode != http.StatusOK {
+			return kv.NewError("cannot get next invocation").With("status", resp.StatusCode)
+		}
+		id := resp.Header.Get(runtimeRequestIDHeader)
+		if err := respond(ctx, client, baseURL+id, resp.Header, payload); err != nil {
+			return err
+		}
+	}
+}
+
+// runtimeInvoke calls invoke with the invocation payload, in a context
+// containing the invocation details from the runtime API headers.
+func runtimeInvoke(ctx context.Context, invoke invokeFunc, header http.Header, payload []byte) (response []byte, panicked bool, err error) {
+	ctx, cancel := runtimeContext(ctx, header)
+	defer cancel()
+	defer func() {
+		if v := recover(); v != nil {
+			panicked, err = true, fmt.Errorf("%v", v)
+		}
+	}()
+	response, err = invoke(ctx, payload)
+	return response, false, err
+}
+
+// runtimeContext returns a context containing the invocation details from
+// the runtime API headers, which is cancelled at the invocation deadline.
+func runtimeContext(ctx context.Context, header http.Header) (context.Context, context.CancelFunc) {
+	deadlineMS, _ := strconv.ParseInt(header.Get(runtimeDeadlineHeader), 10, 64)
+	ctx, cancel := context.WithDeadline(ctx, time.UnixMilli(deadlineMS))
+	ctx = lambdacontext.NewContext(ctx, &lambdacontext.LambdaContext{
+		AwsRequestID:       header.Get(runtimeRequestIDHeader),
+		InvokedFunctionArn: header.Get(runtimeFunctionARNHeader),
+	})
+	traceID := header.Get(runtimeTraceIDHeader)
+	// same key as the aws-lambda-go package, which is read by xrayTraceID
+	ctx = context.WithValue(ctx, "x-amzn-trace-id", traceID) //nolint:staticcheck
+	os.Setenv("_X_AMZN_TRACE_ID", traceID)
+	return ctx, cancel
+}
+
+// runtimeErrorBody returns the error payload for err.
+func runtimeErrorBody(err error) []byte {
+	body, _ := json.Marshal(runtimeError{
+		Message: err.Error(),
+		Type:    runtimeErrorType(err),
+	})
+	return body
+}
+
+// runtimePost sends an invocation result to the runtime API.
+func runtimePost(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusAccepted {
+		return kv.NewError("unexpected status").With("status", resp.StatusCode)
+	}
+	return nil
+}
+
+// runtimeErrorType returns the name of the error's type, as reported by
+// the aws-lambda-go package.
+func runtimeErrorType(err error) string {
+	t := reflect.TypeOf(err)
+	if t.Kind() == reflect.Ptr {
+		return t.Elem().Name()
+	}
+	return t.Name()
+}
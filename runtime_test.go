@@ -0,0 +1,115 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// fakeRuntimeAPI is a Lambda runtime API that sends each event once, and
+// then waits until the request is cancelled.
+type fakeRuntimeAPI struct {
+	events  chan []byte
+	results chan string // "<request id>/<response|error> <body>"
+}
+
+func (api *fakeRuntimeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/2018-06-01/runtime/invocation/")
+	if path == "next" {
+		select {
+		case event := <-api.events:
+			w.Header().Set(runtimeRequestIDHeader, "req-1")
+			w.Header().Set(runtimeDeadlineHeader, strconv.FormatInt(time.Now().Add(time.Minute).UnixMilli(), 10))
+			w.Write(event)
+		case <-r.Context().Done():
+		}
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	w.WriteHeader(http.StatusAccepted)
+	api.results <- path + " " + string(body)
+}
+
+func TestStartWithContext(t *testing.T) {
+	api := &fakeRuntimeAPI{
+		events:  make(chan []byte, 1),
+		results: make(chan string, 1),
+	}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", strings.TrimPrefix(server.URL, "http://"))
+
+	event, _ := json.Marshal(events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/hello"})
+	api.events <- event
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- StartWithContext(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if info := Invocation(r.Context()); info == nil || info.RequestID != "req-1" {
+				t.Errorf("got %+v, want request ID req-1", info)
+			}
+			io.WriteString(w, "hello from "+r.URL.Path)
+		}))
+	}()
+
+	result := <-api.results
+	path, body, _ := strings.Cut(result, " ")
+	if got, want := path, "req-1/response"; got != want {
+		t.Fatalf("got %q, want %q: %s", got, want, body)
+	}
+	var response events.APIGatewayProxyResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := response.Body, "hello from /hello"; got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("got %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartWithContext did not return")
+	}
+}
+
+func TestStartWithContextNoRuntimeAPI(t *testing.T) {
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", "")
+	if err := StartWithContext(context.Background(), http.NotFoundHandler()); err == nil {
+		t.Error("got nil, want error")
+	}
+}
+
+func TestJSONInvoke(t *testing.T) {
+	ctx := context.Background()
+	var got string
+	invoke := jsonInvokeNoResponse(stdJSONCodec{}, func(ctx context.Context, event events.SNSEvent) error {
+		got = event.Records[0].SNS.Message
+		return nil
+	})
+	response, err := invoke(ctx, []byte(`{"Records":[{"Sns":{"Message":"hello"}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "hello" {
+		t.Errorf("got message %q, want %q", got, "hello")
+	}
+	if got, want := string(response), "null"; got != want {
+		t.Errorf("got response %q, want %q", got, want)
+	}
+	if _, err := invoke(ctx, []byte(`[`)); err == nil {
+		t.Error("got nil, want error for invalid payload")
+	}
+}
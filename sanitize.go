@@ -0,0 +1,279 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/jjeffery/kv"
+)
+
+// HeaderMode determines how invalid header names and values are handled.
+type HeaderMode int
+
+// Header modes
+const (
+	// HeaderLenient removes invalid characters from header values, and
+	// removes headers with invalid names. CR and LF characters in values
+	// are replaced with spaces, as net/http does for response headers.
+	HeaderLenient HeaderMode = iota + 1
+
+	// HeaderStrict rejects requests with invalid header names or values with
+	// a 400 (Bad Request) response, without calling the handler. If the handler
+	// sets an invalid response header, a 500 (Internal Server Error) response
+	// is returned instead of the handler's response.
+	HeaderStrict
+)
+
+// HeaderViolation describes an invalid header name or value.
+type HeaderViolation struct {
+	Response bool   // true for a response header, false for a request header
+	Name     string // header name
+	Value    string // header value
+	Reason   string // description of the violation, such as "invalid value"
+}
+
+// Reasons for header violations.
+const (
+	invalidName    = "invalid name"
+	invalidValue   = "invalid value"
+	valueTooLong   = "value too long"
+	tooManyHeaders = "too many headers"
+	hopByHop       = "hop-by-hop header"
+)
+
+// hopByHopHeaders are the response headers that apply to a single
+// connection. API Gateway manages the connection to the client, and rejects
+// or mishandles responses that contain them, so they are removed.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Connection",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// stripHopByHopHeaders removes the hop-by-hop headers from the response,
+// as net/http does for the headers it manages. If header sanitization is
+// configured, it also records a violation for each header removed;
+// otherwise the headers are removed silently, as net/http does.
+func (w *responseWriter) stripHopByHopHeaders() {
+	for _, name := range hopByHopHeaders {
+		values, ok := w.response2.MultiValueHeaders[name]
+		if !ok {
+			value, ok := w.response2.Headers[name]
+			if !ok {
+				continue
+			}
+			values = []string{value}
+		}
+		w.deleteResponseHeader(name)
+		if w.opts.headerMode == 0 {
+			continue
+		}
+		for _, value := range values {
+			v := HeaderViolation{
+				Response: true,
+				Name:     name,
+				Value:    value,
+				Reason:   hopByHop,
+			}
+			if w.opts.onHeaderViolation != nil {
+				w.opts.onHeaderViolation(v)
+			}
+			w.headerViolations = append(w.headerViolations, v)
+		}
+	}
+}
+
+// WithHeaderSanitization configures the adapter to check request headers
+// received from API Gateway and response headers set by the handler for
+// names and values that net/http would reject, such as values containing
+// control characters. The mode determines whether invalid headers are
+// stripped or rejected. Hop-by-hop response headers, such as Connection and
+// Transfer-Encoding, are always removed and are reported as violations in
+// either mode. Invalid response headers are logged as warnings,
+// and in strict mode are also reported to the error hook. If onViolation is
+// not nil, it is called for each invalid header.
+func WithHeaderSanitization(mode HeaderMode, onViolation func(HeaderViolation)) Option {
+	return func(o *options) {
+		o.headerMode = mode
+		o.onHeaderViolation = onViolation
+	}
+}
+
+// WithResponseHeaderLimits configures the adapter to check the response
+// headers set by the handler against the API Gateway quotas: maxValueLength
+// is the maximum length of each header value, and maxCount is the maximum
+// number of header values. A zero limit is not checked. In lenient mode,
+// long values are truncated and excess headers are removed; in strict mode
+// a 500 (Internal Server Error) response is returned. If no mode has been set
+// using WithHeaderSanitization, lenient mode is used.
+func WithResponseHeaderLimits(maxValueLength, maxCount int) Option {
+	return func(o *options) {
+		o.headerValueLimit = maxValueLength
+		o.headerCountLimit = maxCount
+		if o.headerMode == 0 {
+			o.headerMode = HeaderLenient
+		}
+	}
+}
+
+// sanitizeHeader checks the header names and values, and reports whether
+// they are valid. In lenient mode, invalid headers are fixed and the
+// header is always reported as valid. It returns the violations found.
+func sanitizeHeader(header http.Header, response bool, o *options) (bool, []HeaderViolation) {
+	var violations []HeaderViolation
+	report := func(name, value, reason string) {
+		v := HeaderViolation{
+			Response: response,
+			Name:     name,
+			Value:    value,
+			Reason:   reason,
+		}
+		if o.onHeaderViolation != nil {
+			o.onHeaderViolation(v)
+		}
+		violations = append(violations, v)
+	}
+	strict := o.headerMode == HeaderStrict
+	maxLength := 0
+	if response {
+		maxLength = o.headerValueLimit
+	}
+	for name, values := range header {
+		nameValid := validHeaderName(name)
+		for i, value := range values {
+			switch {
+			case !nameValid:
+				report(name, value, invalidName)
+			case !validHeaderValue(value):
+				report(name, value, invalidValue)
+				if !strict {
+					values[i] = cleanHeaderValue(value)
+				}
+			case maxLength > 0 && len(value) > maxLength:
+				report(name, value, valueTooLong)
+				if !strict {
+					values[i] = value[:maxLength]
+				}
+			}
+		}
+		if !nameValid && !strict {
+			delete(header, name)
+		}
+	}
+	if response && o.headerCountLimit > 0 {
+		limitHeaderCount(header, o.headerCountLimit, strict, report)
+	}
+	return !strict || len(violations) == 0, violations
+}
+
+// limitHeaderCount reports a violation if header has more than limit values.
+// If strict is false, the values after the limit are removed, in order of
+// header name so that the result does not depend on map iteration order.
+func limitHeaderCount(header http.Header, limit int, strict bool, report func(name, value, reason string)) {
+	count := 0
+	for _, values := range header {
+		count += len(values)
+	}
+	if count <= limit {
+		return
+	}
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	count = 0
+	for _, name := range names {
+		values := header[name]
+		if count+len(values) <= limit {
+			count += len(values)
+			continue
+		}
+		report(name, values[len(values)-1], tooManyHeaders)
+		if strict {
+			return
+		}
+		if keep := limit - count; keep > 0 {
+			header[name] = values[:keep]
+			count = limit
+		} else {
+			delete(header, name)
+		}
+	}
+}
+
+// validRequestHeader sanitizes the request header, and reports whether
+// it is valid.
+func validRequestHeader(header http.Header, o *options) bool {
+	valid, _ := sanitizeHeader(header, false, o)
+	return valid
+}
+
+// reportHeaderViolations logs the violations in the response headers set
+// by the handler, and reports an error if the response was replaced in
+// strict mode.
+func (inv *invocation) reportHeaderViolations(r *http.Request) {
+	w := &inv.writer
+	logger := Logger(r.Context())
+	for _, v := range w.headerViolations {
+		logger.Warn("invalid response header", "name", v.Name, "reason", v.Reason)
+	}
+	if w.invalidHeader {
+		v := w.headerViolations[0]
+		err := kv.NewError("invalid response header").With("name", v.Name, "reason", v.Reason)
+		inv.reportError(err, func() RequestSnapshot { return newRequestSnapshot(r) })
+	}
+}
+
+// validHeaderName reports whether name is a valid header field name, which
+// is a token as defined in RFC 7230 section 3.2.6.
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isTokenChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isTokenChar(c byte) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+// validHeaderValue reports whether value is a valid header field value as
+// defined in RFC 7230 section 3.2: it must not contain control characters
+// other than horizontal tab.
+func validHeaderValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c < ' ' && c != '\t' || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// cleanHeaderValue replaces CR and LF with spaces, and removes
+// other invalid characters.
+func cleanHeaderValue(value string) string {
+	b := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '\r' || c == '\n':
+			b = append(b, ' ')
+		case c < ' ' && c != '\t' || c == 0x7f:
+			// remove
+		default:
+			b = append(b, c)
+		}
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,260 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestHeaderSanitization(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get("X-Response"); v != "" {
+			w.Header().Set("X-Response", v+"\r\nX-Injected: 1")
+		}
+		w.Write([]byte(r.Header.Get("X-Request")))
+	})
+	tests := []struct {
+		mode       HeaderMode
+		headers    map[string]string
+		wantStatus int
+		wantBody   string
+		wantHeader string
+		violations int
+	}{
+		{
+			mode:       HeaderLenient,
+			headers:    map[string]string{"X-Request": "a\r\nb\x00c", "Bad Name": "x"},
+			wantStatus: http.StatusOK,
+			wantBody:   "a  bc",
+			violations: 2,
+		},
+		{
+			mode:       HeaderStrict,
+			headers:    map[string]string{"X-Request": "a\r\nb"},
+			wantStatus: http.StatusBadRequest,
+			violations: 1,
+		},
+		{
+			mode:       HeaderStrict,
+			headers:    map[string]string{"X-Request": "a\tb"},
+			wantStatus: http.StatusOK,
+			wantBody:   "a\tb",
+		},
+		{
+			mode:       HeaderLenient,
+			headers:    map[string]string{"X-Response": "v"},
+			wantStatus: http.StatusOK,
+			wantHeader: "v  X-Injected: 1",
+			violations: 1,
+		},
+		{
+			mode:       HeaderStrict,
+			headers:    map[string]string{"X-Response": "v"},
+			wantStatus: http.StatusInternalServerError,
+			violations: 1,
+		},
+	}
+	for i, tt := range tests {
+		var violations []HeaderViolation
+		opts := newOptions([]Option{WithHeaderSanitization(tt.mode, func(v HeaderViolation) {
+			violations = append(violations, v)
+		})})
+		handler := apiGatewayHandler(h, opts)
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Path:       "/",
+			Headers:    tt.headers,
+		})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.StatusCode, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if tt.wantStatus == http.StatusOK {
+			if got, want := response.Body, tt.wantBody; got != want {
+				t.Errorf("%d: got body %q, want %q", i, got, want)
+			}
+			if got, want := response.Headers["X-Response"], tt.wantHeader; got != want {
+				t.Errorf("%d: got header %q, want %q", i, got, want)
+			}
+		}
+		if got, want := len(violations), tt.violations; got != want {
+			t.Errorf("%d: got %d violations, want %d: %v", i, got, want, violations)
+		}
+	}
+}
+
+func TestResponseHeaderLimits(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Long", "abcdef")
+		w.Header().Add("X-Many", "1")
+		w.Header().Add("X-Many", "2")
+		w.Header().Add("X-Many", "3")
+	})
+	tests := []struct {
+		mode       HeaderMode
+		maxLength  int
+		maxCount   int
+		wantStatus int
+		wantLong   string
+		wantMany   []string
+		wantReason string
+	}{
+		{
+			maxLength:  3,
+			wantStatus: http.StatusOK,
+			wantLong:   "abc",
+			wantMany:   []string{"1", "2", "3"},
+			wantReason: valueTooLong,
+		},
+		{
+			maxCount:   3,
+			wantStatus: http.StatusOK,
+			wantLong:   "abcdef",
+			wantMany:   []string{"1", "2"},
+			wantReason: tooManyHeaders,
+		},
+		{
+			mode:       HeaderStrict,
+			maxLength:  3,
+			wantStatus: http.StatusInternalServerError,
+			wantReason: valueTooLong,
+		},
+		{
+			mode:       HeaderStrict,
+			maxCount:   3,
+			wantStatus: http.StatusInternalServerError,
+			wantReason: tooManyHeaders,
+		},
+		{
+			mode:       HeaderStrict,
+			maxLength:  6,
+			maxCount:   4,
+			wantStatus: http.StatusOK,
+			wantLong:   "abcdef",
+			wantMany:   []string{"1", "2", "3"},
+		},
+	}
+	for i, tt := range tests {
+		var violations []HeaderViolation
+		var reported []error
+		opts := []Option{
+			WithResponseHeaderLimits(tt.maxLength, tt.maxCount),
+			WithErrorHook(func(err error, snapshot RequestSnapshot) {
+				reported = append(reported, err)
+			}),
+		}
+		mode := tt.mode
+		if mode == 0 {
+			mode = HeaderLenient
+		}
+		opts = append(opts, WithHeaderSanitization(mode, func(v HeaderViolation) {
+			violations = append(violations, v)
+		}))
+		handler := apiGatewayHandler(h, newOptions(opts))
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Path:       "/",
+		})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.StatusCode, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if tt.wantStatus == http.StatusOK {
+			if got, want := response.Headers["X-Long"], tt.wantLong; got != want {
+				t.Errorf("%d: got X-Long %q, want %q", i, got, want)
+			}
+			if got, want := response.MultiValueHeaders["X-Many"], tt.wantMany; !reflect.DeepEqual(got, want) {
+				t.Errorf("%d: got X-Many %q, want %q", i, got, want)
+			}
+		}
+		if tt.wantReason == "" {
+			if len(violations) != 0 {
+				t.Errorf("%d: got violations %v, want none", i, violations)
+			}
+			continue
+		}
+		if len(violations) != 1 || violations[0].Reason != tt.wantReason || !violations[0].Response {
+			t.Errorf("%d: got violations %v, want one %q", i, violations, tt.wantReason)
+		}
+		wantReported := 0
+		if tt.mode == HeaderStrict {
+			wantReported = 1
+		}
+		if got, want := len(reported), wantReported; got != want {
+			t.Errorf("%d: got %d errors reported, want %d", i, got, want)
+		}
+	}
+}
+
+func TestStripHopByHopHeaders(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Transfer-Encoding", "chunked")
+		w.Header().Set("Connection", "close")
+		w.Header().Add("Upgrade", "h2c")
+		w.Header().Add("Upgrade", "websocket")
+		w.Header().Set("X-Kept", "1")
+		w.Write([]byte("ok"))
+	})
+	var violations []HeaderViolation
+	for _, opts := range [][]Option{
+		nil,
+		{WithHeaderSanitization(HeaderStrict, func(v HeaderViolation) {
+			violations = append(violations, v)
+		})},
+	} {
+		handler := apiGatewayHandler(h, newOptions(opts))
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "GET",
+			Path:       "/",
+		})
+		if err != nil {
+			t.Fatalf("got %v, want no error", err)
+		}
+		if got, want := response.StatusCode, http.StatusOK; got != want {
+			t.Errorf("got status %d, want %d", got, want)
+		}
+		for _, name := range []string{"Transfer-Encoding", "Connection", "Upgrade"} {
+			if _, ok := response.Headers[name]; ok {
+				t.Errorf("got %s header, want none", name)
+			}
+			if _, ok := response.MultiValueHeaders[name]; ok {
+				t.Errorf("got %s multi value header, want none", name)
+			}
+		}
+		if got, want := response.Headers["X-Kept"], "1"; got != want {
+			t.Errorf("got X-Kept=%q, want %q", got, want)
+		}
+	}
+	if got, want := len(violations), 4; got != want {
+		t.Fatalf("got %d violations, want %d: %v", got, want, violations)
+	}
+	for _, v := range violations {
+		if v.Reason != hopByHop || !v.Response {
+			t.Errorf("got violation %+v, want hop-by-hop response header", v)
+		}
+	}
+}
+
+func TestStripHopByHopHeadersUnchecked(t *testing.T) {
+	// without header sanitization, hop-by-hop headers are removed
+	// but not recorded, so they are not logged
+	inv := newInvocation(newOptions(nil))
+	defer inv.release()
+	w := &inv.writer
+	w.Header().Set("Connection", "close")
+	w.Write([]byte("ok"))
+	w.finished()
+	if _, ok := w.response.Headers["Connection"]; ok {
+		t.Error("got Connection header, want none")
+	}
+	if got := w.headerViolations; len(got) != 0 {
+		t.Errorf("got violations %v, want none", got)
+	}
+}
@@ -0,0 +1,97 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// StartScheduled starts handling scheduled EventBridge (CloudWatch Events)
+// events by passing each event to the HTTP handler as a GET request. This
+// means that periodic jobs can be implemented as ordinary HTTP endpoints,
+// sharing the same middleware as the rest of the application.
+//
+// The request path is determined by the WithScheduledPath option.
+// The event ID, detail type, source and time are passed in the
+// X-Amz-Event-Id, X-Amz-Event-Detail-Type, X-Amz-Event-Source and
+// X-Amz-Event-Time request headers.
+//
+// If the handler responds with a status code outside the range 200-299, an
+// error is returned to Lambda.
+func StartScheduled(h http.Handler, opts ...Option) {
+	o := newOptions(opts)
+	startLambdaNoResponse(o, withSpanNoResponse(o, scheduledHandler(o.wrap(h), o)))
+}
+
+// ScheduledEvent returns a pointer to the EventBridge event, or nil if the
+// current context is not associated with a scheduled event.
+func ScheduledEvent(ctx context.Context) *events.CloudWatchEvent {
+	event, _ := ctx.Value(ctxKeyScheduled).(*events.CloudWatchEvent)
+	return event
+}
+
+func scheduledHandler(h http.Handler, o *options) func(ctx context.Context, event events.CloudWatchEvent) error {
+	return func(ctx context.Context, event events.CloudWatchEvent) error {
+		inv := newInvocation(o)
+		defer inv.release()
+		inv.startTime = event.Time
+		if inv.startTime.IsZero() {
+			inv.startTime = time.Now()
+		}
+		r, err := newScheduledRequest(withStartTime(ctx, inv.startTime), &event, o)
+		if err != nil {
+			return err
+		}
+		// usage is reported with the context of the request
+		inv.ctx.Context = r.Context()
+		w := &inv.writer
+		h.ServeHTTP(w, r)
+		w.finished()
+		if status := w.response.StatusCode; status < 200 || status > 299 {
+			return kv.NewError("handler failed to process scheduled event").With(
+				"status", status,
+				"path", r.URL.Path,
+			)
+		}
+		return nil
+	}
+}
+
+func newScheduledRequest(ctx context.Context, event *events.CloudWatchEvent, o *options) (*http.Request, error) {
+	var rule string
+	if len(event.Resources) > 0 {
+		rule = ruleName(event.Resources[0])
+	}
+	path := strings.Replace(o.scheduledPath, "{rule}", url.PathEscape(rule), -1)
+	r, err := http.NewRequest(http.MethodGet, path, http.NoBody)
+	if err != nil {
+		return nil, kv.Wrap(newConversionError(ErrInvalidPath, err), "cannot create HTTP request").With("path", path)
+	}
+	r.RequestURI = path
+
+	r.Header.Set("X-Amz-Event-Id", event.ID)
+	r.Header.Set("X-Amz-Event-Detail-Type", event.DetailType)
+	r.Header.Set("X-Amz-Event-Source", event.Source)
+	if !event.Time.IsZero() {
+		r.Header.Set("X-Amz-Event-Time", event.Time.UTC().Format(time.RFC3339))
+	}
+
+	ctx = context.WithValue(ctx, ctxKeyScheduled, event)
+	return r.WithContext(ctx), nil
+}
+
+// ruleName returns the name of the rule from an EventBridge rule ARN.
+// The resource part of the ARN is "rule/name", or "rule/bus/name"
+// for rules on a custom event bus.
+func ruleName(arn string) string {
+	resource := arnResource(arn)
+	if i := strings.LastIndex(resource, "/"); i >= 0 {
+		return resource[i+1:]
+	}
+	return resource
+}
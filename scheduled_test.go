@@ -0,0 +1,94 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestScheduledHandler(t *testing.T) {
+	tests := []struct {
+		opts        []Option
+		resource    string
+		status      int
+		path        string
+		expectError bool
+	}{
+		{
+			resource: "arn:aws:events:us-east-1:123456789012:rule/nightly-cleanup",
+			path:     "/internal/cron/nightly-cleanup",
+		},
+		{
+			resource: "arn:aws:events:us-east-1:123456789012:rule/custom-bus/hourly",
+			path:     "/internal/cron/hourly",
+		},
+		{
+			opts:     []Option{WithScheduledPath("/jobs/{rule}/run")},
+			resource: "arn:aws:events:us-east-1:123456789012:rule/hourly",
+			path:     "/jobs/hourly/run",
+		},
+		{
+			resource:    "arn:aws:events:us-east-1:123456789012:rule/hourly",
+			status:      http.StatusInternalServerError,
+			path:        "/internal/cron/hourly",
+			expectError: true,
+		},
+	}
+
+	for i, tt := range tests {
+		var r *http.Request
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			r = req
+			if tt.status != 0 {
+				w.WriteHeader(tt.status)
+			}
+		})
+		handler := scheduledHandler(h, newOptions(tt.opts))
+		event := events.CloudWatchEvent{
+			ID:         "event-id",
+			DetailType: "Scheduled Event",
+			Source:     "aws.events",
+			Time:       time.Date(2021, 11, 1, 2, 3, 4, 0, time.UTC),
+			Resources:  []string{tt.resource},
+		}
+		err := handler(context.Background(), event)
+		if err != nil {
+			if !tt.expectError {
+				t.Errorf("%d: got %v, want no error", i, err)
+			}
+		} else if tt.expectError {
+			t.Errorf("%d: got no error, expected error", i)
+		}
+		if got, want := r.Method, "GET"; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if got, want := r.URL.Path, tt.path; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if got, want := r.Header.Get("X-Amz-Event-Time"), "2021-11-01T02:03:04Z"; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+		if ScheduledEvent(r.Context()) == nil {
+			t.Errorf("%d: got nil, want event", i)
+		}
+	}
+}
+
+func TestScheduledBufferPool(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	pool := &countingPool{}
+	handler := scheduledHandler(h, newOptions([]Option{WithBufferPool(pool)}))
+	if err := handler(context.Background(), events.CloudWatchEvent{
+		Resources: []string{"arn:aws:events:us-east-1:123456789012:rule/hourly"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if pool.gets != 1 || pool.puts != pool.gets {
+		t.Errorf("got %d gets and %d puts, want 1 of each", pool.gets, pool.puts)
+	}
+}
@@ -0,0 +1,123 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// SNSAttributeHeaderPrefix is the prefix of the request headers that contain
+// the message attributes of an SNS notification.
+const SNSAttributeHeaderPrefix = "X-Amz-Sns-Attribute-"
+
+// StartSNS starts handling AWS Lambda SNS notifications by passing each
+// notification record to the HTTP handler as a POST request.
+//
+// The request body is the notification message, and the request headers
+// match the headers that SNS sends to an HTTP/S subscription endpoint
+// (x-amz-sns-message-type, x-amz-sns-message-id, x-amz-sns-topic-arn, etc).
+// Each message attribute is added as a request header with the
+// SNSAttributeHeaderPrefix, for example "X-Amz-Sns-Attribute-Tenant", so
+// that attributes cannot replace the Content-Type and SNS headers. A handler
+// written to receive SNS notifications as a webhook can be used unchanged.
+//
+// If the handler responds with a status code outside the range 200-299, an
+// error is returned to Lambda so that the notification can be retried.
+func StartSNS(h http.Handler, opts ...Option) {
+	o := newOptions(opts)
+	startLambdaNoResponse(o, withSpanNoResponse(o, snsHandler(o.wrap(h), o)))
+}
+
+// SNSRecord returns a pointer to the SNS notification record, or nil if the
+// current context is not associated with an SNS notification.
+func SNSRecord(ctx context.Context) *events.SNSEventRecord {
+	record, _ := ctx.Value(ctxKeySNSRecord).(*events.SNSEventRecord)
+	return record
+}
+
+func snsHandler(h http.Handler, o *options) func(ctx context.Context, event events.SNSEvent) error {
+	return func(ctx context.Context, event events.SNSEvent) error {
+		start := time.Now()
+		for i := range event.Records {
+			record := &event.Records[i]
+			if err := serveSNSRecord(ctx, h, record, start, o); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// serveSNSRecord passes the notification record to h. The request time is
+// the time the notification was published, or start if the record does not
+// have a timestamp.
+func serveSNSRecord(ctx context.Context, h http.Handler, record *events.SNSEventRecord, start time.Time, o *options) error {
+	inv := newInvocation(o)
+	defer inv.release()
+	inv.startTime = record.SNS.Timestamp
+	if inv.startTime.IsZero() {
+		inv.startTime = start
+	}
+	r, err := newSNSRequest(withStartTime(ctx, inv.startTime), record, o)
+	if err != nil {
+		return err
+	}
+	// usage is reported with the context of the request
+	inv.ctx.Context = r.Context()
+	w := &inv.writer
+	h.ServeHTTP(w, r)
+	w.finished()
+	if status := w.response.StatusCode; status < 200 || status > 299 {
+		return kv.NewError("handler failed to process SNS notification").With(
+			"status", status,
+			"messageId", record.SNS.MessageID,
+		)
+	}
+	return nil
+}
+
+func newSNSRequest(ctx context.Context, record *events.SNSEventRecord, o *options) (*http.Request, error) {
+	topicArn := record.SNS.TopicArn
+	path := strings.Replace(o.snsPath, "{topic}", url.PathEscape(arnResource(topicArn)), -1)
+	r, err := http.NewRequest(http.MethodPost, path, strings.NewReader(record.SNS.Message))
+	if err != nil {
+		return nil, kv.Wrap(newConversionError(ErrInvalidPath, err), "cannot create HTTP request").With("path", path)
+	}
+	r.RequestURI = path
+
+	r.Header.Set("Content-Type", "text/plain; charset=UTF-8")
+	r.Header.Set("X-Amz-Sns-Message-Type", record.SNS.Type)
+	r.Header.Set("X-Amz-Sns-Message-Id", record.SNS.MessageID)
+	r.Header.Set("X-Amz-Sns-Topic-Arn", topicArn)
+	if record.EventSubscriptionArn != "" {
+		r.Header.Set("X-Amz-Sns-Subscription-Arn", record.EventSubscriptionArn)
+	}
+	if record.SNS.Subject != "" {
+		r.Header.Set("X-Amz-Sns-Subject", record.SNS.Subject)
+	}
+	for name, attr := range record.SNS.MessageAttributes {
+		// attributes are delivered as {"Type": "String", "Value": "..."}
+		if m, ok := attr.(map[string]interface{}); ok {
+			if v, ok := m["Value"].(string); ok {
+				r.Header.Set(SNSAttributeHeaderPrefix+name, v)
+			}
+		}
+	}
+
+	ctx = context.WithValue(ctx, ctxKeySNSRecord, record)
+	return r.WithContext(ctx), nil
+}
+
+// arnResource returns the resource part of an ARN, which for an SNS topic
+// is the topic name.
+func arnResource(arn string) string {
+	if i := strings.LastIndex(arn, ":"); i >= 0 {
+		return arn[i+1:]
+	}
+	return arn
+}
@@ -0,0 +1,130 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestSNSHandler(t *testing.T) {
+	var got []*http.Request
+	var bodies []string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if SNSRecord(r.Context()) == nil {
+			t.Error("got nil, want SNS record")
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		got = append(got, r)
+		bodies = append(bodies, string(body))
+		if string(body) == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	handler := snsHandler(h, newOptions(nil))
+
+	event := events.SNSEvent{
+		Records: []events.SNSEventRecord{
+			{
+				EventSubscriptionArn: "arn:aws:sns:us-east-1:123456789012:my-topic:1234",
+				SNS: events.SNSEntity{
+					Type:      "Notification",
+					MessageID: "message-1",
+					TopicArn:  "arn:aws:sns:us-east-1:123456789012:my-topic",
+					Subject:   "greeting",
+					Message:   "hello",
+					MessageAttributes: map[string]interface{}{
+						"Tenant": map[string]interface{}{
+							"Type":  "String",
+							"Value": "acme",
+						},
+						"Content-Type": map[string]interface{}{
+							"Type":  "String",
+							"Value": "application/json",
+						},
+						"X-Amz-Sns-Topic-Arn": map[string]interface{}{
+							"Type":  "String",
+							"Value": "arn:aws:sns:us-east-1:123456789012:other",
+						},
+					},
+				},
+			},
+			{
+				SNS: events.SNSEntity{
+					MessageID: "message-2",
+					TopicArn:  "arn:aws:sns:us-east-1:123456789012:my-topic",
+					Message:   "world",
+				},
+			},
+		},
+	}
+	if err := handler(context.Background(), event); err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got, want := len(got), 2; got != want {
+		t.Fatalf("got=%d, want=%d", got, want)
+	}
+	r := got[0]
+	for _, tt := range []struct{ got, want string }{
+		{r.Method, "POST"},
+		{r.URL.Path, "/sns/my-topic"},
+		{r.RequestURI, "/sns/my-topic"},
+		{bodies[0], "hello"},
+		{bodies[1], "world"},
+		{r.Header.Get("X-Amz-Sns-Message-Type"), "Notification"},
+		{r.Header.Get("X-Amz-Sns-Message-Id"), "message-1"},
+		{r.Header.Get("X-Amz-Sns-Topic-Arn"), "arn:aws:sns:us-east-1:123456789012:my-topic"},
+		{r.Header.Get("X-Amz-Sns-Subject"), "greeting"},
+		{r.Header.Get("X-Amz-Sns-Attribute-Tenant"), "acme"},
+		{r.Header.Get("Tenant"), ""},
+		{r.Header.Get("Content-Type"), "text/plain; charset=UTF-8"},
+		{r.Header.Get("X-Amz-Sns-Attribute-Content-Type"), "application/json"},
+		{r.Header.Get("X-Amz-Sns-Topic-Arn"), "arn:aws:sns:us-east-1:123456789012:my-topic"},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("got=%q, want=%q", tt.got, tt.want)
+		}
+	}
+
+	event.Records[1].SNS.Message = "fail"
+	if err := handler(context.Background(), event); err == nil {
+		t.Error("got no error, want error")
+	}
+}
+
+func TestSNSPath(t *testing.T) {
+	var path string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	})
+	handler := snsHandler(h, newOptions([]Option{WithSNSPath("/webhooks/{topic}/notify")}))
+	event := events.SNSEvent{
+		Records: []events.SNSEventRecord{
+			{SNS: events.SNSEntity{TopicArn: "arn:aws:sns:us-east-1:123456789012:orders"}},
+		},
+	}
+	if err := handler(context.Background(), event); err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got, want := path, "/webhooks/orders/notify"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestSNSBufferPool(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	pool := &countingPool{}
+	handler := snsHandler(h, newOptions([]Option{WithBufferPool(pool)}))
+	if err := handler(context.Background(), events.SNSEvent{
+		Records: []events.SNSEventRecord{{}, {}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if pool.gets != 2 || pool.puts != pool.gets {
+		t.Errorf("got %d gets and %d puts, want 2 of each", pool.gets, pool.puts)
+	}
+}
@@ -0,0 +1,100 @@
+package apigatewayproxy
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// SpanHooks are called at the start and finish of each Lambda invocation,
+// so that tracing and APM libraries can instrument the adapter without
+// replacing the package level RequestReceived and SendingResponse callbacks.
+// Both hooks are optional.
+type SpanHooks struct {
+	// Start is called when an event is received from Lambda, before it is
+	// converted into an HTTP request. The event is a pointer to the raw
+	// event, such as *events.APIGatewayProxyRequest or
+	// *events.APIGatewayV2HTTPRequest. The returned context, which typically
+	// contains the new span, is used for the rest of the invocation. If the
+	// returned context is nil, ctx is used.
+	Start func(ctx context.Context, event interface{}) context.Context
+
+	// Finish is called with the context returned by Start just before the
+	// response is returned to Lambda. The response is a pointer to the
+	// response returned to Lambda, such as *events.APIGatewayProxyResponse
+	// or *events.APIGatewayV2HTTPResponse, or nil for events that have no
+	// response, such as SNS notifications. If the invocation failed, err is
+	// the error returned to Lambda.
+	Finish func(ctx context.Context, response interface{}, err error)
+}
+
+// WithSpanHooks configures the adapter to call hooks at the start and
+// finish of each Lambda invocation.
+func WithSpanHooks(hooks SpanHooks) Option {
+	return func(o *options) {
+		if hooks.Start == nil && hooks.Finish == nil {
+			o.spanHooks = nil
+			return
+		}
+		o.spanHooks = &hooks
+	}
+}
+
+// withSpan returns a Lambda handler that calls the span hooks configured
+// in o around handler.
+func withSpan[E, R any](o *options, handler func(context.Context, E) (R, error)) func(context.Context, E) (R, error) {
+	hooks := o.spanHooks
+	if hooks == nil {
+		return handler
+	}
+	return func(ctx context.Context, event E) (R, error) {
+		ctx = hooks.start(ctx, &event)
+		response, err := handler(ctx, event)
+		if hooks.Finish != nil {
+			hooks.Finish(ctx, spanResponse(&response), err)
+		}
+		return response, err
+	}
+}
+
+// withSpanNoResponse is the same as withSpan, for Lambda handlers that do
+// not return a response.
+func withSpanNoResponse[E any](o *options, handler func(context.Context, E) error) func(context.Context, E) error {
+	hooks := o.spanHooks
+	if hooks == nil {
+		return handler
+	}
+	return func(ctx context.Context, event E) error {
+		ctx = hooks.start(ctx, &event)
+		err := handler(ctx, event)
+		if hooks.Finish != nil {
+			hooks.Finish(ctx, nil, err)
+		}
+		return err
+	}
+}
+
+func (hooks *SpanHooks) start(ctx context.Context, event interface{}) context.Context {
+	if hooks.Start == nil {
+		return ctx
+	}
+	if spanCtx := hooks.Start(ctx, event); spanCtx != nil {
+		return spanCtx
+	}
+	return ctx
+}
+
+// spanResponse returns the response to pass to the Finish hook. The
+// internal response type is converted to its public equivalent.
+func spanResponse(response interface{}) interface{} {
+	if r, ok := response.(*apiGatewayProxyResponse); ok {
+		return &events.APIGatewayProxyResponse{
+			StatusCode:        r.StatusCode,
+			Headers:           r.Headers,
+			MultiValueHeaders: r.MultiValueHeaders,
+			Body:              r.Body,
+			IsBase64Encoded:   r.IsBase64Encoded,
+		}
+	}
+	return response
+}
@@ -0,0 +1,73 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestSpanHooks(t *testing.T) {
+	type spanKey struct{}
+	var gotEvent *events.APIGatewayProxyRequest
+	var gotResponse *events.APIGatewayProxyResponse
+	var gotSpan interface{}
+	opts := newOptions([]Option{WithSpanHooks(SpanHooks{
+		Start: func(ctx context.Context, event interface{}) context.Context {
+			gotEvent, _ = event.(*events.APIGatewayProxyRequest)
+			return context.WithValue(ctx, spanKey{}, "span-1")
+		},
+		Finish: func(ctx context.Context, response interface{}, err error) {
+			if err != nil {
+				t.Errorf("got %v, want no error", err)
+			}
+			gotSpan = ctx.Value(spanKey{})
+			gotResponse, _ = response.(*events.APIGatewayProxyResponse)
+		},
+	})})
+	var handlerSpan interface{}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = r.Context().Value(spanKey{})
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := withSpan(opts, apiGatewayHandler(opts.wrap(h), opts))
+	if _, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Path:       "/tea",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if gotEvent == nil || gotEvent.Path != "/tea" {
+		t.Errorf("got event %+v, want path /tea", gotEvent)
+	}
+	if handlerSpan != "span-1" {
+		t.Errorf("got handler span %v, want span-1", handlerSpan)
+	}
+	if gotSpan != "span-1" {
+		t.Errorf("got finish span %v, want span-1", gotSpan)
+	}
+	if gotResponse == nil || gotResponse.StatusCode != http.StatusTeapot {
+		t.Errorf("got response %+v, want status %d", gotResponse, http.StatusTeapot)
+	}
+}
+
+func TestSpanHooksNoResponse(t *testing.T) {
+	var finished bool
+	opts := newOptions([]Option{WithSpanHooks(SpanHooks{
+		Finish: func(ctx context.Context, response interface{}, err error) {
+			finished = true
+			if response != nil {
+				t.Errorf("got response %v, want nil", response)
+			}
+		},
+	})})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := withSpanNoResponse(opts, snsHandler(opts.wrap(h), opts))
+	if err := handler(context.Background(), events.SNSEvent{}); err != nil {
+		t.Fatal(err)
+	}
+	if !finished {
+		t.Error("got finish not called, want called")
+	}
+}
@@ -0,0 +1,253 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultSSEKeepAlive is the default interval between keep-alive comments
+// in a Server-Sent Events stream.
+const DefaultSSEKeepAlive = 15 * time.Second
+
+// sseKeepAliveComment is written to an idle event stream. Clients ignore
+// comments, so it only keeps the connection open.
+var sseKeepAliveComment = []byte(": keep-alive\n\n")
+
+// WithSSEKeepAlive sets the interval after which a keep-alive comment is
+// written to an idle Server-Sent Events stream, so that clients and proxies
+// do not close the connection while the handler waits for the next event.
+// The default interval is DefaultSSEKeepAlive, and an interval of zero
+// disables the comments.
+//
+// A response is an event stream if its Content-Type is text/event-stream
+// when the header is written. Each write to an event stream is sent to the
+// client immediately, without the handler calling Flush, both when streaming
+// responses from Lambda using StartStreaming and when running as a
+// conventional HTTP server. Comments are only written between events, so
+// an event can be written using more than one call to Write.
+func WithSSEKeepAlive(interval time.Duration) Option {
+	return func(o *options) {
+		o.sseKeepAlive = interval
+	}
+}
+
+// isEventStream reports whether the content type is text/event-stream.
+func isEventStream(contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "text/event-stream"
+}
+
+// sseWriter writes an event stream to w, and writes a keep-alive comment
+// when nothing has been written for the keep-alive interval. The comment is
+// written by another goroutine, so all writes to w must use the sseWriter.
+type sseWriter struct {
+	mutex    sync.Mutex
+	w        io.Writer
+	flush    func() error // nil if w does not buffer
+	interval time.Duration
+	last     time.Time // time of the last write
+	tail     []byte    // the last bytes written, to find the end of an event
+	stopped  bool
+	err      error // error writing a comment
+	done     chan struct{}
+}
+
+// newSSEWriter returns an sseWriter for w. If interval is positive, the
+// keep-alive comments are written until stop is called.
+func newSSEWriter(w io.Writer, flush func() error, interval time.Duration) *sseWriter {
+	s := &sseWriter{
+		w:        w,
+		flush:    flush,
+		interval: interval,
+		last:     time.Now(),
+		done:     make(chan struct{}),
+	}
+	if interval > 0 {
+		go s.keepAlive()
+	}
+	return s
+}
+
+// Write writes b to the stream, and flushes it.
+func (s *sseWriter) Write(b []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	n, err := s.w.Write(b)
+	s.wrote(b[:n])
+	if err != nil {
+		return n, err
+	}
+	return n, s.flushLocked()
+}
+
+// Flush flushes the stream.
+func (s *sseWriter) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.flushLocked()
+}
+
+func (s *sseWriter) flushLocked() error {
+	if s.flush == nil {
+		return nil
+	}
+	return s.flush()
+}
+
+// wrote records the time of the write, and the last bytes written.
+func (s *sseWriter) wrote(b []byte) {
+	s.last = time.Now()
+	s.tail = append(s.tail, b...)
+	if len(s.tail) > 4 {
+		s.tail = append(s.tail[:0], s.tail[len(s.tail)-4:]...)
+	}
+}
+
+// betweenEvents reports whether the stream is at the start, or ends with
+// the blank line that ends an event, so that a comment can be written.
+func (s *sseWriter) betweenEvents() bool {
+	if len(s.tail) == 0 {
+		return true
+	}
+	b, ok := trimLineEnd(s.tail)
+	if !ok {
+		return false
+	}
+	_, ok = trimLineEnd(b)
+	return ok
+}
+
+// trimLineEnd removes the line ending from the end of b, and reports whether
+// there was one. A line ends with CRLF, LF or CR.
+func trimLineEnd(b []byte) ([]byte, bool) {
+	switch {
+	case bytes.HasSuffix(b, []byte("\r\n")):
+		return b[:len(b)-2], true
+	case bytes.HasSuffix(b, []byte("\n")), bytes.HasSuffix(b, []byte("\r")):
+		return b[:len(b)-1], true
+	}
+	return b, false
+}
+
+// keepAlive writes a comment whenever the stream has been idle for the
+// interval, until stop is called.
+func (s *sseWriter) keepAlive() {
+	timer := time.NewTimer(s.interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-timer.C:
+		}
+		s.mutex.Lock()
+		if s.stopped || s.err != nil {
+			s.mutex.Unlock()
+			return
+		}
+		wait := s.interval - time.Since(s.last)
+		if wait <= 0 {
+			wait = s.interval
+			if s.betweenEvents() {
+				_, err := s.w.Write(sseKeepAliveComment)
+				if err == nil {
+					err = s.flushLocked()
+				}
+				s.last = time.Now()
+				s.err = err
+			}
+		}
+		s.mutex.Unlock()
+		timer.Reset(wait)
+	}
+}
+
+// stop stops the keep-alive comments. When stop returns, no more comments
+// are written.
+func (s *sseWriter) stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.done)
+	}
+}
+
+// sseHandler sends each write of an event stream to the client when it is
+// written, with keep-alive comments, for requests that are not received from
+// Lambda. Lambda requests are streamed by the response writer of
+// StartStreaming.
+func sseHandler(h http.Handler, interval time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(ctxKeyInvocation) != nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+		sw := &sseResponseWriter{ResponseWriter: w, interval: interval}
+		defer sw.stop()
+		h.ServeHTTP(sw, r)
+	})
+}
+
+// sseResponseWriter flushes each write if the response is an event stream.
+type sseResponseWriter struct {
+	http.ResponseWriter
+	interval    time.Duration
+	wroteHeader bool
+	events      *sseWriter // non-nil for an event stream
+}
+
+func (w *sseResponseWriter) WriteHeader(status int) {
+	w.ResponseWriter.WriteHeader(status)
+	if w.wroteHeader || status < 200 {
+		// informational responses are followed by the final header
+		return
+	}
+	w.wroteHeader = true
+	if isEventStream(w.Header().Get("Content-Type")) {
+		rc := http.NewResponseController(w.ResponseWriter)
+		// send the header, so the client knows the stream has started
+		rc.Flush()
+		w.events = newSSEWriter(w.ResponseWriter, rc.Flush, w.interval)
+	}
+}
+
+func (w *sseResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.events != nil {
+		return w.events.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher.
+func (w *sseResponseWriter) Flush() {
+	if w.events != nil {
+		w.events.Flush()
+		return
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (w *sseResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *sseResponseWriter) stop() {
+	if w.events != nil {
+		w.events.stop()
+	}
+}
@@ -0,0 +1,115 @@
+package apigatewayproxy
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSSEBetweenEvents(t *testing.T) {
+	tests := []struct {
+		writes []string
+		want   bool
+	}{
+		{writes: nil, want: true},
+		{writes: []string{"data: 1\n\n"}, want: true},
+		{writes: []string{"data: 1\n", "\n"}, want: true},
+		{writes: []string{"data: 1\r\n\r\n"}, want: true},
+		{writes: []string{"data: 1\r\r"}, want: true},
+		{writes: []string{"data: 1\n"}, want: false},
+		{writes: []string{"data: 1"}, want: false},
+		{writes: []string{"data: 1\n\n", "data: 2\n"}, want: false},
+	}
+	for i, tt := range tests {
+		s := &sseWriter{}
+		for _, w := range tt.writes {
+			s.wrote([]byte(w))
+		}
+		if got, want := s.betweenEvents(), tt.want; got != want {
+			t.Errorf("%d: got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestSSELocal(t *testing.T) {
+	next := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: 1\n\n"))
+		// wait until the client has received the first event, which is
+		// sent without calling Flush
+		<-next
+		w.Write([]byte("data: 2\n"))
+		time.Sleep(30 * time.Millisecond)
+		w.Write([]byte("\n"))
+		time.Sleep(30 * time.Millisecond)
+		w.Write([]byte("data: 3\n\n"))
+	})
+	server := httptest.NewServer(Handler(h, WithSSEKeepAlive(10*time.Millisecond)))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	var comments []int // number of event lines before each comment
+	skipBlank := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "data: 1":
+			close(next)
+		case line == ": keep-alive":
+			comments = append(comments, len(lines))
+			skipBlank = true
+			continue
+		case line == "" && skipBlank:
+			skipBlank = false
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if got, want := lines, []string{"data: 1", "", "data: 2", "", "data: 3", ""}; !equalStrings(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(comments) == 0 {
+		t.Error("got no keep-alive comments")
+	}
+	for _, n := range comments {
+		if n%2 != 0 {
+			t.Errorf("got comment after %d lines, want between events", n)
+		}
+	}
+}
+
+func TestSSENotEventStream(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hello"))
+	})
+	w := httptest.NewRecorder()
+	Handler(h).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Flushed {
+		t.Error("got flushed response, want not flushed")
+	}
+	if got, want := w.Body.String(), "hello"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package apigatewayproxy
+
+import (
+	"net/http"
+)
+
+// WithStageHandler configures the adapter to pass requests for the API
+// Gateway stage to h, instead of the handler passed to Start. This allows
+// one function to behave differently for each stage it serves, for example
+// by wrapping the handler in middleware that returns verbose errors in a
+// "dev" stage. Requests for stages without a stage handler, and requests
+// that are not associated with a stage, such as when running as a
+// conventional HTTP server, are passed to the handler passed to Start.
+//
+// The features configured by the other options apply to all handlers.
+func WithStageHandler(stage string, h http.Handler) Option {
+	return func(o *options) {
+		if o.stageHandlers == nil {
+			o.stageHandlers = make(map[string]http.Handler)
+		}
+		o.stageHandlers[stage] = h
+	}
+}
+
+// stageHandler passes each request to the handler for its stage, or to h
+// if there is no handler for the stage.
+func stageHandler(h http.Handler, handlers map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info := Info(r.Context()); info != nil {
+			if sh, ok := handlers[info.Stage]; ok {
+				sh.ServeHTTP(w, r)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,65 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestStageHandler(t *testing.T) {
+	handlerFor := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name)
+		})
+	}
+	opts := newOptions([]Option{
+		WithStageHandler("dev", handlerFor("dev")),
+		WithStageHandler("staging", handlerFor("staging")),
+	})
+	handler := apiGatewayHandler(opts.wrap(handlerFor("default")), opts)
+
+	for stage, want := range map[string]string{
+		"dev":     "dev",
+		"staging": "staging",
+		"prod":    "default",
+		"":        "default",
+	} {
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod:     "GET",
+			Path:           "/",
+			RequestContext: events.APIGatewayProxyRequestContext{Stage: stage},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := response.Body; got != want {
+			t.Errorf("%s: got=%q, want=%q", stage, got, want)
+		}
+	}
+}
+
+func TestStageHandlerStrict(t *testing.T) {
+	var hijackErr error
+	opts := newOptions([]Option{
+		WithStrict(UnsupportedHijack),
+		WithStageHandler("dev", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _, hijackErr = http.NewResponseController(w).Hijack()
+		})),
+	})
+	handler := apiGatewayHandler(opts.wrap(http.NotFoundHandler()), opts)
+	_, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:     "GET",
+		Path:           "/",
+		RequestContext: events.APIGatewayProxyRequestContext{Stage: "dev"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(hijackErr, ErrUnsupported) {
+		t.Errorf("got %v, want ErrUnsupported", hijackErr)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !apigatewayproxy.runtimeapi
+
+package apigatewayproxy
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// startLambda starts handling Lambda invocations using the aws-lambda-go
+// package, with payloads encoded by the configured JSON codec. Build with
+// the apigatewayproxy.runtimeapi tag to use the built-in runtime API client
+// instead.
+func startLambda[E, R any](o *options, fn func(context.Context, E) (R, error)) {
+	o.startTelemetry()
+	lambda.StartHandler(o.flushInvoke(o.recordInvoke(jsonInvoke(o.jsonCodec, fn))))
+}
+
+// startLambdaNoResponse is like startLambda, for handlers that do not
+// return a response.
+func startLambdaNoResponse[E any](o *options, fn func(context.Context, E) error) {
+	o.startTelemetry()
+	lambda.StartHandler(o.flushInvoke(o.recordInvoke(jsonInvokeNoResponse(o.jsonCodec, fn))))
+}
@@ -0,0 +1,34 @@
+//go:build apigatewayproxy.runtimeapi
+
+package apigatewayproxy
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// startLambda starts handling Lambda invocations using the built-in runtime
+// API client, which does not depend on the aws-lambda-go/lambda package, so
+// the binary does not include its RPC support. This requires a runtime that
+// provides the Lambda runtime API, such as provided.al2.
+func startLambda[E, R any](o *options, fn func(context.Context, E) (R, error)) {
+	o.startTelemetry()
+	startRuntimeAPI(o.flushInvoke(o.recordInvoke(jsonInvoke(o.jsonCodec, fn))))
+}
+
+// startLambdaNoResponse is like startLambda, for handlers that do not
+// return a response.
+func startLambdaNoResponse[E any](o *options, fn func(context.Context, E) error) {
+	o.startTelemetry()
+	startRuntimeAPI(o.flushInvoke(o.recordInvoke(jsonInvokeNoResponse(o.jsonCodec, fn))))
+}
+
+// startRuntimeAPI runs the runtime API loop. The loop only returns if there
+// is an error, in which case the process exits so that Lambda starts a new
+// container, in the same way as the aws-lambda-go package.
+func startRuntimeAPI(invoke invokeFunc) {
+	err := runtimeLoop(context.Background(), os.Getenv("AWS_LAMBDA_RUNTIME_API"), invoke)
+	slog.Error("lambda runtime API loop stopped", "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,328 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// StartStreaming starts handling Lambda function URL requests by passing
+// each request to the HTTP handler, and streaming the response to the
+// client. The function URL must use the RESPONSE_STREAM invoke mode. Use
+// RequestV2 to obtain the request event, which has the same format as an
+// API Gateway HTTP API payload format version 2.0 event.
+//
+// The status code and headers are sent when the handler first calls Flush,
+// and the body written so far is sent each time the handler calls Flush,
+// using http.Flusher or http.ResponseController. Server-Sent Events are sent
+// as they are written: see WithSSEKeepAlive, and so is every response when
+// configured using WithStreamingWrites. If the handler returns without
+// flushing, the whole response is sent as it would be by StartV2, including
+// any compression. Once the response has started it is not compressed, and
+// it is not replaced by an error response if the handler panics.
+//
+// StartStreaming uses the built-in client for the Lambda runtime API
+// regardless of build tags, because the aws-lambda-go package does not
+// support streaming responses, so it requires a runtime such as
+// provided.al2.
+func StartStreaming(h http.Handler, opts ...Option) {
+	o := newOptions(opts)
+	o.startTelemetry()
+	err := runtimeStreamLoop(context.Background(), os.Getenv("AWS_LAMBDA_RUNTIME_API"), streamingHandler(o.wrap(h), o))
+	slog.Error("lambda runtime API loop stopped", "error", err)
+	os.Exit(1)
+}
+
+// WithStreamingWrites configures StartStreaming to send each Write to the
+// client immediately, instead of buffering the body until the handler calls
+// Flush, which suits large exports such as newline-delimited JSON. Each
+// Write blocks until the data has been sent to the Lambda runtime API, so
+// a handler that produces data faster than the client receives it is slowed
+// down rather than buffering the result in memory. Each Write is sent
+// separately, so wrap the response writer in a bufio.Writer when writing
+// many small records.
+//
+// The response is not compressed, and the Content-Length header is not
+// set. The option has no effect on the other Start functions, or when
+// running as a conventional HTTP server.
+func WithStreamingWrites() Option {
+	return func(o *options) {
+		o.streamWrites = true
+	}
+}
+
+// streamInvokeFunc handles a Lambda invocation with a JSON payload, and
+// writes the response to w as it is produced.
+type streamInvokeFunc func(ctx context.Context, payload []byte, w io.Writer) error
+
+// Lambda runtime API headers and trailers for streamed responses.
+const (
+	runtimeResponseModeHeader = "Lambda-Runtime-Function-Response-Mode"
+	runtimeErrorTypeTrailer   = "Lambda-Runtime-Function-Error-Type"
+	runtimeErrorBodyTrailer   = "Lambda-Runtime-Function-Error-Body"
+)
+
+// streamContentType is the content type of a streamed function URL
+// response, which starts with a JSON prelude containing the status code and
+// headers, followed by streamDelimiter and the body.
+const streamContentType = "application/vnd.awslambda.http-integration-response"
+
+// streamDelimiter separates the prelude from the body of a streamed response.
+var streamDelimiter = make([]byte, 8)
+
+// streamPrelude is the status code and headers of a streamed response.
+type streamPrelude struct {
+	StatusCode int               `json:"statusCode"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Cookies    []string          `json:"cookies,omitempty"`
+}
+
+// runtimeStreamLoop is like runtimeLoop, for invocations whose response is
+// streamed to the Lambda runtime API.
+func runtimeStreamLoop(ctx context.Context, address string, invoke streamInvokeFunc) error {
+	return runtimeNextLoop(ctx, address, func(ctx context.Context, client *http.Client, url string, header http.Header, payload []byte) error {
+		id := header.Get(runtimeRequestIDHeader)
+		var panicked bool
+		var invokeErr error
+		// the response is sent even if ctx is cancelled while the handler runs
+		err := runtimeStreamPost(context.WithoutCancel(ctx), client, url+"/response", func(w io.Writer) error {
+			panicked, invokeErr = runtimeStreamInvoke(ctx, invoke, header, payload, w)
+			return invokeErr
+		})
+		if err != nil {
+			return kv.Wrap(err, "cannot send invocation result").With("requestId", id)
+		}
+		if panicked {
+			return kv.Wrap(invokeErr, "handler panicked").With("requestId", id)
+		}
+		return nil
+	})
+}
+
+// runtimeStreamInvoke is like runtimeInvoke, for a streamed response.
+func runtimeStreamInvoke(ctx context.Context, invoke streamInvokeFunc, header http.Header, payload []byte, w io.Writer) (panicked bool, err error) {
+	ctx, cancel := runtimeContext(ctx, header)
+	defer cancel()
+	defer func() {
+		if v := recover(); v != nil {
+			panicked, err = true, fmt.Errorf("%v", v)
+		}
+	}()
+	return false, invoke(ctx, payload, w)
+}
+
+// runtimeStreamPost sends an invocation result to the runtime API as it is
+// written by send. The request body is a pipe, so each write blocks until
+// it has been sent. If send returns an error, it is reported in the request
+// trailers, because the response may already have been partly sent.
+func runtimeStreamPost(ctx context.Context, client *http.Client, url string, send func(w io.Writer) error) error {
+	pr, pw := io.Pipe()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, pr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", streamContentType)
+	req.Header.Set(runtimeResponseModeHeader, "streaming")
+	req.Trailer = http.Header{
+		runtimeErrorTypeTrailer: nil,
+		runtimeErrorBodyTrailer: nil,
+	}
+	result := make(chan error, 1)
+	go func() {
+		resp, err := client.Do(req)
+		if err == nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusAccepted {
+				err = kv.NewError("unexpected status").With("status", resp.StatusCode)
+			}
+		}
+		// fail any further writes if the request has failed
+		pr.CloseWithError(err)
+		result <- err
+	}()
+	if err := send(pw); err != nil {
+		req.Trailer.Set(runtimeErrorTypeTrailer, runtimeErrorType(err))
+		req.Trailer.Set(runtimeErrorBodyTrailer, base64.StdEncoding.EncodeToString(runtimeErrorBody(err)))
+	}
+	pw.Close()
+	return <-result
+}
+
+// streamingHandler returns the streamInvokeFunc for function URL requests.
+func streamingHandler(h http.Handler, o *options) streamInvokeFunc {
+	return func(ctx context.Context, payload []byte, w io.Writer) error {
+		var request events.APIGatewayV2HTTPRequest
+		if err := o.jsonCodec.Unmarshal(payload, &request); err != nil {
+			return kv.Wrap(err, "cannot decode event")
+		}
+		err := withSpanNoResponse(o, func(ctx context.Context, request events.APIGatewayV2HTTPRequest) error {
+			return serveStream(ctx, h, o, &request, w)
+		})(ctx, request)
+		if len(o.flushes) > 0 {
+			o.flush(ctx)
+		}
+		return err
+	}
+}
+
+// serveStream passes the request to the handler, and writes the response
+// to w.
+func serveStream(ctx context.Context, h http.Handler, o *options, request *events.APIGatewayV2HTTPRequest, w io.Writer) error {
+	inv := newInvocation(o)
+	defer inv.release()
+	s := &responseStream{w: w, opts: o}
+	defer s.stop()
+	r, err := newV2Request(ctx, inv, request)
+	if err != nil {
+		response, err := inv.fail(err)
+		if err != nil {
+			return err
+		}
+		return s.writeResponse(&response)
+	}
+	inv.writer.stream = s
+	rw := inv.serve(h, r)
+	if !s.started {
+		if err := s.writeResponse(&rw.response2); err != nil {
+			return err
+		}
+	}
+	if s.err != nil {
+		return kv.Wrap(s.err, "cannot stream response")
+	}
+	return rw.err
+}
+
+// responseStream sends a response to the Lambda runtime API as the handler
+// writes it.
+type responseStream struct {
+	w            io.Writer // request body of the runtime API request
+	opts         *options
+	out          io.Writer // w, or the sseWriter for an event stream
+	events       *sseWriter
+	started      bool // the prelude has been sent
+	flushOnWrite bool // each write is sent immediately
+	err          error
+}
+
+// start sends the prelude containing the status code and headers.
+func (s *responseStream) start(response *apiGatewayProxyResponse) error {
+	s.started = true
+	s.out = s.w
+	if s.flushOnWrite && isEventStream(response.Headers["Content-Type"]) {
+		s.events = newSSEWriter(s.w, nil, s.opts.sseKeepAlive)
+		s.out = s.events
+	}
+	v2 := v2Response(response)
+	prelude, err := s.opts.jsonCodec.Marshal(streamPrelude{
+		StatusCode: v2.StatusCode,
+		Headers:    v2.Headers,
+		Cookies:    v2.Cookies,
+	})
+	if err != nil {
+		return s.fail(kv.Wrap(err, "cannot marshal response prelude"))
+	}
+	if _, err := s.w.Write(prelude); err != nil {
+		return s.fail(err)
+	}
+	if _, err := s.w.Write(streamDelimiter); err != nil {
+		return s.fail(err)
+	}
+	return nil
+}
+
+// write sends part of the body.
+func (s *responseStream) write(b []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	n, err := s.out.Write(b)
+	if err != nil {
+		s.fail(err)
+	}
+	return n, err
+}
+
+// writeResponse sends the complete response, if the handler did not start
+// streaming it.
+func (s *responseStream) writeResponse(response *apiGatewayProxyResponse) error {
+	if err := s.start(response); err != nil {
+		return err
+	}
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		var err error
+		if body, err = s.opts.base64Encoding.DecodeString(response.Body); err != nil {
+			return s.fail(kv.Wrap(err, "cannot decode response body"))
+		}
+	}
+	_, err := s.write(body)
+	return err
+}
+
+func (s *responseStream) fail(err error) error {
+	if s.err == nil {
+		s.err = err
+	}
+	return s.err
+}
+
+// stop stops any keep-alive comments.
+func (s *responseStream) stop() {
+	if s.events != nil {
+		s.events.stop()
+	}
+}
+
+// startStream sends the status code and headers when the handler starts
+// streaming the response, followed by the body written so far. The hop-by-hop
+// headers are removed and the content type is sniffed, as for a buffered
+// response, but the response is not compressed.
+func (w *responseWriter) startStream() {
+	if w.invalidHeader {
+		// the response is replaced when the handler returns
+		return
+	}
+	w.stripHopByHopHeaders()
+	if !w.opts.noSniff {
+		w.sniffContentType()
+	}
+	if err := w.stream.start(&w.response2); err != nil {
+		return
+	}
+	w.flushStream()
+}
+
+// flushStream sends the body written since the last flush.
+func (w *responseWriter) flushStream() {
+	if w.body != nil && w.body.Len() > 0 {
+		w.stream.write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
+
+// Flush implements http.Flusher. When streaming the response using
+// StartStreaming, it sends the response written so far. Otherwise it does
+// nothing, because the response is returned when the handler returns.
+func (w *responseWriter) Flush() {
+	s := w.stream
+	if s == nil {
+		return
+	}
+	if !w.headersWritten {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !s.started {
+		w.startStream()
+		return
+	}
+	w.flushStream()
+}
@@ -0,0 +1,194 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// chunkWriter records each write.
+type chunkWriter struct {
+	chunks []string
+}
+
+func (w *chunkWriter) Write(b []byte) (int, error) {
+	w.chunks = append(w.chunks, string(b))
+	return len(b), nil
+}
+
+func TestStreamingHandler(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/buffered":
+			w.Header().Set("Content-Type", "text/plain")
+			http.SetCookie(w, &http.Cookie{Name: "a", Value: "1"})
+			io.WriteString(w, "hello, ")
+			io.WriteString(w, "world")
+		case "/flush":
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusAccepted)
+			io.WriteString(w, "a")
+			io.WriteString(w, "b")
+			http.NewResponseController(w).Flush()
+			io.WriteString(w, "c")
+			io.WriteString(w, "d")
+		case "/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "data: 1\n\n")
+			io.WriteString(w, "data: 2\n\n")
+		}
+	})
+	tests := []struct {
+		path    string
+		prelude string
+		chunks  []string
+	}{
+		{
+			path:    "/buffered",
+			prelude: `{"statusCode":200,"headers":{"Content-Type":"text/plain"},"cookies":["a=1"]}`,
+			chunks:  []string{"hello, world"},
+		},
+		{
+			path:    "/flush",
+			prelude: `{"statusCode":202,"headers":{"Content-Type":"text/plain"}}`,
+			chunks:  []string{"ab", "cd"},
+		},
+		{
+			path:    "/events",
+			prelude: `{"statusCode":200,"headers":{"Content-Type":"text/event-stream"}}`,
+			chunks:  []string{"data: 1\n\n", "data: 2\n\n"},
+		},
+	}
+	o := newOptions(nil)
+	invoke := streamingHandler(o.wrap(h), o)
+	for i, tt := range tests {
+		payload, _ := json.Marshal(events.APIGatewayV2HTTPRequest{
+			RawPath: tt.path,
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET"},
+			},
+		})
+		var w chunkWriter
+		if err := invoke(context.Background(), payload, &w); err != nil {
+			t.Errorf("%d: got %v, want no error", i, err)
+			continue
+		}
+		want := append([]string{tt.prelude, string(streamDelimiter)}, tt.chunks...)
+		if got := w.chunks; !equalStrings(got, want) {
+			t.Errorf("%d: got %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestRuntimeStreamLoop(t *testing.T) {
+	type result struct {
+		path    string
+		header  http.Header
+		body    string
+		trailer http.Header
+	}
+	results := make(chan result, 1)
+	sent := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/2018-06-01/runtime/invocation/")
+		if path == "next" {
+			if sent {
+				<-r.Context().Done()
+				return
+			}
+			sent = true
+			w.Header().Set(runtimeRequestIDHeader, "req-1")
+			w.Header().Set(runtimeDeadlineHeader, strconv.FormatInt(time.Now().Add(time.Minute).UnixMilli(), 10))
+			w.Write([]byte("{}"))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+		results <- result{path: path, header: r.Header, body: string(body), trailer: r.Trailer}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- runtimeStreamLoop(ctx, strings.TrimPrefix(server.URL, "http://"), func(ctx context.Context, payload []byte, w io.Writer) error {
+			io.WriteString(w, "partial")
+			panic("boom")
+		})
+	}()
+
+	var got result
+	select {
+	case got = <-results:
+	case err := <-done:
+		t.Fatalf("got %v, want result", err)
+	}
+	if want := "req-1/response"; got.path != want {
+		t.Errorf("got path %q, want %q", got.path, want)
+	}
+	if got, want := got.header.Get(runtimeResponseModeHeader), "streaming"; got != want {
+		t.Errorf("got response mode %q, want %q", got, want)
+	}
+	if got, want := got.header.Get("Content-Type"), streamContentType; got != want {
+		t.Errorf("got content type %q, want %q", got, want)
+	}
+	if got, want := got.body, "partial"; got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+	if got, want := got.trailer.Get(runtimeErrorTypeTrailer), "errorString"; got != want {
+		t.Errorf("got error type %q, want %q", got, want)
+	}
+	errorBody, _ := base64.StdEncoding.DecodeString(got.trailer.Get(runtimeErrorBodyTrailer))
+	if !bytes.Contains(errorBody, []byte(`"errorMessage":"boom"`)) {
+		t.Errorf("got error body %s, want boom", errorBody)
+	}
+	// the loop stops after a panic, so that Lambda starts a new container
+	if err := <-done; err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("got %v, want panic error", err)
+	}
+}
+
+func TestWithStreamingWrites(t *testing.T) {
+	var w chunkWriter
+	h := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 1; i <= 3; i++ {
+			io.WriteString(rw, `{"n":`+strconv.Itoa(i)+"}\n")
+			// each write has been sent before the next one
+			w.chunks = append(w.chunks, "sent")
+		}
+	})
+	o := newOptions([]Option{WithStreamingWrites(), WithCompression(1)})
+	invoke := streamingHandler(o.wrap(h), o)
+	payload, _ := json.Marshal(events.APIGatewayV2HTTPRequest{
+		RawPath: "/export",
+		Headers: map[string]string{"Accept-Encoding": "gzip"},
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "GET"},
+		},
+	})
+	if err := invoke(context.Background(), payload, &w); err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	want := []string{
+		`{"statusCode":200,"headers":{"Content-Type":"application/x-ndjson"}}`,
+		string(streamDelimiter),
+		`{"n":1}` + "\n", "sent",
+		`{"n":2}` + "\n", "sent",
+		`{"n":3}` + "\n", "sent",
+	}
+	if got := w.chunks; !equalStrings(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
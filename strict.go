@@ -0,0 +1,162 @@
+package apigatewayproxy
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/jjeffery/kv"
+)
+
+// Unsupported is a set of http.ResponseWriter features that cannot work
+// when the response is returned to API Gateway.
+type Unsupported int
+
+// Unsupported features
+const (
+	// UnsupportedHijack is http.Hijacker: there is no connection to take over.
+	UnsupportedHijack Unsupported = 1 << iota
+
+	// UnsupportedPush is http.Pusher: API Gateway does not support HTTP/2
+	// server push.
+	UnsupportedPush
+
+	// UnsupportedTrailers is trailers, which are dropped because the
+	// response is buffered and returned without a chunked body. Trailers in
+	// gRPC-Web responses are returned in the body, and are supported.
+	UnsupportedTrailers
+
+	// UnsupportedAll is all of the unsupported features.
+	UnsupportedAll = UnsupportedHijack | UnsupportedPush | UnsupportedTrailers
+)
+
+// String returns a description of the features, such as "hijack|push".
+func (u Unsupported) String() string {
+	var names []string
+	for _, f := range unsupportedFeatures {
+		if u&f.feature != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// unsupportedFeatures has the name and use count of each feature.
+var unsupportedFeatures = []struct {
+	feature Unsupported
+	name    string
+	count   *int64
+}{
+	{UnsupportedHijack, "hijack", new(int64)},
+	{UnsupportedPush, "push", new(int64)},
+	{UnsupportedTrailers, "trailers", new(int64)},
+}
+
+// UnsupportedCount returns the number of times the handlers in this process
+// have used the unsupported features while they were configured as strict
+// using WithStrict.
+func UnsupportedCount(features Unsupported) int64 {
+	var count int64
+	for _, f := range unsupportedFeatures {
+		if features&f.feature != 0 {
+			count += atomic.LoadInt64(f.count)
+		}
+	}
+	return count
+}
+
+// countUnsupported increments the use count of feature.
+func countUnsupported(feature Unsupported) {
+	for _, f := range unsupportedFeatures {
+		if feature == f.feature {
+			atomic.AddInt64(f.count, 1)
+		}
+	}
+}
+
+// WithStrict configures the adapter to make the unsupported features fail
+// explicitly, instead of silently doing nothing. Hijack and Push return an
+// error wrapping ErrUnsupported, and trailers set by the handler are logged
+// as an error. Each use is counted, see UnsupportedCount.
+//
+// The features fail in the same way when running as a conventional HTTP
+// server, where they would otherwise work, so that incompatibilities are
+// discovered during local development and testing rather than in production.
+func WithStrict(features Unsupported) Option {
+	return func(o *options) {
+		o.strict = features
+	}
+}
+
+// strictHandler passes a response writer to h that fails for the strict
+// features, and checks the response for trailers.
+func strictHandler(h http.Handler, strict Unsupported) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strict&(UnsupportedHijack|UnsupportedPush) != 0 {
+			w = &strictWriter{ResponseWriter: w, strict: strict}
+		}
+		h.ServeHTTP(w, r)
+		if strict&UnsupportedTrailers != 0 {
+			if names := trailerNames(w.Header()); len(names) > 0 && !isGRPCWeb(w.Header().Get("Content-Type")) {
+				countUnsupported(UnsupportedTrailers)
+				Logger(r.Context()).Error("trailers are not supported", "trailers", names, "error", ErrUnsupported)
+			}
+		}
+	})
+}
+
+// trailerNames returns the names of the trailers in header: those declared
+// in the Trailer header, and those with the http.TrailerPrefix.
+func trailerNames(header http.Header) []string {
+	var names []string
+	for _, v := range header.Values("Trailer") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	for name := range header {
+		if strings.HasPrefix(name, http.TrailerPrefix) {
+			names = append(names, strings.TrimPrefix(name, http.TrailerPrefix))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// strictWriter implements http.Hijacker and http.Pusher, and returns an
+// error for the strict features.
+type strictWriter struct {
+	http.ResponseWriter
+	strict Unsupported
+}
+
+// Hijack implements http.Hijacker.
+func (w *strictWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.strict&UnsupportedHijack != 0 {
+		countUnsupported(UnsupportedHijack)
+		return nil, nil, kv.Wrap(ErrUnsupported, "cannot hijack connection")
+	}
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Push implements http.Pusher.
+func (w *strictWriter) Push(target string, opts *http.PushOptions) error {
+	if w.strict&UnsupportedPush != 0 {
+		countUnsupported(UnsupportedPush)
+		return kv.Wrap(ErrUnsupported, "cannot push").With("target", target)
+	}
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the underlying response writer, for http.ResponseController.
+func (w *strictWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,97 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestWithStrict(t *testing.T) {
+	var hijackErr, pushErr error
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, hijackErr = http.NewResponseController(w).Hijack()
+		if pusher, ok := w.(http.Pusher); ok {
+			pushErr = pusher.Push("/style.css", nil)
+		} else {
+			pushErr = http.ErrNotSupported
+		}
+		w.Header().Set("Trailer", "X-Checksum")
+		w.Write([]byte("ok"))
+		w.Header().Set("X-Checksum", "abc")
+	})
+	tests := []struct {
+		strict    Unsupported
+		wantCount [3]int64 // hijack, push, trailers
+	}{
+		{strict: UnsupportedAll, wantCount: [3]int64{1, 1, 1}},
+		{strict: UnsupportedHijack, wantCount: [3]int64{1, 0, 0}},
+		{strict: UnsupportedPush | UnsupportedTrailers, wantCount: [3]int64{0, 1, 1}},
+		{strict: 0},
+	}
+	for i, tt := range tests {
+		before := [3]int64{
+			UnsupportedCount(UnsupportedHijack),
+			UnsupportedCount(UnsupportedPush),
+			UnsupportedCount(UnsupportedTrailers),
+		}
+		o := newOptions([]Option{WithStrict(tt.strict)})
+		handler := apiGatewayHandler(o.wrap(h), o)
+		response, err := handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/"})
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.Body, "ok"; got != want {
+			t.Errorf("%d: got body %q, want %q", i, got, want)
+		}
+		for _, err := range []error{hijackErr, pushErr} {
+			if !errors.Is(err, http.ErrNotSupported) {
+				t.Errorf("%d: got error %v, want http.ErrNotSupported", i, err)
+			}
+		}
+		if got, want := errors.Is(hijackErr, ErrUnsupported), tt.strict&UnsupportedHijack != 0; got != want {
+			t.Errorf("%d: got hijack error %v, want ErrUnsupported=%v", i, hijackErr, want)
+		}
+		if got, want := errors.Is(pushErr, ErrUnsupported), tt.strict&UnsupportedPush != 0; got != want {
+			t.Errorf("%d: got push error %v, want ErrUnsupported=%v", i, pushErr, want)
+		}
+		got := [3]int64{
+			UnsupportedCount(UnsupportedHijack) - before[0],
+			UnsupportedCount(UnsupportedPush) - before[1],
+			UnsupportedCount(UnsupportedTrailers) - before[2],
+		}
+		if got != tt.wantCount {
+			t.Errorf("%d: got counts %v, want %v", i, got, tt.wantCount)
+		}
+	}
+}
+
+func TestUnsupportedString(t *testing.T) {
+	tests := []struct {
+		features Unsupported
+		want     string
+	}{
+		{0, ""},
+		{UnsupportedPush, "push"},
+		{UnsupportedAll, "hijack|push|trailers"},
+	}
+	for _, tt := range tests {
+		if got := tt.features.String(); got != tt.want {
+			t.Errorf("%d: got %q, want %q", int(tt.features), got, tt.want)
+		}
+	}
+}
+
+func TestTrailerNames(t *testing.T) {
+	header := http.Header{
+		"Trailer":                    {"x-checksum, X-Count"},
+		http.TrailerPrefix + "X-Sum": {"1"},
+		"Content-Type":               {"text/plain"},
+	}
+	if got, want := trailerNames(header), []string{"X-Checksum", "X-Count", "X-Sum"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
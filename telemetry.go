@@ -0,0 +1,341 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/jjeffery/kv"
+)
+
+// PlatformMetrics are the metrics reported by the Lambda platform for one
+// invocation, received from the Lambda Telemetry API.
+type PlatformMetrics struct {
+	RequestID       string        // Lambda request ID
+	Status          string        // "success", "failure", "error" or "timeout"
+	RuntimeDuration time.Duration // time until the runtime returned the response
+	ProducedBytes   int64         // size of the response, if reported
+	Duration        time.Duration // duration of the invocation, including extensions
+	BilledDuration  time.Duration
+	MemorySizeMB    int
+	MaxMemoryUsedMB int
+	InitDuration    time.Duration // duration of the init phase, for a cold start
+}
+
+// A PlatformUsageReporter is a UsageReporter that also receives the metrics
+// reported by the Lambda platform for each invocation, see WithTelemetryAPI.
+type PlatformUsageReporter interface {
+	UsageReporter
+
+	// ReportPlatformUsage is called when the platform metrics for an
+	// invocation are received, which is usually during a later invocation.
+	// The usage is the usage reported to ReportUsage for the same
+	// invocation, or nil if there is none.
+	ReportPlatformUsage(usage *Usage, metrics *PlatformMetrics)
+}
+
+// WithTelemetryAPI configures the adapter to register as an internal Lambda
+// extension, and subscribe to the platform events of the Lambda Telemetry
+// API. The runtimeDone and report events for each invocation are combined
+// with the usage reported by the adapter, and passed to the usage reporter
+// configured using WithUsageReporter, which must implement
+// PlatformUsageReporter. This means that the billed duration and the memory
+// used reported by Lambda can be recorded alongside the request and response
+// sizes and the handler time measured by the adapter.
+//
+// Lambda buffers telemetry events, so the metrics for an invocation are
+// usually reported during the next invocation, and the metrics for the last
+// invocation before the environment is shut down may not be reported at all.
+// If the extension cannot be registered, the error is logged and requests
+// are handled as usual. The option has no effect unless running in AWS
+// Lambda, or if the usage reporter does not implement PlatformUsageReporter.
+func WithTelemetryAPI() Option {
+	return func(o *options) {
+		o.telemetryAPI = true
+	}
+}
+
+// maxPendingTelemetry is the maximum number of invocations whose usage is
+// kept while waiting for their platform metrics.
+const maxPendingTelemetry = 100
+
+// Lambda extensions and telemetry API details.
+const (
+	extensionNameHeader       = "Lambda-Extension-Name"
+	extensionIdentifierHeader = "Lambda-Extension-Identifier"
+	telemetrySchemaVersion    = "2022-12-13"
+)
+
+// telemetryListenAddress is the address on which telemetry events are
+// received. It is a variable so that it can be replaced in tests.
+var telemetryListenAddress = "sandbox.localdomain:0"
+
+// telemetryCollector correlates the usage of each invocation with the
+// platform metrics received from the Telemetry API.
+type telemetryCollector struct {
+	reporter PlatformUsageReporter
+	mu       sync.Mutex
+	pending  map[string]*pendingTelemetry
+	order    []string // request IDs in pending, oldest first
+}
+
+// pendingTelemetry is the usage and metrics received so far for one
+// invocation.
+type pendingTelemetry struct {
+	usage   *Usage
+	metrics PlatformMetrics
+}
+
+func newTelemetryCollector(reporter PlatformUsageReporter) *telemetryCollector {
+	return &telemetryCollector{
+		reporter: reporter,
+		pending:  make(map[string]*pendingTelemetry),
+	}
+}
+
+// get returns the pending telemetry for the request ID, adding it if
+// necessary. The oldest invocation is discarded if there are too many.
+func (c *telemetryCollector) get(requestID string) *pendingTelemetry {
+	if p, ok := c.pending[requestID]; ok {
+		return p
+	}
+	if len(c.order) >= maxPendingTelemetry {
+		delete(c.pending, c.order[0])
+		c.order = c.order[1:]
+	}
+	p := &pendingTelemetry{metrics: PlatformMetrics{RequestID: requestID}}
+	c.pending[requestID] = p
+	c.order = append(c.order, requestID)
+	return p
+}
+
+// remove removes the pending telemetry for the request ID.
+func (c *telemetryCollector) remove(requestID string) {
+	delete(c.pending, requestID)
+	for i, id := range c.order {
+		if id == requestID {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// addUsage records the usage reported by the adapter for an invocation.
+func (c *telemetryCollector) addUsage(requestID string, usage *Usage) {
+	if requestID == "" {
+		return
+	}
+	u := *usage
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(requestID).usage = &u
+}
+
+// telemetryEvent is an event received from the Telemetry API.
+type telemetryEvent struct {
+	Time   string          `json:"time"`
+	Type   string          `json:"type"`
+	Record json.RawMessage `json:"record"`
+}
+
+// platformRecord is the record of the platform.runtimeDone and
+// platform.report events.
+type platformRecord struct {
+	RequestID string `json:"requestId"`
+	Status    string `json:"status"`
+	Metrics   struct {
+		DurationMS       float64 `json:"durationMs"`
+		ProducedBytes    int64   `json:"producedBytes"`
+		BilledDurationMS float64 `json:"billedDurationMs"`
+		MemorySizeMB     int     `json:"memorySizeMB"`
+		MaxMemoryUsedMB  int     `json:"maxMemoryUsedMB"`
+		InitDurationMS   float64 `json:"initDurationMs"`
+	} `json:"metrics"`
+}
+
+// milliseconds converts a duration in milliseconds to a time.Duration.
+func milliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// addEvents records the events received from the Telemetry API, and
+// reports each invocation whose report event has been received. The report
+// event is the last event for an invocation.
+func (c *telemetryCollector) addEvents(events []telemetryEvent) {
+	type report struct {
+		usage   *Usage
+		metrics PlatformMetrics
+	}
+	var reports []report
+	c.mu.Lock()
+	for _, ev := range events {
+		if ev.Type != "platform.runtimeDone" && ev.Type != "platform.report" {
+			continue
+		}
+		var record platformRecord
+		if err := json.Unmarshal(ev.Record, &record); err != nil || record.RequestID == "" {
+			continue
+		}
+		p := c.get(record.RequestID)
+		m := &p.metrics
+		if ev.Type == "platform.runtimeDone" {
+			m.RuntimeDuration = milliseconds(record.Metrics.DurationMS)
+			m.ProducedBytes = record.Metrics.ProducedBytes
+			if m.Status == "" {
+				m.Status = record.Status
+			}
+			continue
+		}
+		m.Status = record.Status
+		m.Duration = milliseconds(record.Metrics.DurationMS)
+		m.BilledDuration = milliseconds(record.Metrics.BilledDurationMS)
+		m.MemorySizeMB = record.Metrics.MemorySizeMB
+		m.MaxMemoryUsedMB = record.Metrics.MaxMemoryUsedMB
+		m.InitDuration = milliseconds(record.Metrics.InitDurationMS)
+		reports = append(reports, report{usage: p.usage, metrics: *m})
+		c.remove(record.RequestID)
+	}
+	c.mu.Unlock()
+
+	// the reporter is called without holding the lock
+	for i := range reports {
+		c.reporter.ReportPlatformUsage(reports[i].usage, &reports[i].metrics)
+	}
+}
+
+// ServeHTTP receives the events sent by the Telemetry API.
+func (c *telemetryCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var events []telemetryEvent
+	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		slog.Warn("cannot decode telemetry events", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.addEvents(events)
+}
+
+// startTelemetry registers the internal extension and subscribes to the
+// Telemetry API, if configured. It must be called during init, before the
+// first invocation is requested from the runtime API.
+func (o *options) startTelemetry() {
+	if !o.telemetryAPI {
+		return
+	}
+	reporter, ok := o.usageReporter.(PlatformUsageReporter)
+	address := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	if !ok || address == "" {
+		return
+	}
+	c := newTelemetryCollector(reporter)
+	if err := startTelemetryExtension(context.Background(), address, c); err != nil {
+		slog.Warn("cannot subscribe to the Lambda Telemetry API", "error", err)
+		return
+	}
+	o.telemetry = c
+}
+
+// startTelemetryExtension registers an internal extension with the Lambda
+// extensions API at address, starts receiving telemetry events for c and
+// subscribes to the platform events.
+func startTelemetryExtension(ctx context.Context, address string, c *telemetryCollector) error {
+	client := &http.Client{}
+	baseURL := "http://" + address
+
+	// register for invoke events, which is required for internal extensions
+	header := http.Header{extensionNameHeader: {filepath.Base(os.Args[0])}}
+	resp, err := extensionRequest(ctx, client, http.MethodPost, baseURL+"/2020-01-01/extension/register", header, map[string]interface{}{
+		"events": []string{"INVOKE"},
+	})
+	if err != nil {
+		return kv.Wrap(err, "cannot register extension")
+	}
+	id := resp.Header.Get(extensionIdentifierHeader)
+
+	listener, err := net.Listen("tcp", telemetryListenAddress)
+	if err != nil {
+		return kv.Wrap(err, "cannot listen for telemetry events")
+	}
+	go http.Serve(listener, c)
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	host, _, _ := net.SplitHostPort(telemetryListenAddress)
+	header = http.Header{extensionIdentifierHeader: {id}}
+	if _, err := extensionRequest(ctx, client, http.MethodPut, baseURL+"/2022-07-01/telemetry", header, map[string]interface{}{
+		"schemaVersion": telemetrySchemaVersion,
+		"types":         []string{"platform"},
+		"buffering": map[string]int{
+			"maxItems":  1000,
+			"maxBytes":  256 * 1024,
+			"timeoutMs": 25,
+		},
+		"destination": map[string]string{
+			"protocol": "HTTP",
+			"URI":      "http://" + net.JoinHostPort(host, strconv.Itoa(port)),
+		},
+	}); err != nil {
+		listener.Close()
+		return kv.Wrap(err, "cannot subscribe to telemetry")
+	}
+
+	// Lambda waits for each extension to request the next event before
+	// each phase, so the events are requested until the process exits
+	go func() {
+		for ctx.Err() == nil {
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/2020-01-01/extension/event/next", nil)
+			req.Header.Set(extensionIdentifierHeader, id)
+			resp, err := client.Do(req)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("cannot get next extension event", "error", err)
+				}
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}()
+	return nil
+}
+
+// extensionRequest sends a request with a JSON body to the extensions API.
+func extensionRequest(ctx context.Context, client *http.Client, method, url string, header http.Header, body interface{}) (*http.Response, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, kv.NewError("unexpected status").With("status", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// invocationRequestID returns the Lambda request ID of the invocation
+// associated with ctx.
+func invocationRequestID(ctx context.Context) string {
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		return lc.AwsRequestID
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// platformReporter records the platform usage reported.
+type platformReporter struct {
+	mu      sync.Mutex
+	usage   []*Usage
+	metrics []*PlatformMetrics
+	done    chan struct{}
+}
+
+func (r *platformReporter) ReportUsage(ctx context.Context, usage *Usage) {}
+
+func (r *platformReporter) ReportPlatformUsage(usage *Usage, metrics *PlatformMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usage = append(r.usage, usage)
+	r.metrics = append(r.metrics, metrics)
+	if r.done != nil {
+		close(r.done)
+		r.done = nil
+	}
+}
+
+func TestTelemetryCollector(t *testing.T) {
+	reporter := &platformReporter{}
+	c := newTelemetryCollector(reporter)
+	c.addUsage("req-1", &Usage{RequestBodySize: 10, HandlerTime: 5 * time.Millisecond})
+	c.addUsage("", &Usage{})
+
+	var events []telemetryEvent
+	if err := json.Unmarshal([]byte(`[
+		{"time":"2026-10-14T07:00:00.000Z","type":"platform.start","record":{"requestId":"req-1"}},
+		{"time":"2026-10-14T07:00:00.010Z","type":"platform.runtimeDone","record":{"requestId":"req-1","status":"success","metrics":{"durationMs":9.5,"producedBytes":42}}},
+		{"time":"2026-10-14T07:00:00.012Z","type":"platform.report","record":{"requestId":"req-1","status":"success","metrics":{"durationMs":11.25,"billedDurationMs":12,"memorySizeMB":128,"maxMemoryUsedMB":64}}},
+		{"time":"2026-10-14T07:00:01.000Z","type":"platform.report","record":{"requestId":"req-2","status":"timeout","metrics":{"durationMs":3000,"billedDurationMs":3000,"memorySizeMB":128,"maxMemoryUsedMB":70}}}
+	]`), &events); err != nil {
+		t.Fatal(err)
+	}
+	c.addEvents(events)
+
+	if got, want := len(reporter.metrics), 2; got != want {
+		t.Fatalf("got %d reports, want %d", got, want)
+	}
+	want := PlatformMetrics{
+		RequestID:       "req-1",
+		Status:          "success",
+		RuntimeDuration: 9500 * time.Microsecond,
+		ProducedBytes:   42,
+		Duration:        11250 * time.Microsecond,
+		BilledDuration:  12 * time.Millisecond,
+		MemorySizeMB:    128,
+		MaxMemoryUsedMB: 64,
+	}
+	if got := *reporter.metrics[0]; got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got := reporter.usage[0]; got == nil || got.RequestBodySize != 10 {
+		t.Errorf("got usage %+v, want request body size 10", got)
+	}
+	if got, want := reporter.metrics[1].Status, "timeout"; got != want {
+		t.Errorf("got status %q, want %q", got, want)
+	}
+	if got := reporter.usage[1]; got != nil {
+		t.Errorf("got usage %+v, want nil", got)
+	}
+	if got := len(c.pending); got != 0 {
+		t.Errorf("got %d pending, want none", got)
+	}
+
+	// the oldest usage is discarded if the platform metrics are not received
+	for i := 0; i < maxPendingTelemetry+10; i++ {
+		c.addUsage("req-"+strings.Repeat("x", i), &Usage{})
+	}
+	if got, want := len(c.pending), maxPendingTelemetry; got != want {
+		t.Errorf("got %d pending, want %d", got, want)
+	}
+}
+
+func TestTelemetryExtension(t *testing.T) {
+	defer func(address string) { telemetryListenAddress = address }(telemetryListenAddress)
+	telemetryListenAddress = "127.0.0.1:0"
+
+	var mu sync.Mutex
+	var subscription struct {
+		Types       []string
+		Destination struct{ URI string }
+	}
+	var registered, subscribedID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/2020-01-01/extension/register":
+			registered = r.Header.Get(extensionNameHeader)
+			w.Header().Set(extensionIdentifierHeader, "ext-1")
+			w.Write([]byte(`{}`))
+		case r.Method == "PUT" && r.URL.Path == "/2022-07-01/telemetry":
+			subscribedID = r.Header.Get(extensionIdentifierHeader)
+			json.NewDecoder(r.Body).Decode(&subscription)
+		case r.Method == "GET" && r.URL.Path == "/2020-01-01/extension/event/next":
+			mu.Unlock()
+			<-r.Context().Done()
+			mu.Lock()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	reporter := &platformReporter{done: done}
+	c := newTelemetryCollector(reporter)
+	if err := startTelemetryExtension(ctx, strings.TrimPrefix(server.URL, "http://"), c); err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	mu.Lock()
+	if registered == "" {
+		t.Error("got empty extension name")
+	}
+	if got, want := subscribedID, "ext-1"; got != want {
+		t.Errorf("got identifier %q, want %q", got, want)
+	}
+	if got, want := subscription.Types, []string{"platform"}; !equalStrings(got, want) {
+		t.Errorf("got types %q, want %q", got, want)
+	}
+	uri := subscription.Destination.URI
+	mu.Unlock()
+
+	c.addUsage("req-1", &Usage{PayloadSize: 100})
+	resp, err := http.Post(uri, "application/json", bytes.NewReader([]byte(`[
+		{"time":"2026-10-14T07:00:00.012Z","type":"platform.report","record":{"requestId":"req-1","status":"success","metrics":{"durationMs":11,"billedDurationMs":12,"memorySizeMB":128,"maxMemoryUsedMB":64}}}
+	]`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("got no report")
+	}
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	if got, want := reporter.usage[0].PayloadSize, 100; got != want {
+		t.Errorf("got payload size %d, want %d", got, want)
+	}
+	if got, want := reporter.metrics[0].BilledDuration, 12*time.Millisecond; got != want {
+		t.Errorf("got billed duration %v, want %v", got, want)
+	}
+}
+
+func TestTelemetryUsage(t *testing.T) {
+	reporter := &platformReporter{}
+	o := newOptions([]Option{WithUsageReporter(reporter), WithTelemetryAPI()})
+	o.telemetry = newTelemetryCollector(reporter)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "req-1"})
+	if _, err := apiGatewayHandler(o.wrap(h), o)(ctx, events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/"}); err != nil {
+		t.Fatal(err)
+	}
+	p, ok := o.telemetry.pending["req-1"]
+	if !ok || p.usage == nil {
+		t.Fatal("got no pending usage")
+	}
+	if got, want := p.usage.ResponseBodySize, 5; got != want {
+		t.Errorf("got response body size %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/jjeffery/kv"
+)
+
+// TraceContext is the W3C Trace Context received in the traceparent and
+// tracestate request headers. See https://www.w3.org/TR/trace-context/.
+type TraceContext struct {
+	Version  byte   // version of the traceparent header
+	TraceID  string // 32 lower case hex digits identifying the trace
+	ParentID string // 16 lower case hex digits identifying the caller's span
+	Flags    byte   // trace flags
+	State    string // vendor specific trace state, if any
+}
+
+// Trace returns the trace context of the request associated with ctx, or
+// nil if the request did not have a valid traceparent header. The
+// traceparent and tracestate headers are also left unchanged in the
+// request header.
+func Trace(ctx context.Context) *TraceContext {
+	tc, _ := ctx.Value(ctxKeyTrace).(*TraceContext)
+	return tc
+}
+
+// ParseTraceContext parses the values of the traceparent and tracestate
+// headers. The tracestate value is kept as is.
+func ParseTraceContext(traceparent, tracestate string) (*TraceContext, error) {
+	// version "-" trace-id "-" parent-id "-" trace-flags
+	const size = 2 + 1 + 32 + 1 + 16 + 1 + 2
+	s := strings.TrimSpace(traceparent)
+	if len(s) < size || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return nil, kv.NewError("invalid traceparent").With("traceparent", traceparent)
+	}
+	version, ok := parseHexByte(s[0:2])
+	if !ok || version == 0xff || (version == 0 && len(s) != size) || (len(s) > size && s[size] != '-') {
+		return nil, kv.NewError("invalid traceparent version").With("traceparent", traceparent)
+	}
+	tc := &TraceContext{
+		Version:  version,
+		TraceID:  s[3:35],
+		ParentID: s[36:52],
+		State:    strings.TrimSpace(tracestate),
+	}
+	if !isTraceID(tc.TraceID) || !isTraceID(tc.ParentID) {
+		return nil, kv.NewError("invalid traceparent id").With("traceparent", traceparent)
+	}
+	if tc.Flags, ok = parseHexByte(s[53:55]); !ok {
+		return nil, kv.NewError("invalid traceparent flags").With("traceparent", traceparent)
+	}
+	return tc, nil
+}
+
+// Sampled reports whether the caller may have recorded the trace.
+func (tc *TraceContext) Sampled() bool {
+	return tc.Flags&0x01 != 0
+}
+
+// String returns the value of the traceparent header for the trace context.
+// The version is always 00, which is the version this package supports.
+func (tc *TraceContext) String() string {
+	const hex = "0123456789abcdef"
+	return "00-" + tc.TraceID + "-" + tc.ParentID + "-" + string([]byte{hex[tc.Flags>>4], hex[tc.Flags&0x0f]})
+}
+
+// Inject sets the traceparent and tracestate headers in header, so that
+// an outgoing request continues the trace. Clients that create their own
+// span should set ParentID to the ID of that span first.
+func (tc *TraceContext) Inject(header http.Header) {
+	header.Set("Traceparent", tc.String())
+	if tc.State != "" {
+		header.Set("Tracestate", tc.State)
+	} else {
+		header.Del("Tracestate")
+	}
+}
+
+// traceContextHandler adds the trace context of the request, if any,
+// to the request context.
+func traceContextHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if traceparent := r.Header.Get("Traceparent"); traceparent != "" {
+			// multiple tracestate headers are combined, as for a list
+			tracestate := strings.Join(r.Header.Values("Tracestate"), ",")
+			if tc, err := ParseTraceContext(traceparent, tracestate); err == nil {
+				r = r.WithContext(context.WithValue(r.Context(), ctxKeyTrace, tc))
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// isTraceID reports whether s is a valid trace ID or parent ID: lower case
+// hex digits that are not all zero.
+func isTraceID(s string) bool {
+	var nonZero bool
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '0':
+		case '1' <= c && c <= '9', 'a' <= c && c <= 'f':
+			nonZero = true
+		default:
+			return false
+		}
+	}
+	return nonZero
+}
+
+// parseHexByte parses two lower case hex digits.
+func parseHexByte(s string) (byte, bool) {
+	var b byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case '0' <= c && c <= '9':
+			b = b<<4 | (c - '0')
+		case 'a' <= c && c <= 'f':
+			b = b<<4 | (c - 'a' + 10)
+		default:
+			return 0, false
+		}
+	}
+	return b, true
+}
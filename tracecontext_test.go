@@ -0,0 +1,100 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestParseTraceContext(t *testing.T) {
+	tests := []struct {
+		traceparent string
+		wantErr     bool
+		wantSampled bool
+	}{
+		{traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantSampled: true},
+		{traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{traceparent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", wantSampled: true},
+		{traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantErr: true},
+		{traceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{traceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		{traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", wantErr: true},
+		{traceparent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", wantErr: true},
+		{traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", wantErr: true},
+		{traceparent: "", wantErr: true},
+	}
+	for i, tt := range tests {
+		tc, err := ParseTraceContext(tt.traceparent, "")
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%d: got %+v, want error", i, tc)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: got %v, want no error", i, err)
+			continue
+		}
+		if got, want := tc.Sampled(), tt.wantSampled; got != want {
+			t.Errorf("%d: got sampled=%v, want %v", i, got, want)
+		}
+		if got, want := tc.String(), "00"+tt.traceparent[2:55]; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	var tc *TraceContext
+	var header http.Header
+	opts := newOptions(nil)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tc = Trace(r.Context())
+		header = r.Header
+	})
+	handler := apiGatewayHandler(opts.wrap(h), opts)
+
+	if _, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		Path: "/",
+		Headers: map[string]string{
+			"traceparent": traceparent,
+			"tracestate":  "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if tc == nil {
+		t.Fatal("got nil trace context")
+	}
+	if got, want := tc.TraceID, "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+		t.Errorf("got trace ID %q, want %q", got, want)
+	}
+	if got, want := tc.State, "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7"; got != want {
+		t.Errorf("got state %q, want %q", got, want)
+	}
+	if got, want := header.Get("Traceparent"), traceparent; got != want {
+		t.Errorf("got header %q, want %q", got, want)
+	}
+
+	outgoing := make(http.Header)
+	tc.Inject(outgoing)
+	if got, want := outgoing.Get("Traceparent"), traceparent; got != want {
+		t.Errorf("got outgoing traceparent %q, want %q", got, want)
+	}
+	if got, want := outgoing.Get("Tracestate"), tc.State; got != want {
+		t.Errorf("got outgoing tracestate %q, want %q", got, want)
+	}
+
+	if _, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		Path:    "/",
+		Headers: map[string]string{"traceparent": "invalid"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if tc != nil {
+		t.Errorf("got %+v, want nil for invalid traceparent", tc)
+	}
+}
@@ -0,0 +1,185 @@
+package apigatewayproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// LambdaInvoker invokes a Lambda function synchronously. The lambda client
+// in the AWS SDK is easily adapted to this interface; it is defined here so
+// that this package does not depend on the AWS SDK. Implementations should
+// return an error if the function returns a function error.
+type LambdaInvoker interface {
+	Invoke(ctx context.Context, functionName string, payload []byte) ([]byte, error)
+}
+
+// Transport is an http.RoundTripper that sends each request directly to a
+// Lambda function as an API Gateway proxy event, and converts the function's
+// response back into an HTTP response. This allows services to call each
+// other without going through API Gateway:
+//
+//	client := &http.Client{
+//		Transport: &apigatewayproxy.Transport{Invoker: invoker},
+//	}
+//	resp, err := client.Get("http://orders-service/orders/123")
+type Transport struct {
+	// Invoker invokes the Lambda function.
+	Invoker LambdaInvoker
+
+	// FunctionName is the name or ARN of the function to invoke. If empty,
+	// the host of the request URL is used as the function name.
+	FunctionName string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	functionName := t.FunctionName
+	if functionName == "" {
+		functionName = req.URL.Hostname()
+	}
+	ev, err := newProxyEvent(req)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot marshal request event")
+	}
+	b, err := t.Invoker.Invoke(req.Context(), functionName, payload)
+	if err != nil {
+		return nil, kv.Wrap(err, "cannot invoke function").With("function", functionName)
+	}
+	var response events.APIGatewayProxyResponse
+	if err := json.Unmarshal(b, &response); err != nil {
+		return nil, kv.Wrap(err, "cannot unmarshal response").With("function", functionName)
+	}
+	return newHTTPResponse(req, &response)
+}
+
+// newProxyEvent creates the API Gateway proxy event for the request.
+func newProxyEvent(req *http.Request) (*events.APIGatewayProxyRequest, error) {
+	ev := &events.APIGatewayProxyRequest{
+		HTTPMethod: req.Method,
+		Path:       req.URL.Path,
+		Headers:    make(map[string]string, len(req.Header)+1),
+		RequestContext: events.APIGatewayProxyRequestContext{
+			HTTPMethod: req.Method,
+		},
+	}
+	if ev.HTTPMethod == "" {
+		ev.HTTPMethod = http.MethodGet
+	}
+	if ev.Path == "" {
+		ev.Path = "/"
+	}
+	if query := req.URL.Query(); len(query) > 0 {
+		ev.QueryStringParameters = make(map[string]string, len(query))
+		ev.MultiValueQueryStringParameters = query
+		for k, vv := range query {
+			ev.QueryStringParameters[k] = vv[len(vv)-1]
+		}
+	}
+	for k, vv := range req.Header {
+		ev.Headers[k] = vv[len(vv)-1]
+		if len(vv) > 1 {
+			if ev.MultiValueHeaders == nil {
+				ev.MultiValueHeaders = make(map[string][]string)
+			}
+			ev.MultiValueHeaders[k] = vv
+		}
+	}
+	if req.Host != "" {
+		ev.Headers["Host"] = req.Host
+	} else if req.URL.Host != "" {
+		ev.Headers["Host"] = req.URL.Host
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, kv.Wrap(err, "cannot read request body")
+		}
+		if utf8.Valid(body) {
+			ev.Body = string(body)
+		} else {
+			ev.Body = base64.StdEncoding.EncodeToString(body)
+			ev.IsBase64Encoded = true
+		}
+	}
+	return ev, nil
+}
+
+// newHTTPResponse creates the HTTP response from the response event.
+func newHTTPResponse(req *http.Request, response *events.APIGatewayProxyResponse) (*http.Response, error) {
+	var body []byte
+	if response.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(response.Body); err != nil {
+			return nil, kv.Wrap(newConversionError(ErrInvalidBase64Body, err), "cannot decode response body")
+		}
+	} else {
+		body = []byte(response.Body)
+	}
+	header := make(http.Header, len(response.Headers)+len(response.MultiValueHeaders))
+	for k, v := range response.Headers {
+		header[http.CanonicalHeaderKey(k)] = []string{v}
+	}
+	// API Gateway prefers the multi-value headers
+	for k, vv := range response.MultiValueHeaders {
+		header[http.CanonicalHeaderKey(k)] = vv
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(response.StatusCode) + " " + http.StatusText(response.StatusCode),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// NewInProcessTransport returns an http.RoundTripper that converts each
+// request into an API Gateway proxy event, passes the event to h in the same
+// way as the Lambda runtime, and converts the response event back into an
+// HTTP response. Everything happens in memory, so a standard http.Client can
+// exercise the full Lambda code path in unit tests, without any network or
+// AWS dependencies. The options are the same as those passed to Start.
+func NewInProcessTransport(h http.Handler, opts ...Option) http.RoundTripper {
+	o := newOptions(opts)
+	return &Transport{
+		Invoker:      inProcessInvoker{handler: withSpan(o, apiGatewayHandler(o.wrap(h), o)), codec: o.jsonCodec},
+		FunctionName: "in-process",
+	}
+}
+
+// inProcessInvoker invokes the handler for an API Gateway proxy event
+type inProcessInvoker struct {
+	handler func(context.Context, events.APIGatewayProxyRequest) (apiGatewayProxyResponse, error)
+	codec   JSONCodec
+}
+
+func (i inProcessInvoker) Invoke(ctx context.Context, functionName string, payload []byte) ([]byte, error) {
+	var ev events.APIGatewayProxyRequest
+	if err := i.codec.Unmarshal(payload, &ev); err != nil {
+		return nil, kv.Wrap(err, "cannot unmarshal request event")
+	}
+	response, err := i.handler(ctx, ev)
+	if err != nil {
+		return nil, err
+	}
+	return i.codec.Marshal(response)
+}
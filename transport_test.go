@@ -0,0 +1,106 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// testInvoker records the function name, and invokes the handler
+// as if it were a Lambda function
+type testInvoker struct {
+	handler      http.Handler
+	functionName string
+	err          error
+}
+
+func (i *testInvoker) Invoke(ctx context.Context, functionName string, payload []byte) ([]byte, error) {
+	i.functionName = functionName
+	if i.err != nil {
+		return nil, i.err
+	}
+	var ev events.APIGatewayProxyRequest
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return nil, err
+	}
+	response, err := apiGatewayHandler(i.handler, newOptions(nil))(ctx, ev)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(response)
+}
+
+func TestTransport(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Add("Set-Cookie", "a=1")
+		w.Header().Add("Set-Cookie", "b=2")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(r.Method + " " + r.Host + " " + r.URL.RequestURI() + " " +
+			r.Header.Get("X-Custom") + " " + string(body)))
+	})
+	invoker := &testInvoker{handler: h}
+	client := &http.Client{Transport: &Transport{Invoker: invoker}}
+
+	req, _ := http.NewRequest("PUT", "http://orders-service/orders/123?q=1&q=2", strings.NewReader("body"))
+	req.Header.Set("X-Custom", "x")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if got, want := invoker.functionName, "orders-service"; got != want {
+		t.Errorf("got function %q, want %q", got, want)
+	}
+	if got, want := resp.StatusCode, http.StatusCreated; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+	if got, want := string(body), "PUT orders-service /orders/123?q=1&q=2 x body"; got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+	if got, want := strings.Join(resp.Header.Values("Set-Cookie"), ";"), "a=1;b=2"; got != want {
+		t.Errorf("got cookies %q, want %q", got, want)
+	}
+
+	client.Transport = &Transport{Invoker: &testInvoker{err: errors.New("throttled")}, FunctionName: "fn"}
+	if _, err := client.Get("http://ignored/"); err == nil {
+		t.Error("got no error, want error")
+	}
+}
+
+func TestInProcessTransport(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Request(r.Context()) == nil {
+			t.Error("got nil request event")
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte{0xff, 0x00, 0x01})
+	})
+	client := &http.Client{Transport: NewInProcessTransport(h, WithHealthCheck(""))}
+	resp, err := client.Get("http://localhost/binary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if got, want := string(body), "\xff\x00\x01"; got != want {
+		t.Errorf("got body %q, want %q", got, want)
+	}
+
+	// options apply
+	resp, err = client.Get("http://localhost/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got, want := resp.Header.Get("Content-Type"), "application/json"; got != want {
+		t.Errorf("got content type %q, want %q", got, want)
+	}
+}
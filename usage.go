@@ -0,0 +1,66 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"time"
+)
+
+// Usage describes the resources used by one invocation.
+type Usage struct {
+	RequestBodySize  int           // size of the decoded request body
+	ResponseBodySize int           // bytes buffered for the response body, before compression
+	PayloadSize      int           // estimated size of the encoded response payload
+	HandlerTime      time.Duration // wall time spent in the HTTP handler
+	BufferCapacity   int           // peak capacity of the pooled buffers held at once
+}
+
+// A UsageReporter receives the resource usage of each invocation. Use it to
+// choose the memory size of the function based on real traffic.
+type UsageReporter interface {
+	// ReportUsage is called after the response has been converted, with
+	// the context of the invocation.
+	ReportUsage(ctx context.Context, usage *Usage)
+}
+
+// WithUsageReporter configures the adapter to report the resource usage of
+// each invocation to reporter.
+func WithUsageReporter(reporter UsageReporter) Option {
+	return func(o *options) {
+		o.usageReporter = reporter
+	}
+}
+
+// reportUsage reports the usage of the invocation, if a usage reporter is
+// configured. It must be called before the buffers are released.
+func (inv *invocation) reportUsage() {
+	reporter := inv.opts.usageReporter
+	if reporter == nil {
+		return
+	}
+	usage := Usage{
+		ResponseBodySize: inv.writer.bodySize,
+		PayloadSize:      payloadSize(&inv.writer.response2),
+		HandlerTime:      inv.handlerTime,
+	}
+	if inv.body != nil {
+		usage.RequestBodySize = inv.body.Len()
+		usage.BufferCapacity = inv.body.Cap()
+	} else {
+		usage.RequestBodySize = len(inv.request.Body)
+	}
+	// the request body buffer is held until the invocation is released
+	responseCap := inv.writer.bufferCap
+	if body := inv.writer.body; body != nil {
+		responseCap = max(responseCap, body.Cap())
+	}
+	usage.BufferCapacity += responseCap
+	var ctx context.Context = &inv.ctx
+	if inv.ctx.Context == nil {
+		// the request could not be converted
+		ctx = context.Background()
+	}
+	reporter.ReportUsage(ctx, &usage)
+	if c := inv.opts.telemetry; c != nil {
+		c.addUsage(invocationRequestID(ctx), &usage)
+	}
+}
@@ -0,0 +1,72 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type testUsageReporter struct {
+	usage     []Usage
+	requestID []string
+}
+
+func (r *testUsageReporter) ReportUsage(ctx context.Context, usage *Usage) {
+	r.usage = append(r.usage, *usage)
+	if request := Request(ctx); request != nil {
+		r.requestID = append(r.requestID, request.RequestContext.RequestID)
+	}
+}
+
+func TestUsageReporter(t *testing.T) {
+	reporter := &testUsageReporter{}
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(strings.Repeat("x", 2000)))
+	})
+	handler := apiGatewayHandler(h, newOptions([]Option{
+		WithUsageReporter(reporter),
+		WithCompression(0),
+	}))
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:      "POST",
+		Path:            "/",
+		Headers:         map[string]string{"Accept-Encoding": "gzip"},
+		Body:            base64.StdEncoding.EncodeToString([]byte("hello")),
+		IsBase64Encoded: true,
+		RequestContext:  events.APIGatewayProxyRequestContext{RequestID: "req-1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reporter.usage) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reporter.usage))
+	}
+	usage := reporter.usage[0]
+	if got, want := usage.RequestBodySize, 5; got != want {
+		t.Errorf("got request body size %d, want %d", got, want)
+	}
+	if got, want := usage.ResponseBodySize, 2000; got != want {
+		t.Errorf("got response body size %d, want %d", got, want)
+	}
+	if got, want := usage.PayloadSize, len(response.Body); got <= want {
+		t.Errorf("got payload size %d, want more than %d", got, want)
+	}
+	// the response body and its compressed copy are held at the same time
+	if got, want := usage.BufferCapacity, 2000+usage.RequestBodySize; got < want {
+		t.Errorf("got buffer capacity %d, want at least %d", got, want)
+	}
+	if usage.HandlerTime < 10*time.Millisecond {
+		t.Errorf("got handler time %v, want at least 10ms", usage.HandlerTime)
+	}
+	if got, want := strings.Join(reporter.requestID, ","), "req-1"; got != want {
+		t.Errorf("got request ID %q, want %q", got, want)
+	}
+}
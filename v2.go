@@ -0,0 +1,132 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// StartV2 starts handling API Gateway HTTP API requests that use payload
+// format version 2.0 by passing each request to the HTTP handler. Use Start
+// for REST APIs, and for HTTP APIs that use payload format version 1.0.
+func StartV2(h http.Handler, opts ...Option) {
+	o := newOptions(opts)
+	startLambda(o, withSpan(o, v2Handler(o.wrap(h), o)))
+}
+
+// RequestV2 returns a pointer to the API Gateway HTTP API request, or nil
+// if the current context is not associated with a payload format
+// version 2.0 request. Request returns nil for these requests.
+func RequestV2(ctx context.Context) *events.APIGatewayV2HTTPRequest {
+	request, _ := ctx.Value(ctxKeyV2).(*events.APIGatewayV2HTTPRequest)
+	return request
+}
+
+func v2Handler(h http.Handler, o *options) func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		inv := newInvocation(o)
+		defer inv.release()
+		r, err := newV2Request(ctx, inv, &request)
+		if err != nil {
+			response, err := inv.fail(err)
+			return v2Response(&response), err
+		}
+		w := inv.serve(h, r)
+		if err := inv.complete(r); err != nil {
+			response, err := inv.fail(err)
+			return v2Response(&response), err
+		}
+		return v2Response(&w.response2), w.err
+	}
+}
+
+// newV2Request converts the version 2.0 request into the equivalent
+// version 1.0 request, so that the HTTP request is created in the
+// same way for both payload versions.
+func newV2Request(ctx context.Context, inv *invocation, request *events.APIGatewayV2HTTPRequest) (*http.Request, error) {
+	rc := &request.RequestContext
+	path := request.RawPath
+	if request.RawQueryString != "" {
+		path += "?" + request.RawQueryString
+	}
+	headers := request.Headers
+	if len(request.Cookies) > 0 {
+		// version 2.0 moves the cookie header into its own field
+		headers = make(map[string]string, len(request.Headers)+1)
+		for k, v := range request.Headers {
+			headers[k] = v
+		}
+		headers["Cookie"] = strings.Join(request.Cookies, "; ")
+	}
+	resourcePath := rc.RouteKey
+	if i := strings.IndexByte(resourcePath, ' '); i >= 0 {
+		// route key is "METHOD /path"
+		resourcePath = resourcePath[i+1:]
+	}
+	inv.request = events.APIGatewayProxyRequest{
+		HTTPMethod:      rc.HTTP.Method,
+		Path:            path,
+		Headers:         headers,
+		PathParameters:  request.PathParameters,
+		StageVariables:  request.StageVariables,
+		Body:            request.Body,
+		IsBase64Encoded: request.IsBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:        rc.AccountID,
+			Stage:            rc.Stage,
+			DomainName:       rc.DomainName,
+			DomainPrefix:     rc.DomainPrefix,
+			RequestID:        rc.RequestID,
+			Protocol:         rc.HTTP.Protocol,
+			Identity:         events.APIGatewayRequestIdentity{SourceIP: rc.HTTP.SourceIP, UserAgent: rc.HTTP.UserAgent},
+			ResourcePath:     resourcePath,
+			HTTPMethod:       rc.HTTP.Method,
+			RequestTime:      rc.Time,
+			RequestTimeEpoch: rc.TimeEpoch,
+			APIID:            rc.APIID,
+		},
+	}
+	return inv.newRequest(ctx, ctxKeyV2, request)
+}
+
+// v2Response converts the response to payload format version 2.0, which
+// does not support multi-value headers. Set-Cookie headers are returned
+// in the cookies field, and other headers with multiple values are
+// combined into a comma-separated list.
+func v2Response(response *apiGatewayProxyResponse) events.APIGatewayV2HTTPResponse {
+	v2 := events.APIGatewayV2HTTPResponse{
+		StatusCode:      response.StatusCode,
+		Headers:         response.Headers,
+		Body:            response.Body,
+		IsBase64Encoded: response.IsBase64Encoded,
+	}
+	if response.MultiValueHeaders == nil && !hasSetCookie(response.Headers) {
+		return v2
+	}
+	v2.Headers = make(map[string]string, len(response.Headers)+len(response.MultiValueHeaders))
+	add := func(k string, vv []string) {
+		if strings.EqualFold(k, "Set-Cookie") {
+			v2.Cookies = append(v2.Cookies, vv...)
+			return
+		}
+		v2.Headers[k] = strings.Join(vv, ",")
+	}
+	for k, v := range response.Headers {
+		add(k, []string{v})
+	}
+	for k, vv := range response.MultiValueHeaders {
+		add(k, vv)
+	}
+	return v2
+}
+
+func hasSetCookie(headers map[string]string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, "Set-Cookie") {
+			return true
+		}
+	}
+	return false
+}
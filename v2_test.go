@@ -0,0 +1,81 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestV2Handler(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Request(r.Context()) != nil {
+			t.Error("got version 1.0 request, want nil")
+		}
+		request := RequestV2(r.Context())
+		if request == nil {
+			t.Fatal("got nil version 2.0 request")
+		}
+		if got, want := ClientIP(r), "203.0.113.1"; got != want {
+			t.Errorf("got client IP %q, want %q", got, want)
+		}
+		cookie, err := r.Cookie("b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Add("Set-Cookie", "x=1")
+		w.Header().Add("Set-Cookie", "y=2")
+		w.Header().Add("Vary", "Accept")
+		w.Header().Add("Vary", "Origin")
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + " " + cookie.Value))
+	})
+	handler := v2Handler(h, newOptions(nil))
+	response, err := handler(context.Background(), events.APIGatewayV2HTTPRequest{
+		RawPath:        "/users/123",
+		RawQueryString: "a=1&b=2",
+		Cookies:        []string{"a=1", "b=2"},
+		Headers:        map[string]string{"accept": "*/*"},
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey: "POST /users/{id}",
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:   "POST",
+				SourceIP: "203.0.113.1",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(response.Cookies)
+	want := events.APIGatewayV2HTTPResponse{
+		StatusCode: http.StatusCreated,
+		Headers: map[string]string{
+			"Content-Type": "text/plain",
+			"Vary":         "Accept,Origin",
+		},
+		Body:    "POST /users/123?a=1&b=2 2",
+		Cookies: []string{"x=1", "y=2"},
+	}
+	if !reflect.DeepEqual(response, want) {
+		t.Errorf("got=%+v\nwant=%+v", response, want)
+	}
+}
+
+func TestRequestV2(t *testing.T) {
+	var got *events.APIGatewayV2HTTPRequest
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = RequestV2(r.Context())
+	})
+	handler := apiGatewayHandler(h, newOptions(nil))
+	if _, err := handler(context.Background(), events.APIGatewayProxyRequest{Path: "/"}); err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("got=%+v, want nil", got)
+	}
+}
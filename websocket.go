@@ -0,0 +1,319 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/jjeffery/kv"
+)
+
+// StartWebSocket starts handling API Gateway WebSocket events by passing
+// each event to the HTTP handler. The HTTP method and path of each request
+// is determined by the route key of the event: see WithWebSocketRoute.
+//
+// The response status code is returned to API Gateway. For routes
+// configured for two-way communication, the response body is sent
+// to the client.
+//
+// For the $connect route, a 2xx status code accepts the connection and any
+// other status code rejects it. A 101 (Switching Protocols) status code,
+// as returned by handlers written for WebSocket servers, is returned as
+// 200 (OK). The response headers are returned to the client with the
+// handshake response, so the handler can select a subprotocol using
+// AcceptSubprotocol.
+func StartWebSocket(h http.Handler, opts ...Option) {
+	o := newOptions(opts)
+	startLambda(o, withSpan(o, webSocketHandler(o.wrap(h), o)))
+}
+
+// WebSocketRequest returns a pointer to the API Gateway WebSocket event, or
+// nil if the current context is not associated with a WebSocket event.
+func WebSocketRequest(ctx context.Context) *events.APIGatewayWebsocketProxyRequest {
+	request, _ := ctx.Value(ctxKeyWebSocket).(*events.APIGatewayWebsocketProxyRequest)
+	return request
+}
+
+// ConnectionID returns the ID of the WebSocket connection that sent the
+// event associated with ctx, or an empty string if ctx is not associated
+// with a WebSocket event. Use it with NewConnectionWriter to send messages
+// to the client after the handler has returned.
+func ConnectionID(ctx context.Context) string {
+	if request := WebSocketRequest(ctx); request != nil {
+		return request.RequestContext.ConnectionID
+	}
+	return ""
+}
+
+// RouteKey returns the route key of the WebSocket event associated with
+// ctx, such as "$connect" or "sendmessage", or an empty string if ctx is
+// not associated with a WebSocket event.
+func RouteKey(ctx context.Context) string {
+	if request := WebSocketRequest(ctx); request != nil {
+		return request.RequestContext.RouteKey
+	}
+	return ""
+}
+
+// ManagementEndpoint returns the endpoint of the API Gateway Management API
+// for the WebSocket API that sent the event associated with ctx, such as
+// "https://abc123.execute-api.us-east-1.amazonaws.com/prod". Configure the
+// client that implements ManagementAPI with this endpoint. It returns an
+// empty string if ctx is not associated with a WebSocket event.
+//
+// When the API is accessed using a custom domain name, the endpoint uses the
+// execute-api domain name of the API, which is derived from the API ID and
+// the AWS_REGION environment variable, because the Management API is not
+// available at the custom domain name.
+func ManagementEndpoint(ctx context.Context) string {
+	request := WebSocketRequest(ctx)
+	if request == nil {
+		return ""
+	}
+	rc := &request.RequestContext
+	domain := rc.DomainName
+	if !strings.HasSuffix(domain, ".amazonaws.com") {
+		if region := os.Getenv("AWS_REGION"); rc.APIID != "" && region != "" {
+			domain = rc.APIID + ".execute-api." + region + ".amazonaws.com"
+		}
+	}
+	if domain == "" {
+		return ""
+	}
+	endpoint := "https://" + domain
+	if rc.Stage != "" {
+		endpoint += "/" + rc.Stage
+	}
+	return endpoint
+}
+
+// webSocketRoute is the HTTP method and path for a WebSocket route key
+type webSocketRoute struct {
+	method string
+	path   string
+}
+
+// webSocketRoute returns the HTTP method and path for the route key.
+func (o *options) webSocketRoute(routeKey string) webSocketRoute {
+	if route, ok := o.webSocketRoutes[routeKey]; ok {
+		return route
+	}
+	return webSocketRoute{
+		method: http.MethodPost,
+		path:   "/ws/" + url.PathEscape(strings.TrimPrefix(routeKey, "$")),
+	}
+}
+
+func webSocketHandler(h http.Handler, o *options) func(ctx context.Context, request events.APIGatewayWebsocketProxyRequest) (apiGatewayProxyResponse, error) {
+	return func(ctx context.Context, request events.APIGatewayWebsocketProxyRequest) (apiGatewayProxyResponse, error) {
+		inv := newInvocation(o)
+		defer inv.release()
+		r, err := newWebSocketRequest(ctx, inv, &request, o)
+		if err != nil {
+			return inv.fail(err)
+		}
+		w := inv.serve(h, r)
+		if request.RequestContext.EventType == "CONNECT" {
+			w.mapConnectResponse(r)
+		}
+		if err := inv.complete(r); err != nil {
+			return inv.fail(err)
+		}
+		if o.connectionStore != nil {
+			if err := storeConnection(ctx, o.connectionStore, &request, w.response2.StatusCode); err != nil {
+				return inv.fail(err)
+			}
+		}
+		return w.response2, w.err
+	}
+}
+
+// storeConnection records the connection in store when a client connects,
+// and removes it when the client disconnects. Connections rejected
+// by the handler are not stored.
+func storeConnection(ctx context.Context, store ConnectionStore, request *events.APIGatewayWebsocketProxyRequest, status int) error {
+	rc := &request.RequestContext
+	switch rc.EventType {
+	case "CONNECT":
+		if !connectAccepted(status) {
+			return nil
+		}
+		var connectedAt time.Time
+		if rc.ConnectedAt != 0 {
+			connectedAt = time.Unix(0, rc.ConnectedAt*int64(time.Millisecond))
+		}
+		return store.Add(ctx, Connection{
+			ID:          rc.ConnectionID,
+			ConnectedAt: connectedAt,
+			DomainName:  rc.DomainName,
+			Stage:       rc.Stage,
+		})
+	case "DISCONNECT":
+		return store.Remove(ctx, rc.ConnectionID)
+	}
+	return nil
+}
+
+// SubprotocolHeader is the header containing the WebSocket subprotocols
+// requested by the client, and the subprotocol selected by the server.
+const SubprotocolHeader = "Sec-WebSocket-Protocol"
+
+// AcceptSubprotocol selects the first subprotocol requested by the client in
+// the SubprotocolHeader of r that is one of supported, and sets the response
+// header to the selected subprotocol. It returns the selected subprotocol, or
+// an empty string if the client did not request a supported subprotocol. Use
+// it in the handler for the $connect route: clients such as browsers fail
+// the connection if they request subprotocols and none is selected.
+func AcceptSubprotocol(w http.ResponseWriter, r *http.Request, supported ...string) string {
+	for _, requested := range requestedSubprotocols(r) {
+		for _, s := range supported {
+			if requested == s {
+				w.Header().Set(SubprotocolHeader, s)
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// requestedSubprotocols returns the subprotocols requested by the client.
+func requestedSubprotocols(r *http.Request) []string {
+	var protocols []string
+	for _, v := range r.Header.Values(SubprotocolHeader) {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				protocols = append(protocols, p)
+			}
+		}
+	}
+	return protocols
+}
+
+// connectAccepted reports whether the status code of the response to the
+// $connect route accepts the connection.
+func connectAccepted(status int) bool {
+	return status >= 200 && status <= 299
+}
+
+// mapConnectResponse maps the response to the $connect route to the
+// response that API Gateway expects.
+func (w *responseWriter) mapConnectResponse(r *http.Request) {
+	if w.response2.StatusCode == http.StatusSwitchingProtocols {
+		w.response.StatusCode = http.StatusOK
+		w.response2.StatusCode = http.StatusOK
+	}
+	if !connectAccepted(w.response2.StatusCode) {
+		return
+	}
+
+	// the handshake response can select only one subprotocol, which
+	// must be one that the client requested
+	protocols := w.header.Values(SubprotocolHeader)
+	switch len(protocols) {
+	case 0:
+		return
+	case 1:
+		for _, requested := range requestedSubprotocols(r) {
+			if requested == protocols[0] {
+				return
+			}
+		}
+	}
+	Logger(r.Context()).Warn("invalid subprotocol selected", "subprotocol", protocols)
+}
+
+func newWebSocketRequest(ctx context.Context, inv *invocation, request *events.APIGatewayWebsocketProxyRequest, o *options) (*http.Request, error) {
+	route := o.webSocketRoute(request.RequestContext.RouteKey)
+	inv.request = events.APIGatewayProxyRequest{
+		HTTPMethod:            route.method,
+		Path:                  route.path,
+		Headers:               request.Headers,
+		QueryStringParameters: request.QueryStringParameters,
+		Body:                  request.Body,
+		IsBase64Encoded:       request.IsBase64Encoded,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID: request.RequestContext.RequestID,
+			Stage:     request.RequestContext.Stage,
+			// the identity contains the source IP address used by
+			// ClientIP and RateLimitKey
+			Identity: request.RequestContext.Identity,
+		},
+	}
+	return inv.newRequest(ctx, ctxKeyWebSocket, request)
+}
+
+// ManagementAPI is the subset of the API Gateway Management API used to
+// send messages to, and close, WebSocket connections. The
+// apigatewaymanagementapi client in the AWS SDK is easily adapted to this
+// interface; it is defined here so that this package does not depend on
+// the AWS SDK.
+type ManagementAPI interface {
+	// PostToConnection sends data to the connection.
+	PostToConnection(ctx context.Context, connectionID string, data []byte) error
+
+	// DeleteConnection disconnects the connection.
+	DeleteConnection(ctx context.Context, connectionID string) error
+}
+
+// ConnectionWriter is an io.WriteCloser that sends messages to a
+// connected WebSocket client. Each call to Write sends one message,
+// and Close disconnects the client.
+type ConnectionWriter struct {
+	ctx          context.Context
+	api          ManagementAPI
+	connectionID string
+
+	mutex  sync.Mutex
+	closed bool
+}
+
+// NewConnectionWriter returns a writer that sends messages to the WebSocket
+// connection identified by connectionID using api. The context is passed to
+// each call to api.
+func NewConnectionWriter(ctx context.Context, api ManagementAPI, connectionID string) *ConnectionWriter {
+	return &ConnectionWriter{
+		ctx:          ctx,
+		api:          api,
+		connectionID: connectionID,
+	}
+}
+
+// ConnectionID returns the ID of the WebSocket connection.
+func (w *ConnectionWriter) ConnectionID() string {
+	return w.connectionID
+}
+
+// Write sends p to the WebSocket client as a single message.
+func (w *ConnectionWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	closed := w.closed
+	w.mutex.Unlock()
+	if closed {
+		return 0, kv.NewError("connection writer is closed").With("connectionId", w.connectionID)
+	}
+	if err := w.api.PostToConnection(w.ctx, w.connectionID, p); err != nil {
+		return 0, kv.Wrap(err, "cannot post to connection").With("connectionId", w.connectionID)
+	}
+	return len(p), nil
+}
+
+// Close disconnects the WebSocket client. Calling Close more than once
+// has no effect.
+func (w *ConnectionWriter) Close() error {
+	w.mutex.Lock()
+	closed := w.closed
+	w.closed = true
+	w.mutex.Unlock()
+	if closed {
+		return nil
+	}
+	if err := w.api.DeleteConnection(w.ctx, w.connectionID); err != nil {
+		return kv.Wrap(err, "cannot delete connection").With("connectionId", w.connectionID)
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package apigatewayproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type fakeManagementAPI struct {
+	posted  []string
+	deleted []string
+	err     error
+}
+
+func (api *fakeManagementAPI) PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	if api.err != nil {
+		return api.err
+	}
+	api.posted = append(api.posted, connectionID+":"+string(data))
+	return nil
+}
+
+func (api *fakeManagementAPI) DeleteConnection(ctx context.Context, connectionID string) error {
+	if api.err != nil {
+		return api.err
+	}
+	api.deleted = append(api.deleted, connectionID)
+	return nil
+}
+
+func TestConnectionWriter(t *testing.T) {
+	api := &fakeManagementAPI{}
+	w := NewConnectionWriter(context.Background(), api, "conn-1")
+	fmt.Fprint(w, "hello")
+	fmt.Fprint(w, "world")
+	if err := w.Close(); err != nil {
+		t.Errorf("got %v, want no error", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("got %v, want no error", err)
+	}
+	if _, err := w.Write([]byte("closed")); err == nil {
+		t.Error("got no error, want error")
+	}
+	if got, want := api.posted, []string{"conn-1:hello", "conn-1:world"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+	if got, want := api.deleted, []string{"conn-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v, want=%v", got, want)
+	}
+
+	api.err = errors.New("gone")
+	w = NewConnectionWriter(context.Background(), api, "conn-2")
+	if n, err := w.Write([]byte("hello")); err == nil || n != 0 {
+		t.Errorf("got n=%d, err=%v, want error", n, err)
+	}
+	if !errors.Is(w.Close(), api.err) {
+		t.Error("want error to wrap the API error")
+	}
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if WebSocketRequest(r.Context()) == nil {
+			t.Error("got nil, want WebSocket request")
+		}
+		if Request(r.Context()) != nil {
+			t.Error("got proxy request, want nil")
+		}
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.Path, body)
+	})
+	handler := webSocketHandler(h, newOptions([]Option{
+		WithWebSocketRoute("sendmessage", "PUT", "/messages"),
+	}))
+
+	tests := []struct {
+		routeKey string
+		body     string
+		want     string
+	}{
+		{routeKey: "$connect", want: "POST /ws/connect "},
+		{routeKey: "$default", body: "hi", want: "POST /ws/default hi"},
+		{routeKey: "sendmessage", body: "hello", want: "PUT /messages hello"},
+		{routeKey: "subscribe", body: "x", want: "POST /ws/subscribe x"},
+	}
+	for i, tt := range tests {
+		var request events.APIGatewayWebsocketProxyRequest
+		request.RequestContext.RouteKey = tt.routeKey
+		request.RequestContext.ConnectionID = "conn-1"
+		request.Body = tt.body
+		response, err := handler(context.Background(), request)
+		if err != nil {
+			t.Errorf("%d: got %v, want no error", i, err)
+			continue
+		}
+		if got, want := response.StatusCode, 200; got != want {
+			t.Errorf("%d: got=%d, want=%d", i, got, want)
+		}
+		if got, want := response.Body, tt.want; got != want {
+			t.Errorf("%d: got=%q, want=%q", i, got, want)
+		}
+	}
+}
+
+func TestWebSocketConnect(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("result") {
+		case "upgrade":
+			w.WriteHeader(http.StatusSwitchingProtocols)
+		case "deny":
+			http.Error(w, "forbidden", http.StatusForbidden)
+		default:
+			AcceptSubprotocol(w, r, "graphql-ws", "json")
+		}
+	})
+	store := NewMemoryConnectionStore()
+	handler := webSocketHandler(h, newOptions([]Option{WithConnectionStore(store)}))
+
+	tests := []struct {
+		result       string
+		protocols    string
+		wantStatus   int
+		wantProtocol string
+		wantStored   bool
+	}{
+		{wantStatus: http.StatusOK, wantStored: true},
+		{result: "upgrade", wantStatus: http.StatusOK, wantStored: true},
+		{result: "deny", wantStatus: http.StatusForbidden},
+		{protocols: "mqtt, json, graphql-ws", wantStatus: http.StatusOK, wantProtocol: "json", wantStored: true},
+		{protocols: "mqtt", wantStatus: http.StatusOK, wantStored: true},
+	}
+	for i, tt := range tests {
+		var request events.APIGatewayWebsocketProxyRequest
+		request.RequestContext.RouteKey = "$connect"
+		request.RequestContext.EventType = "CONNECT"
+		request.RequestContext.ConnectionID = fmt.Sprintf("conn-%d", i)
+		request.QueryStringParameters = map[string]string{"result": tt.result}
+		if tt.protocols != "" {
+			request.Headers = map[string]string{"Sec-WebSocket-Protocol": tt.protocols}
+		}
+		response, err := handler(context.Background(), request)
+		if err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if got, want := response.StatusCode, tt.wantStatus; got != want {
+			t.Errorf("%d: got status %d, want %d", i, got, want)
+		}
+		if got, want := response.Headers[http.CanonicalHeaderKey(SubprotocolHeader)], tt.wantProtocol; got != want {
+			t.Errorf("%d: got subprotocol %q, want %q", i, got, want)
+		}
+		connections, _ := store.List(context.Background())
+		stored := false
+		for _, c := range connections {
+			stored = stored || c.ID == request.RequestContext.ConnectionID
+		}
+		if got, want := stored, tt.wantStored; got != want {
+			t.Errorf("%d: got stored=%v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestWebSocketContext(t *testing.T) {
+	tests := []struct {
+		domain string
+		apiID  string
+		region string
+		want   string
+	}{
+		{
+			domain: "abc123.execute-api.us-east-1.amazonaws.com",
+			apiID:  "abc123",
+			region: "us-east-1",
+			want:   "https://abc123.execute-api.us-east-1.amazonaws.com/prod",
+		},
+		{
+			domain: "ws.example.com",
+			apiID:  "abc123",
+			region: "eu-west-2",
+			want:   "https://abc123.execute-api.eu-west-2.amazonaws.com/prod",
+		},
+		{
+			domain: "localhost:3001",
+			want:   "https://localhost:3001/prod",
+		},
+	}
+	for i, tt := range tests {
+		t.Setenv("AWS_REGION", tt.region)
+		var request events.APIGatewayWebsocketProxyRequest
+		request.RequestContext.RouteKey = "sendmessage"
+		request.RequestContext.ConnectionID = "conn-1"
+		request.RequestContext.DomainName = tt.domain
+		request.RequestContext.APIID = tt.apiID
+		request.RequestContext.Stage = "prod"
+		var connectionID, routeKey, endpoint string
+		h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			connectionID, routeKey, endpoint = ConnectionID(ctx), RouteKey(ctx), ManagementEndpoint(ctx)
+		})
+		if _, err := webSocketHandler(h, newOptions(nil))(context.Background(), request); err != nil {
+			t.Fatalf("%d: got %v, want no error", i, err)
+		}
+		if connectionID != "conn-1" || routeKey != "sendmessage" {
+			t.Errorf("%d: got connection %q, route %q", i, connectionID, routeKey)
+		}
+		if got, want := endpoint, tt.want; got != want {
+			t.Errorf("%d: got endpoint %q, want %q", i, got, want)
+		}
+	}
+	ctx := context.Background()
+	if ConnectionID(ctx) != "" || RouteKey(ctx) != "" || ManagementEndpoint(ctx) != "" {
+		t.Error("got values for context without WebSocket event, want empty")
+	}
+}
+
+func TestWebSocketOffload(t *testing.T) {
+	large := make([]byte, MaxResponseSize+1)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(large)
+	})
+	uploader := &fakeUploader{}
+	handler := webSocketHandler(h, newOptions([]Option{WithResponseOffload(uploader, OffloadRedirect)}))
+	var request events.APIGatewayWebsocketProxyRequest
+	request.RequestContext.RouteKey = "$default"
+	request.RequestContext.RequestID = "request-id"
+	response, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got, want := response.StatusCode, http.StatusOK; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if got := uploader.key; got != "" {
+		t.Errorf("got upload %q, want none", got)
+	}
+}
+
+func TestWebSocketClientIP(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ClientIP(r) + "\n" + RateLimitKey(r)))
+	})
+	handler := webSocketHandler(h, newOptions(nil))
+	var request events.APIGatewayWebsocketProxyRequest
+	request.RequestContext.RouteKey = "$default"
+	request.RequestContext.Identity.SourceIP = "203.0.113.1"
+	response, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got, want := response.Body, "203.0.113.1\nip:203.0.113.1"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}
+
+func TestWebSocketTrailingSlash(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	})
+	o := newOptions([]Option{
+		WithWebSocketRoute("sendmessage", http.MethodPost, "/messages/"),
+		WithTrailingSlash(TrailingSlashRedirect),
+	})
+	handler := webSocketHandler(o.wrap(h), o)
+	var request events.APIGatewayWebsocketProxyRequest
+	request.RequestContext.RouteKey = "sendmessage"
+	response, err := handler(context.Background(), request)
+	if err != nil {
+		t.Fatalf("got %v, want no error", err)
+	}
+	if got, want := response.StatusCode, http.StatusOK; got != want {
+		t.Errorf("got=%d, want=%d", got, want)
+	}
+	if got, want := response.Body, "/messages"; got != want {
+		t.Errorf("got=%q, want=%q", got, want)
+	}
+}